@@ -26,6 +26,11 @@ type AddonOperatorSpec struct {
 	// when set to True
 	// +optional
 	Paused bool `json:"pause"`
+
+	// Default maintenance windows for Addons
+	// that do not specify their own .spec.maintenanceWindows.
+	// +optional
+	MaintenanceWindows []AddonMaintenanceWindow `json:"maintenanceWindows,omitempty"`
 }
 
 // AddonOperatorStatus defines the observed state of Addon
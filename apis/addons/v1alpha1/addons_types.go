@@ -1,6 +1,8 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -15,19 +17,156 @@ type AddonSpec struct {
 	Paused bool `json:"pause"`
 	// Defines a list of Kubernetes Namespaces that belong to this Addon.
 	// Namespaces listed here will be created prior to installation of the Addon and
-	// will be removed from the cluster when the Addon is deleted.
+	// will be handled according to their deletion policy when they are removed
+	// from this list or the Addon is deleted.
 	// Collisions with existing Namespaces are NOT allowed.
 	Namespaces []AddonNamespace `json:"namespaces,omitempty"`
 
+	// Default deletion policy of the Namespaces of this Addon,
+	// applying to all Namespaces that don't specify their own.
+	// +kubebuilder:default=Delete
+	// +kubebuilder:validation:Enum={"Delete","Orphan","Retain"}
+	// +optional
+	NamespaceDeletionPolicy AddonNamespaceDeletionPolicy `json:"namespaceDeletionPolicy,omitempty"`
+
 	// Defines how an Addon is installed.
 	// This field is immutable.
 	Install AddonInstallSpec `json:"install"`
 
+	// Other Addons that have to be Available before this Addon is installed.
+	// +optional
+	Dependencies []AddonDependency `json:"dependencies,omitempty"`
+
+	// Parameters to configure the Addon with.
+	// Parameters are rendered into a Secret in the install namespace,
+	// each parameter name becoming a key of the Secret.
+	// String values are stored as is, all other values are stored as JSON.
+	// +optional
+	Parameters map[string]apiextensionsv1.JSON `json:"parameters,omitempty"`
+
+	// Schema to validate .spec.parameters against.
+	// Parameters are validated by the admission webhook on create and update.
+	// +optional
+	ParametersSchema *AddonParametersSchema `json:"parametersSchema,omitempty"`
+
+	// Maintenance windows in which upgrades of the Addon may be installed.
+	// InstallPlans for upgrades are held until a window opens.
+	// The initial installation of the Addon is not bound to maintenance windows.
+	// If empty, the maintenance windows of the AddonOperator apply.
+	// +optional
+	MaintenanceWindows []AddonMaintenanceWindow `json:"maintenanceWindows,omitempty"`
+
+	// Secrets in the namespace of the Addon Operator used to pull
+	// the catalog and bundle images of the Addon from private registries.
+	// The Secrets are copied into the install namespace and kept in sync,
+	// referenced from the CatalogSources of the Addon
+	// and attached to the default ServiceAccount of the install namespace.
+	// +optional
+	PullSecrets []AddonPullSecret `json:"pullSecrets,omitempty"`
+
 	// ResourceAdoptionStrategy coordinates resource adoption for an Addon
 	// Originally introduced for coordinating fleetwide migration on OSD with pre-existing OLM objects.
 	// NOTE: This field is for internal usage only and not to be modified by the user.
+	// Deprecated: use .spec.adoption instead, AdoptAll is only honored while .spec.adoption is unset.
 	// +kubebuilder:validation:Enum={"Prevent","AdoptAll"}
 	ResourceAdoptionStrategy ResourceAdoptionStrategyType `json:"resourceAdoptionStrategy,omitempty"`
+
+	// Adoption configures whether existing objects that are not controlled by the Addon
+	// are adopted when the Addon reconciles an object of the same name.
+	// Applies to every kind of object reconciled for the Addon:
+	// Namespaces, OperatorGroups, CatalogSources, Subscriptions, Secrets and manifest objects.
+	// +optional
+	Adoption *AddonAdoption `json:"adoption,omitempty"`
+}
+
+// AddonAdoption defines the adoption policy for existing objects.
+type AddonAdoption struct {
+	// Policy for all kinds of objects without an override.
+	// +kubebuilder:default=Prevent
+	// +optional
+	Policy AddonAdoptionPolicy `json:"policy,omitempty"`
+	// Policies for individual kinds of objects, overriding Policy.
+	// +optional
+	Overrides []AddonAdoptionOverride `json:"overrides,omitempty"`
+}
+
+// AddonAdoptionOverride defines the adoption policy for one kind of objects.
+type AddonAdoptionOverride struct {
+	// Kind of the objects, e.g. "Namespace", "Subscription" or the kind of a manifest object.
+	// +kubebuilder:validation:MinLength=1
+	Kind string `json:"kind"`
+	// Policy for the objects of this kind.
+	Policy AddonAdoptionPolicy `json:"policy"`
+}
+
+// AddonAdoptionPolicy defines which existing objects are adopted.
+// +kubebuilder:validation:Enum={"Prevent","AdoptIfLabelled","AdoptAll"}
+type AddonAdoptionPolicy string
+
+const (
+	// Existing objects are never adopted, they collide with the objects of the Addon.
+	AdoptionPolicyPrevent AddonAdoptionPolicy = "Prevent"
+	// Existing objects are adopted if they carry the common labels of the Addon
+	// and are not controlled by another owner.
+	AdoptionPolicyAdoptIfLabelled AddonAdoptionPolicy = "AdoptIfLabelled"
+	// Existing objects are always adopted, taking over the control from any other owner.
+	AdoptionPolicyAdoptAll AddonAdoptionPolicy = "AdoptAll"
+)
+
+// AddonParametersSchema defines where the schema for Addon parameters is loaded from.
+// Exactly one of OpenAPIV3Schema and ConfigMapRef has to be set.
+type AddonParametersSchema struct {
+	// Inline OpenAPI v3 schema describing the parameters object.
+	// +optional
+	OpenAPIV3Schema *apiextensionsv1.JSON `json:"openAPIV3Schema,omitempty"`
+
+	// Reference to a ConfigMap holding an OpenAPI v3 schema
+	// describing the parameters object, in JSON or YAML format.
+	// +optional
+	ConfigMapRef *AddonParametersSchemaConfigMapReference `json:"configMapRef,omitempty"`
+}
+
+// AddonParametersSchemaConfigMapReference references a key within a ConfigMap.
+type AddonParametersSchemaConfigMapReference struct {
+	// Name of the ConfigMap.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Namespace of the ConfigMap.
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// Key within the ConfigMap holding the schema.
+	// +kubebuilder:default=schema
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// AddonPullSecret references an image pull Secret
+// in the namespace of the Addon Operator.
+type AddonPullSecret struct {
+	// Name of the Secret.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// AddonDependency references another Addon this Addon depends on.
+type AddonDependency struct {
+	// Name of the Addon.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// AddonMaintenanceWindow defines a recurring time window for upgrades.
+type AddonMaintenanceWindow struct {
+	// Cron expression describing when the window opens,
+	// in the standard 5 field format, e.g. "0 2 * * SAT".
+	// Times are interpreted in UTC, unless prefixed with "CRON_TZ=<time zone>".
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// How long the window stays open, e.g. "4h".
+	Duration metav1.Duration `json:"duration"`
 }
 
 type ResourceAdoptionStrategyType string
@@ -41,12 +180,118 @@ const (
 // AddonInstallSpec defines the desired Addon installation type.
 type AddonInstallSpec struct {
 	// Type of installation.
-	// +kubebuilder:validation:Enum={"OLMOwnNamespace","OLMAllNamespaces"}
+	// +kubebuilder:validation:Enum={"OLMOwnNamespace","OLMAllNamespaces","OLMMultiNamespace","OLMClusterExtension","Manifests","Helm"}
 	Type AddonInstallType `json:"type"`
 	// OLMAllNamespaces config parameters. Present only if Type = OLMAllNamespaces.
 	OLMAllNamespaces *AddonInstallOLMAllNamespaces `json:"olmAllNamespaces,omitempty"`
 	// OLMOwnNamespace config parameters. Present only if Type = OLMOwnNamespace.
 	OLMOwnNamespace *AddonInstallOLMOwnNamespace `json:"olmOwnNamespace,omitempty"`
+	// OLMMultiNamespace config parameters. Present only if Type = OLMMultiNamespace.
+	OLMMultiNamespace *AddonInstallOLMMultiNamespace `json:"olmMultiNamespace,omitempty"`
+	// OLMClusterExtension config parameters. Present only if Type = OLMClusterExtension.
+	OLMClusterExtension *AddonInstallOLMClusterExtension `json:"olmClusterExtension,omitempty"`
+	// Manifests config parameters. Present only if Type = Manifests.
+	Manifests *AddonInstallManifests `json:"manifests,omitempty"`
+	// Helm config parameters. Present only if Type = Helm.
+	Helm *AddonInstallHelm `json:"helm,omitempty"`
+}
+
+// Helm specific Addon installation parameters.
+// Charts with CustomResourceDefinitions are refused.
+type AddonInstallHelm struct {
+	// Namespace to install the chart into.
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// Chart to install.
+	Chart AddonHelmChart `json:"chart"`
+
+	// Values to render the chart with, overriding the default values of the chart.
+	// +optional
+	Values *apiextensionsv1.JSON `json:"values,omitempty"`
+
+	// Use the Addon parameters, which are also rendered into the parameters Secret, as values.
+	// Each parameter becomes a top-level value, taking precedence over .values.
+	// +optional
+	ValuesFromParameters bool `json:"valuesFromParameters,omitempty"`
+}
+
+// AddonHelmChart references a chart in a Helm chart repository.
+type AddonHelmChart struct {
+	// URL of the Helm chart repository, serving an index.yaml.
+	// +kubebuilder:validation:MinLength=1
+	RepositoryURL string `json:"repositoryURL"`
+
+	// Name of the chart.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Version of the chart.
+	// +kubebuilder:validation:MinLength=1
+	Version string `json:"version"`
+}
+
+// ClusterExtension specific Addon installation parameters.
+// Field names match AddonInstallOLMCommon, so Addons can move between install types easily.
+type AddonInstallOLMClusterExtension struct {
+	// Namespace to install the Addon into.
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// Defines the ClusterCatalog image.
+	// Please only use digests and no tags here!
+	// +kubebuilder:validation:MinLength=1
+	CatalogSourceImage string `json:"catalogSourceImage"`
+
+	// Channel to install the package from.
+	// +optional
+	Channel string `json:"channel,omitempty"`
+
+	// Name of the package to install via OLM.
+	// OLM will resove this package name to install the matching bundle.
+	// +kubebuilder:validation:MinLength=1
+	PackageName string `json:"packageName"`
+
+	// Version or version range of the package to install.
+	// If empty, OLM installs the latest version in the Channel.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Name of the ServiceAccount in Namespace used by OLM to install the package.
+	// The ServiceAccount needs permissions to manage all objects of the package.
+	// +kubebuilder:validation:MinLength=1
+	ServiceAccountName string `json:"serviceAccountName"`
+}
+
+// Manifests specific Addon installation parameters.
+// Exactly one of Image and ConfigMapRef has to be set.
+type AddonInstallManifests struct {
+	// Namespace to install namespaced objects into,
+	// if they don't specify a namespace themselves.
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// OCI image containing the manifests.
+	// All .yaml, .yml and .json files in the image are applied.
+	// Please only use digests and no tags here!
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Reference to a ConfigMap containing the manifests.
+	// All keys ending in .yaml, .yml or .json are applied.
+	// +optional
+	ConfigMapRef *AddonManifestsConfigMapReference `json:"configMapRef,omitempty"`
+}
+
+// AddonManifestsConfigMapReference references a ConfigMap.
+type AddonManifestsConfigMapReference struct {
+	// Name of the ConfigMap.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Namespace of the ConfigMap.
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
 }
 
 // Common Addon installation parameters.
@@ -68,6 +313,139 @@ type AddonInstallOLMCommon struct {
 	// OLM will resove this package name to install the matching bundle.
 	// +kubebuilder:validation:MinLength=1
 	PackageName string `json:"packageName"`
+
+	// Name of the ClusterServiceVersion to start the installation from.
+	// If empty, OLM installs the latest version in the Channel.
+	// +optional
+	StartingCSV string `json:"startingCSV,omitempty"`
+
+	// Controls which InstallPlans of the Addon are approved.
+	// If unset, the InstallPlanApproval of the Subscription is left unmanaged.
+	// +optional
+	UpgradePolicy *AddonUpgradePolicy `json:"upgradePolicy,omitempty"`
+
+	// Configuration passed to the OLM Subscription,
+	// to customize the operator deployment of the Addon.
+	// Only applies to the main package, not to the additional packages.
+	// +optional
+	Config *SubscriptionConfig `json:"config,omitempty"`
+
+	// Controls how OLM discovers updates of the catalog images.
+	// If unset, catalog images are only pulled when the CatalogSources are created,
+	// so rebuilt images behind the same tag are never picked up.
+	// +optional
+	CatalogUpdatePolicy *AddonCatalogUpdatePolicy `json:"catalogUpdatePolicy,omitempty"`
+
+	// Additional OLM packages installed into the same Namespace,
+	// e.g. companion operators published in a different index.
+	// Each package gets its own CatalogSource and Subscription,
+	// the Addon is only Available when all of them are installed.
+	// The config of the main package is not passed to their Subscriptions.
+	// +optional
+	AdditionalPackages []AddonInstallOLMPackage `json:"additionalPackages,omitempty"`
+
+	// Controls how OperatorGroups in the install Namespace that are not owned by the Addon are handled.
+	// OLM can not install operators into a Namespace with more than one OperatorGroup.
+	// Report only reports them as a configuration error,
+	// Adopt takes over a single foreign OperatorGroup in place of the OperatorGroup of the Addon
+	// and Remove deletes them.
+	// +kubebuilder:default=Report
+	// +kubebuilder:validation:Enum={"Report","Adopt","Remove"}
+	// +optional
+	ForeignOperatorGroupPolicy AddonForeignOperatorGroupPolicy `json:"foreignOperatorGroupPolicy,omitempty"`
+}
+
+// AddonForeignOperatorGroupPolicy defines how foreign OperatorGroups in the install Namespace are handled.
+type AddonForeignOperatorGroupPolicy string
+
+const (
+	ForeignOperatorGroupPolicyReport AddonForeignOperatorGroupPolicy = "Report"
+	ForeignOperatorGroupPolicyAdopt  AddonForeignOperatorGroupPolicy = "Adopt"
+	ForeignOperatorGroupPolicyRemove AddonForeignOperatorGroupPolicy = "Remove"
+)
+
+// AddonCatalogUpdatePolicy defines how OLM polls the catalog images of an Addon for updates.
+type AddonCatalogUpdatePolicy struct {
+	// Interval in which OLM polls the catalog images for updates, e.g. "45m".
+	// Applies to the CatalogSources of all packages of the Addon.
+	Interval metav1.Duration `json:"interval"`
+}
+
+// AddonInstallOLMPackage is an additional OLM package of an Addon.
+type AddonInstallOLMPackage struct {
+	// Name of the package to install via OLM.
+	// +kubebuilder:validation:MinLength=1
+	PackageName string `json:"packageName"`
+
+	// Channel for the Subscription object.
+	// +kubebuilder:validation:MinLength=1
+	Channel string `json:"channel"`
+
+	// Defines the CatalogSource image to install the package from.
+	// Please only use digests and no tags here!
+	// +kubebuilder:validation:MinLength=1
+	CatalogSourceImage string `json:"catalogSourceImage"`
+
+	// Name of the ClusterServiceVersion to start the installation from.
+	// If empty, OLM installs the latest version in the Channel.
+	// +optional
+	StartingCSV string `json:"startingCSV,omitempty"`
+}
+
+// AddonUpgradePolicy defines which InstallPlans are approved for an Addon.
+type AddonUpgradePolicy struct {
+	// Type of upgrade policy.
+	// Automatic approves all InstallPlans.
+	// Manual approves only the initial installation and holds all upgrades.
+	// ApproveUpTo approves InstallPlans up to and including the given version.
+	// +kubebuilder:validation:Enum={"Automatic","Manual","ApproveUpTo"}
+	Type AddonUpgradePolicyType `json:"type"`
+
+	// Highest version to approve, required if Type is ApproveUpTo.
+	// +optional
+	Version string `json:"version,omitempty"`
+}
+
+type AddonUpgradePolicyType string
+
+const (
+	// Approves every InstallPlan of the Addon.
+	UpgradePolicyAutomatic AddonUpgradePolicyType = "Automatic"
+	// Approves the initial InstallPlan and holds all upgrades.
+	UpgradePolicyManual AddonUpgradePolicyType = "Manual"
+	// Approves InstallPlans up to and including .version.
+	UpgradePolicyApproveUpTo AddonUpgradePolicyType = "ApproveUpTo"
+)
+
+// SubscriptionConfig maps to the OLM Subscription .spec.config.
+type SubscriptionConfig struct {
+	// Env is a list of environment variables to set in the operator container.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// EnvFrom is a list of sources to populate environment variables in the operator container.
+	// +optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
+	// Resources represents compute resources required by the operator container.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// NodeSelector must match a node's labels for the operator pod to be scheduled on that node.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of the operator pod.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Volumes is a list of volumes to add to the operator pod.
+	// +optional
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+
+	// VolumeMounts is a list of volumes to mount into the operator container.
+	// +optional
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
 }
 
 // AllNamespaces specific Addon installation parameters.
@@ -80,6 +458,16 @@ type AddonInstallOLMOwnNamespace struct {
 	AddonInstallOLMCommon `json:",inline"`
 }
 
+// MultiNamespace specific Addon installation parameters.
+type AddonInstallOLMMultiNamespace struct {
+	AddonInstallOLMCommon `json:",inline"`
+
+	// Namespaces the Operator watches.
+	// Each Namespace has to be listed in .spec.namespaces or already exist.
+	// +kubebuilder:validation:MinItems=1
+	TargetNamespaces []string `json:"targetNamespaces"`
+}
+
 type AddonInstallType string
 
 const (
@@ -92,6 +480,22 @@ const (
 	// The Operator will only watch and be made available for use in this single namespace.
 	// Maps directly to the OLM install mode "specific namespace"
 	OLMOwnNamespace AddonInstallType = "OLMOwnNamespace"
+	// Installs the operator into a specific namespace.
+	// The Operator will watch and be made available for use in the given set of target namespaces.
+	// Maps directly to the OLM install mode "multi namespace"
+	OLMMultiNamespace AddonInstallType = "OLMMultiNamespace"
+	// Installs the operator via a ClusterCatalog and ClusterExtension of OLM v1,
+	// instead of a CatalogSource and Subscription.
+	OLMClusterExtension AddonInstallType = "OLMClusterExtension"
+	// Applies plain Kubernetes manifests, loaded from an OCI image or a ConfigMap.
+	// Only ConfigMaps, Secrets, Services, ServiceAccounts, Deployments, StatefulSets,
+	// DaemonSets and Jobs are supported. Does not involve OLM.
+	Manifests AddonInstallType = "Manifests"
+	// Renders a Helm chart and applies the resulting objects.
+	// Supports the same kinds as Manifests, so charts must not contain
+	// CustomResourceDefinitions (crds/) or RBAC objects. Chart hooks are not supported.
+	// Does not involve OLM.
+	Helm AddonInstallType = "Helm"
 )
 
 // Addon condition reasons
@@ -118,16 +522,88 @@ const (
 	// Addon has unready namespaces
 	AddonReasonUnreadyNamespaces = "UnreadyNamespaces"
 
+	// Addon has namespaces that are stuck in the Terminating phase
+	AddonReasonStuckNamespaces = "StuckNamespaces"
+
 	// Addon has unready CSV
 	AddonReasonUnreadyCSV = "UnreadyCSV"
+
+	// Addon is waiting for its dependencies to become Available
+	AddonReasonDependenciesNotReady = "DependenciesNotReady"
+
+	// Addon has an unready Subscription
+	AddonReasonUnreadySubscription = "UnreadySubscription"
+
+	// OLM failed to resolve the Subscription of the Addon
+	AddonReasonSubscriptionResolutionFailed = "SubscriptionResolutionFailed"
+
+	// CatalogSources used to resolve the Subscription of the Addon are unhealthy
+	AddonReasonCatalogSourcesUnhealthy = "CatalogSourcesUnhealthy"
+
+	// The InstallPlan of the Addon failed
+	AddonReasonInstallPlanFailed = "InstallPlanFailed"
+
+	// Deployments of the Addon operator are unavailable or crash-looping
+	AddonReasonUnhealthyDeployments = "UnhealthyDeployments"
+
+	// Addon has unready manifests
+	AddonReasonUnreadyManifests = "UnreadyManifests"
+
+	// Addon manifests collide with existing objects
+	// Deprecated: collisions are reported with the AdoptionPrevented,
+	// NotLabelledForAdoption and ControlledByOther reasons.
+	AddonReasonCollidedManifests = "CollidedManifests"
+
+	// An existing object collides with an object of the Addon
+	// and the Prevent adoption policy forbids adopting it
+	AddonReasonAdoptionPrevented = "AdoptionPrevented"
+
+	// An existing object collides with an object of the Addon
+	// and is not labelled for adoption by the AdoptIfLabelled policy
+	AddonReasonNotLabelledForAdoption = "NotLabelledForAdoption"
+
+	// An existing object collides with an object of the Addon
+	// and is controlled by another owner, which the AdoptIfLabelled policy does not take over
+	AddonReasonControlledByOther = "ControlledByOther"
+
+	// Addon has an unready ClusterCatalog
+	AddonReasonUnreadyClusterCatalog = "UnreadyClusterCatalog"
+
+	// Addon has an unready ClusterExtension
+	AddonReasonUnreadyClusterExtension = "UnreadyClusterExtension"
+
+	// Phase of the Addon reconciliation is ready
+	AddonReasonReady = "Ready"
+
+	// Phase of the Addon reconciliation has not been reported yet
+	AddonReasonUnknown = "Unknown"
 )
 
 type AddonNamespace struct {
 	// Name of the KubernetesNamespace.
 	// +kubebuilder:validation:MinLength=1
 	Name string `json:"name"`
+	// What happens to the Namespace when it is removed from the Addon or the Addon is deleted.
+	// Defaults to .spec.namespaceDeletionPolicy.
+	// +kubebuilder:validation:Enum={"Delete","Orphan","Retain"}
+	// +optional
+	DeletionPolicy AddonNamespaceDeletionPolicy `json:"deletionPolicy,omitempty"`
 }
 
+type AddonNamespaceDeletionPolicy string
+
+const (
+	// The Namespace is deleted, including everything in it.
+	NamespaceDeletionPolicyDelete AddonNamespaceDeletionPolicy = "Delete"
+	// The Namespace is kept and released from the Addon,
+	// the labels and owner reference of the Addon are removed.
+	NamespaceDeletionPolicyOrphan AddonNamespaceDeletionPolicy = "Orphan"
+	// The Namespace is kept and listed in .status.retainedNamespaces of the Addon.
+	// Only the owner reference of the Addon is removed,
+	// so the Namespace is adopted again when it is added back to the Addon.
+	NamespaceDeletionPolicyRetain AddonNamespaceDeletionPolicy = "Retain"
+)
+
 const (
 	// Available condition indicates that all resources for the Addon are reconciled and healthy
 	Available = "Available"
@@ -136,6 +612,51 @@ const (
 	Paused = "Paused"
 )
 
+// Addon phase condition types.
+// Available is computed from these conditions.
+const (
+	// NamespacesReady condition indicates that all Namespaces of the Addon exist and are Active
+	NamespacesReady = "NamespacesReady"
+
+	// ParametersReady condition indicates that the parameters Secret of the Addon is reconciled,
+	// or removed if the Addon has no parameters
+	ParametersReady = "ParametersReady"
+
+	// PullSecretsReady condition indicates that the pull Secrets of the Addon are copied
+	// into the install Namespace
+	PullSecretsReady = "PullSecretsReady"
+
+	// DependenciesReady condition indicates that all dependencies of the Addon are Available
+	DependenciesReady = "DependenciesReady"
+
+	// OperatorGroupReady condition indicates that the OperatorGroup of the Addon is reconciled
+	OperatorGroupReady = "OperatorGroupReady"
+
+	// CatalogSourceReady condition indicates that the CatalogSource of the Addon is reconciled and serving
+	CatalogSourceReady = "CatalogSourceReady"
+
+	// SubscriptionReady condition indicates that the Subscription of the Addon is reconciled
+	// and has a ClusterServiceVersion linked
+	SubscriptionReady = "SubscriptionReady"
+
+	// CSVSucceeded condition indicates that the current ClusterServiceVersion of the Addon has succeeded
+	CSVSucceeded = "CSVSucceeded"
+
+	// DeploymentsAvailable condition indicates that the Deployments of the install strategy
+	// of the ClusterServiceVersions of the Addon are available and not crash-looping
+	DeploymentsAvailable = "DeploymentsAvailable"
+
+	// ManifestsReady condition indicates that all objects of a Manifests or Helm Addon are applied and healthy
+	ManifestsReady = "ManifestsReady"
+
+	// ClusterCatalogReady condition indicates that the ClusterCatalog of the Addon is reconciled and serving
+	ClusterCatalogReady = "ClusterCatalogReady"
+
+	// ClusterExtensionInstalled condition indicates that the ClusterExtension of the Addon
+	// has installed a bundle
+	ClusterExtensionInstalled = "ClusterExtensionInstalled"
+)
+
 // AddonStatus defines the observed state of Addon
 type AddonStatus struct {
 	// The most recent generation observed by the controller.
@@ -146,6 +667,163 @@ type AddonStatus struct {
 	// it will go away as soon as kubectl can print conditions!
 	// Human readable status - please use .Conditions from code
 	Phase AddonPhase `json:"phase,omitempty"`
+	// Revision of the Addon parameters that have been rendered into
+	// the parameters Secret in the install namespace.
+	// +optional
+	ParametersRevision string `json:"parametersRevision,omitempty"`
+	// Upgrade that is held back by the Addon upgrade policy.
+	// +optional
+	PendingUpgrade *AddonPendingUpgrade `json:"pendingUpgrade,omitempty"`
+	// Maintenance window that is currently open or opens next.
+	// +optional
+	NextMaintenanceWindow *AddonMaintenanceWindowStatus `json:"nextMaintenanceWindow,omitempty"`
+	// Objects applied for a Manifests or Helm Addon.
+	// Objects that are no longer part of the manifests are pruned.
+	// +optional
+	ManifestObjects []AddonObjectReference `json:"manifestObjects,omitempty"`
+	// Helm release of a Helm Addon.
+	// +optional
+	HelmRelease *AddonHelmReleaseStatus `json:"helmRelease,omitempty"`
+	// Bundle installed by OLM, the ClusterServiceVersion or the bundle
+	// installed by the ClusterExtension of an OLMClusterExtension Addon.
+	// +optional
+	InstalledBundle *AddonInstalledBundle `json:"installedBundle,omitempty"`
+	// Catalog images served by the CatalogSources of an OLM Addon,
+	// one for the main package followed by one for each additional package.
+	// +optional
+	Catalogs []AddonCatalogStatus `json:"catalogs,omitempty"`
+	// Namespaces that were removed from the Addon, but kept because of their Retain deletion policy.
+	// +optional
+	RetainedNamespaces []string `json:"retainedNamespaces,omitempty"`
+	// Namespaces of the Addon that are stuck in the Terminating phase
+	// and what blocks their finalization.
+	// +optional
+	StuckNamespaces []AddonStuckNamespace `json:"stuckNamespaces,omitempty"`
+	// Existing objects adopted by the Addon according to its adoption policy.
+	// Objects that are gone or no longer controlled by the Addon are removed from the list.
+	// +optional
+	AdoptedObjects []AddonObjectReference `json:"adoptedObjects,omitempty"`
+	// Progress of the teardown of a deleted Addon.
+	// +optional
+	Teardown *AddonTeardownStatus `json:"teardown,omitempty"`
+}
+
+// AddonStuckNamespace describes why a terminating Namespace can not be finalized,
+// as reported by the status conditions of the Namespace.
+type AddonStuckNamespace struct {
+	// Name of the Namespace.
+	Name string `json:"name"`
+	// Resources remaining in the Namespace, e.g. "foos.example.com has 2 resource instances".
+	// +optional
+	RemainingResources []string `json:"remainingResources,omitempty"`
+	// Finalizers remaining on resources in the Namespace,
+	// e.g. "example.com/cleanup in 2 resource instances".
+	// +optional
+	RemainingFinalizers []string `json:"remainingFinalizers,omitempty"`
+	// Errors of the namespace controller while deleting the content of the Namespace,
+	// e.g. failed discovery of unavailable API services.
+	// +optional
+	Failures []string `json:"failures,omitempty"`
+}
+
+// AddonTeardownStatus describes the progress of the teardown of a deleted Addon.
+// The finalizer of the Addon is removed after the last stage completed.
+type AddonTeardownStatus struct {
+	// Stage of the teardown that is in progress.
+	// +kubebuilder:validation:Enum={"Subscriptions","ClusterServiceVersions","CatalogSources","ClusterExtension","ClusterCatalog","ManifestObjects","Namespaces","Completed"}
+	Stage AddonTeardownStage `json:"stage"`
+	// Objects the stage is waiting for to be deleted.
+	// +optional
+	PendingObjects []AddonObjectReference `json:"pendingObjects,omitempty"`
+	// ClusterServiceVersions installed and currently resolved by the Subscriptions of the Addon,
+	// recorded before the Subscriptions are deleted.
+	// +optional
+	ClusterServiceVersions []AddonObjectReference `json:"clusterServiceVersions,omitempty"`
+}
+
+type AddonTeardownStage string
+
+const (
+	// The Subscriptions of all OLM packages are deleted,
+	// so OLM stops installing and upgrading the Addon.
+	AddonTeardownStageSubscriptions AddonTeardownStage = "Subscriptions"
+	// The installed and current ClusterServiceVersions are deleted.
+	AddonTeardownStageClusterServiceVersions AddonTeardownStage = "ClusterServiceVersions"
+	// The CatalogSources and the OperatorGroup are deleted.
+	AddonTeardownStageCatalogSources AddonTeardownStage = "CatalogSources"
+	// The ClusterExtension of an OLMClusterExtension Addon is deleted,
+	// so OLM v1 uninstalls the bundle.
+	AddonTeardownStageClusterExtension AddonTeardownStage = "ClusterExtension"
+	// The ClusterCatalog of an OLMClusterExtension Addon is deleted.
+	AddonTeardownStageClusterCatalog AddonTeardownStage = "ClusterCatalog"
+	// The objects applied for a Manifests or Helm Addon are deleted.
+	AddonTeardownStageManifestObjects AddonTeardownStage = "ManifestObjects"
+	// The Namespaces of the Addon are deleted.
+	AddonTeardownStageNamespaces AddonTeardownStage = "Namespaces"
+	// Everything is deleted, the finalizer is removed.
+	AddonTeardownStageCompleted AddonTeardownStage = "Completed"
+)
+
+// AddonCatalogStatus describes the catalog image served by a CatalogSource of an Addon.
+type AddonCatalogStatus struct {
+	// Name of the CatalogSource.
+	CatalogSource string `json:"catalogSource"`
+	// Digest of the catalog image that is currently served.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+	// When OLM last polled the catalog image for updates.
+	// +optional
+	LastPollTime *metav1.Time `json:"lastPollTime,omitempty"`
+	// When a new catalog image digest was last picked up.
+	// +optional
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// AddonInstalledBundle describes a bundle installed by OLM.
+type AddonInstalledBundle struct {
+	// Name of the bundle.
+	Name string `json:"name"`
+	// Version of the bundle.
+	Version string `json:"version"`
+}
+
+// AddonHelmReleaseStatus describes the applied revision of a Helm release.
+type AddonHelmReleaseStatus struct {
+	// Revision of the release, incremented with every upgrade.
+	Revision int `json:"revision"`
+	// Version of the applied chart.
+	ChartVersion string `json:"chartVersion"`
+	// Hash of the chart version and values the revision was rendered with.
+	ConfigHash string `json:"configHash"`
+}
+
+// AddonObjectReference references an object managed for an Addon.
+type AddonObjectReference struct {
+	// APIVersion of the object.
+	APIVersion string `json:"apiVersion"`
+	// Kind of the object.
+	Kind string `json:"kind"`
+	// Name of the object.
+	Name string `json:"name"`
+	// Namespace of the object, empty for cluster-scoped objects.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// AddonMaintenanceWindowStatus describes a single occurrence of a maintenance window.
+type AddonMaintenanceWindowStatus struct {
+	// Time the maintenance window opens.
+	Start metav1.Time `json:"start"`
+	// Time the maintenance window closes.
+	End metav1.Time `json:"end"`
+}
+
+// AddonPendingUpgrade describes an InstallPlan awaiting approval.
+type AddonPendingUpgrade struct {
+	// Name of the InstallPlan awaiting approval.
+	InstallPlanName string `json:"installPlanName"`
+	// ClusterServiceVersions that will be installed by the InstallPlan.
+	ClusterServiceVersionNames []string `json:"clusterServiceVersionNames,omitempty"`
 }
 
 type AddonPhase string
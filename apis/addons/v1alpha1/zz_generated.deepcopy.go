@@ -5,6 +5,8 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -36,10 +38,170 @@ func (in *Addon) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonAdoption) DeepCopyInto(out *AddonAdoption) {
+	*out = *in
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = make([]AddonAdoptionOverride, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonAdoption.
+func (in *AddonAdoption) DeepCopy() *AddonAdoption {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonAdoption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonAdoptionOverride) DeepCopyInto(out *AddonAdoptionOverride) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonAdoptionOverride.
+func (in *AddonAdoptionOverride) DeepCopy() *AddonAdoptionOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonAdoptionOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonCatalogStatus) DeepCopyInto(out *AddonCatalogStatus) {
+	*out = *in
+	if in.LastPollTime != nil {
+		in, out := &in.LastPollTime, &out.LastPollTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastUpdateTime != nil {
+		in, out := &in.LastUpdateTime, &out.LastUpdateTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonCatalogStatus.
+func (in *AddonCatalogStatus) DeepCopy() *AddonCatalogStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonCatalogStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonCatalogUpdatePolicy) DeepCopyInto(out *AddonCatalogUpdatePolicy) {
+	*out = *in
+	out.Interval = in.Interval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonCatalogUpdatePolicy.
+func (in *AddonCatalogUpdatePolicy) DeepCopy() *AddonCatalogUpdatePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonCatalogUpdatePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonDependency) DeepCopyInto(out *AddonDependency) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonDependency.
+func (in *AddonDependency) DeepCopy() *AddonDependency {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonDependency)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonHelmChart) DeepCopyInto(out *AddonHelmChart) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonHelmChart.
+func (in *AddonHelmChart) DeepCopy() *AddonHelmChart {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonHelmChart)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonHelmReleaseStatus) DeepCopyInto(out *AddonHelmReleaseStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonHelmReleaseStatus.
+func (in *AddonHelmReleaseStatus) DeepCopy() *AddonHelmReleaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonHelmReleaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonInstallHelm) DeepCopyInto(out *AddonInstallHelm) {
+	*out = *in
+	out.Chart = in.Chart
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonInstallHelm.
+func (in *AddonInstallHelm) DeepCopy() *AddonInstallHelm {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonInstallHelm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonInstallManifests) DeepCopyInto(out *AddonInstallManifests) {
+	*out = *in
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(AddonManifestsConfigMapReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonInstallManifests.
+func (in *AddonInstallManifests) DeepCopy() *AddonInstallManifests {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonInstallManifests)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AddonInstallOLMAllNamespaces) DeepCopyInto(out *AddonInstallOLMAllNamespaces) {
 	*out = *in
-	out.AddonInstallOLMCommon = in.AddonInstallOLMCommon
+	in.AddonInstallOLMCommon.DeepCopyInto(&out.AddonInstallOLMCommon)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonInstallOLMAllNamespaces.
@@ -52,9 +214,44 @@ func (in *AddonInstallOLMAllNamespaces) DeepCopy() *AddonInstallOLMAllNamespaces
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonInstallOLMClusterExtension) DeepCopyInto(out *AddonInstallOLMClusterExtension) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonInstallOLMClusterExtension.
+func (in *AddonInstallOLMClusterExtension) DeepCopy() *AddonInstallOLMClusterExtension {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonInstallOLMClusterExtension)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AddonInstallOLMCommon) DeepCopyInto(out *AddonInstallOLMCommon) {
 	*out = *in
+	if in.UpgradePolicy != nil {
+		in, out := &in.UpgradePolicy, &out.UpgradePolicy
+		*out = new(AddonUpgradePolicy)
+		**out = **in
+	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = new(SubscriptionConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CatalogUpdatePolicy != nil {
+		in, out := &in.CatalogUpdatePolicy, &out.CatalogUpdatePolicy
+		*out = new(AddonCatalogUpdatePolicy)
+		**out = **in
+	}
+	if in.AdditionalPackages != nil {
+		in, out := &in.AdditionalPackages, &out.AdditionalPackages
+		*out = make([]AddonInstallOLMPackage, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonInstallOLMCommon.
@@ -67,10 +264,31 @@ func (in *AddonInstallOLMCommon) DeepCopy() *AddonInstallOLMCommon {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonInstallOLMMultiNamespace) DeepCopyInto(out *AddonInstallOLMMultiNamespace) {
+	*out = *in
+	in.AddonInstallOLMCommon.DeepCopyInto(&out.AddonInstallOLMCommon)
+	if in.TargetNamespaces != nil {
+		in, out := &in.TargetNamespaces, &out.TargetNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonInstallOLMMultiNamespace.
+func (in *AddonInstallOLMMultiNamespace) DeepCopy() *AddonInstallOLMMultiNamespace {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonInstallOLMMultiNamespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AddonInstallOLMOwnNamespace) DeepCopyInto(out *AddonInstallOLMOwnNamespace) {
 	*out = *in
-	out.AddonInstallOLMCommon = in.AddonInstallOLMCommon
+	in.AddonInstallOLMCommon.DeepCopyInto(&out.AddonInstallOLMCommon)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonInstallOLMOwnNamespace.
@@ -83,19 +301,54 @@ func (in *AddonInstallOLMOwnNamespace) DeepCopy() *AddonInstallOLMOwnNamespace {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonInstallOLMPackage) DeepCopyInto(out *AddonInstallOLMPackage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonInstallOLMPackage.
+func (in *AddonInstallOLMPackage) DeepCopy() *AddonInstallOLMPackage {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonInstallOLMPackage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AddonInstallSpec) DeepCopyInto(out *AddonInstallSpec) {
 	*out = *in
 	if in.OLMAllNamespaces != nil {
 		in, out := &in.OLMAllNamespaces, &out.OLMAllNamespaces
 		*out = new(AddonInstallOLMAllNamespaces)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.OLMOwnNamespace != nil {
 		in, out := &in.OLMOwnNamespace, &out.OLMOwnNamespace
 		*out = new(AddonInstallOLMOwnNamespace)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OLMMultiNamespace != nil {
+		in, out := &in.OLMMultiNamespace, &out.OLMMultiNamespace
+		*out = new(AddonInstallOLMMultiNamespace)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OLMClusterExtension != nil {
+		in, out := &in.OLMClusterExtension, &out.OLMClusterExtension
+		*out = new(AddonInstallOLMClusterExtension)
 		**out = **in
 	}
+	if in.Manifests != nil {
+		in, out := &in.Manifests, &out.Manifests
+		*out = new(AddonInstallManifests)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Helm != nil {
+		in, out := &in.Helm, &out.Helm
+		*out = new(AddonInstallHelm)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonInstallSpec.
@@ -108,6 +361,21 @@ func (in *AddonInstallSpec) DeepCopy() *AddonInstallSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonInstalledBundle) DeepCopyInto(out *AddonInstalledBundle) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonInstalledBundle.
+func (in *AddonInstalledBundle) DeepCopy() *AddonInstalledBundle {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonInstalledBundle)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AddonList) DeepCopyInto(out *AddonList) {
 	*out = *in
@@ -140,6 +408,54 @@ func (in *AddonList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonMaintenanceWindow) DeepCopyInto(out *AddonMaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonMaintenanceWindow.
+func (in *AddonMaintenanceWindow) DeepCopy() *AddonMaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonMaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonMaintenanceWindowStatus) DeepCopyInto(out *AddonMaintenanceWindowStatus) {
+	*out = *in
+	in.Start.DeepCopyInto(&out.Start)
+	in.End.DeepCopyInto(&out.End)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonMaintenanceWindowStatus.
+func (in *AddonMaintenanceWindowStatus) DeepCopy() *AddonMaintenanceWindowStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonMaintenanceWindowStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonManifestsConfigMapReference) DeepCopyInto(out *AddonManifestsConfigMapReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonManifestsConfigMapReference.
+func (in *AddonManifestsConfigMapReference) DeepCopy() *AddonManifestsConfigMapReference {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonManifestsConfigMapReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AddonNamespace) DeepCopyInto(out *AddonNamespace) {
 	*out = *in
@@ -155,12 +471,27 @@ func (in *AddonNamespace) DeepCopy() *AddonNamespace {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonObjectReference) DeepCopyInto(out *AddonObjectReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonObjectReference.
+func (in *AddonObjectReference) DeepCopy() *AddonObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AddonOperator) DeepCopyInto(out *AddonOperator) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -217,6 +548,11 @@ func (in *AddonOperatorList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AddonOperatorSpec) DeepCopyInto(out *AddonOperatorSpec) {
 	*out = *in
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]AddonMaintenanceWindow, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonOperatorSpec.
@@ -252,6 +588,81 @@ func (in *AddonOperatorStatus) DeepCopy() *AddonOperatorStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonParametersSchema) DeepCopyInto(out *AddonParametersSchema) {
+	*out = *in
+	if in.OpenAPIV3Schema != nil {
+		in, out := &in.OpenAPIV3Schema, &out.OpenAPIV3Schema
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(AddonParametersSchemaConfigMapReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonParametersSchema.
+func (in *AddonParametersSchema) DeepCopy() *AddonParametersSchema {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonParametersSchema)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonParametersSchemaConfigMapReference) DeepCopyInto(out *AddonParametersSchemaConfigMapReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonParametersSchemaConfigMapReference.
+func (in *AddonParametersSchemaConfigMapReference) DeepCopy() *AddonParametersSchemaConfigMapReference {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonParametersSchemaConfigMapReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonPendingUpgrade) DeepCopyInto(out *AddonPendingUpgrade) {
+	*out = *in
+	if in.ClusterServiceVersionNames != nil {
+		in, out := &in.ClusterServiceVersionNames, &out.ClusterServiceVersionNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonPendingUpgrade.
+func (in *AddonPendingUpgrade) DeepCopy() *AddonPendingUpgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonPendingUpgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonPullSecret) DeepCopyInto(out *AddonPullSecret) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonPullSecret.
+func (in *AddonPullSecret) DeepCopy() *AddonPullSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonPullSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AddonSpec) DeepCopyInto(out *AddonSpec) {
 	*out = *in
@@ -261,6 +672,38 @@ func (in *AddonSpec) DeepCopyInto(out *AddonSpec) {
 		copy(*out, *in)
 	}
 	in.Install.DeepCopyInto(&out.Install)
+	if in.Dependencies != nil {
+		in, out := &in.Dependencies, &out.Dependencies
+		*out = make([]AddonDependency, len(*in))
+		copy(*out, *in)
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]apiextensionsv1.JSON, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.ParametersSchema != nil {
+		in, out := &in.ParametersSchema, &out.ParametersSchema
+		*out = new(AddonParametersSchema)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]AddonMaintenanceWindow, len(*in))
+		copy(*out, *in)
+	}
+	if in.PullSecrets != nil {
+		in, out := &in.PullSecrets, &out.PullSecrets
+		*out = make([]AddonPullSecret, len(*in))
+		copy(*out, *in)
+	}
+	if in.Adoption != nil {
+		in, out := &in.Adoption, &out.Adoption
+		*out = new(AddonAdoption)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonSpec.
@@ -283,6 +726,60 @@ func (in *AddonStatus) DeepCopyInto(out *AddonStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PendingUpgrade != nil {
+		in, out := &in.PendingUpgrade, &out.PendingUpgrade
+		*out = new(AddonPendingUpgrade)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NextMaintenanceWindow != nil {
+		in, out := &in.NextMaintenanceWindow, &out.NextMaintenanceWindow
+		*out = new(AddonMaintenanceWindowStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ManifestObjects != nil {
+		in, out := &in.ManifestObjects, &out.ManifestObjects
+		*out = make([]AddonObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.HelmRelease != nil {
+		in, out := &in.HelmRelease, &out.HelmRelease
+		*out = new(AddonHelmReleaseStatus)
+		**out = **in
+	}
+	if in.InstalledBundle != nil {
+		in, out := &in.InstalledBundle, &out.InstalledBundle
+		*out = new(AddonInstalledBundle)
+		**out = **in
+	}
+	if in.Catalogs != nil {
+		in, out := &in.Catalogs, &out.Catalogs
+		*out = make([]AddonCatalogStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RetainedNamespaces != nil {
+		in, out := &in.RetainedNamespaces, &out.RetainedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StuckNamespaces != nil {
+		in, out := &in.StuckNamespaces, &out.StuckNamespaces
+		*out = make([]AddonStuckNamespace, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AdoptedObjects != nil {
+		in, out := &in.AdoptedObjects, &out.AdoptedObjects
+		*out = make([]AddonObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Teardown != nil {
+		in, out := &in.Teardown, &out.Teardown
+		*out = new(AddonTeardownStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonStatus.
@@ -294,3 +791,135 @@ func (in *AddonStatus) DeepCopy() *AddonStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonStuckNamespace) DeepCopyInto(out *AddonStuckNamespace) {
+	*out = *in
+	if in.RemainingResources != nil {
+		in, out := &in.RemainingResources, &out.RemainingResources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RemainingFinalizers != nil {
+		in, out := &in.RemainingFinalizers, &out.RemainingFinalizers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Failures != nil {
+		in, out := &in.Failures, &out.Failures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonStuckNamespace.
+func (in *AddonStuckNamespace) DeepCopy() *AddonStuckNamespace {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonStuckNamespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonTeardownStatus) DeepCopyInto(out *AddonTeardownStatus) {
+	*out = *in
+	if in.PendingObjects != nil {
+		in, out := &in.PendingObjects, &out.PendingObjects
+		*out = make([]AddonObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterServiceVersions != nil {
+		in, out := &in.ClusterServiceVersions, &out.ClusterServiceVersions
+		*out = make([]AddonObjectReference, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonTeardownStatus.
+func (in *AddonTeardownStatus) DeepCopy() *AddonTeardownStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonTeardownStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonUpgradePolicy) DeepCopyInto(out *AddonUpgradePolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonUpgradePolicy.
+func (in *AddonUpgradePolicy) DeepCopy() *AddonUpgradePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonUpgradePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubscriptionConfig) DeepCopyInto(out *SubscriptionConfig) {
+	*out = *in
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnvFrom != nil {
+		in, out := &in.EnvFrom, &out.EnvFrom
+		*out = make([]corev1.EnvFromSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]corev1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]corev1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubscriptionConfig.
+func (in *SubscriptionConfig) DeepCopy() *SubscriptionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SubscriptionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
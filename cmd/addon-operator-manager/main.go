@@ -18,8 +18,14 @@ import (
 
 	aoapis "github.com/openshift/addon-operator/apis"
 	"github.com/openshift/addon-operator/internal/controllers"
+	"github.com/openshift/addon-operator/internal/helm"
+	"github.com/openshift/addon-operator/internal/manifests"
+	"github.com/openshift/addon-operator/internal/metrics"
 )
 
+// Namespace the operator is deployed into by default.
+const defaultOperatorNamespace = "addon-operator"
+
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
@@ -38,6 +44,7 @@ func main() {
 		pprofAddr            string
 		enableLeaderElection bool
 		probeAddr            string
+		operatorNamespace    string
 	)
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&pprofAddr, "pprof-addr", "", "The address the pprof web endpoint binds to.")
@@ -46,7 +53,12 @@ func main() {
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081",
 		"The address the probe endpoint binds to.")
+	flag.StringVar(&operatorNamespace, "namespace", os.Getenv("ADDON_OPERATOR_NAMESPACE"),
+		"The namespace the operator runs in, pull Secrets of Addons are copied from here.")
 	flag.Parse()
+	if len(operatorNamespace) == 0 {
+		operatorNamespace = defaultOperatorNamespace
+	}
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
 
@@ -111,9 +123,16 @@ func main() {
 	}
 
 	addonReconciler := &controllers.AddonReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Addon"),
-		Scheme: mgr.GetScheme(),
+		Client:      mgr.GetClient(),
+		Log:         ctrl.Log.WithName("controllers").WithName("Addon"),
+		Scheme:      mgr.GetScheme(),
+		Recorder:    mgr.GetEventRecorderFor("addon-operator"),
+		Metrics:     metrics.NewRecorder(true),
+		ImagePuller: manifests.NewImagePuller(nil),
+		ChartLoader: helm.NewChartLoader(nil),
+
+		OperatorNamespace: operatorNamespace,
+		APIReader:         mgr.GetAPIReader(),
 	}
 
 	if err = addonReconciler.SetupWithManager(mgr); err != nil {
@@ -125,6 +144,7 @@ func main() {
 		Client:             mgr.GetClient(),
 		Log:                ctrl.Log.WithName("controllers").WithName("AddonOperator"),
 		Scheme:             mgr.GetScheme(),
+		Recorder:           mgr.GetEventRecorderFor("addon-operator"),
 		GlobalPauseManager: addonReconciler,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "AddonOperator")
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -21,6 +22,7 @@ var (
 )
 
 func init() {
+	_ = clientgoscheme.AddToScheme(scheme)
 	_ = aoapis.AddToScheme(scheme)
 }
 
@@ -67,8 +69,9 @@ func main() {
 	wbh := mgr.GetWebhookServer()
 	wbh.Register("/validate-addon", &webhook.Admission{
 		Handler: &webhooks.AddonWebhookHandler{
-			Log:    log.Log.WithName("validating webhooks").WithName("Addon"),
-			Client: mgr.GetClient(),
+			Log:       log.Log.WithName("validating webhooks").WithName("Addon"),
+			Client:    mgr.GetClient(),
+			APIReader: mgr.GetAPIReader(),
 		},
 	})
 
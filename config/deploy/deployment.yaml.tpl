@@ -21,6 +21,11 @@ spec:
         image: quay.io/openshift/addon-operator:latest
         args:
         - --enable-leader-election
+        env:
+        - name: ADDON_OPERATOR_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
         livenessProbe:
           httpGet:
             path: /healthz
@@ -3,14 +3,24 @@ module github.com/openshift/addon-operator
 go 1.16
 
 require (
+	github.com/blang/semver/v4 v4.0.0
 	github.com/go-logr/logr v0.4.0
 	github.com/operator-framework/api v0.8.1
-	github.com/stretchr/testify v1.6.1
-	k8s.io/api v0.20.2
-	k8s.io/apiextensions-apiserver v0.20.1
+	github.com/prometheus/client_golang v1.7.1
+	github.com/robfig/cron/v3 v3.0.1
+	github.com/stretchr/testify v1.7.0
+	helm.sh/helm/v3 v3.5.4
+	k8s.io/api v0.20.4
+	k8s.io/apiextensions-apiserver v0.20.4
 	k8s.io/apimachinery v0.21.0
-	k8s.io/client-go v0.20.2
+	k8s.io/client-go v0.20.4
 	k8s.io/utils v0.0.0-20210111153108-fddb29f9d009
 	sigs.k8s.io/controller-runtime v0.8.3
 	sigs.k8s.io/yaml v1.2.0
 )
+
+// Required by helm.sh/helm/v3, see its go.mod.
+replace (
+	github.com/docker/distribution => github.com/docker/distribution v0.0.0-20191216044856-a8371794149d
+	github.com/docker/docker => github.com/moby/moby v17.12.0-ce-rc1.0.20200618181300-9dc6525e6118+incompatible
+)
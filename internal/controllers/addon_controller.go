@@ -9,17 +9,31 @@ import (
 	"github.com/go-logr/logr"
 	operatorsv1 "github.com/operator-framework/api/pkg/operators/v1"
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
 	internalhandler "github.com/openshift/addon-operator/internal/handler"
+	"github.com/openshift/addon-operator/internal/metrics"
 )
 
 // Default timeout when we do a manual RequeueAfter
@@ -30,10 +44,26 @@ const (
 
 type AddonReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	Metrics  *metrics.Recorder
+	// Namespace the Addon Operator runs in, pull Secrets of Addons are copied from here.
+	OperatorNamespace string
+	// Reads objects that are not cached, like the Deployments and Pods of ClusterServiceVersions.
+	// Defaults to the Client.
+	APIReader client.Reader
+	// Pulls manifests of Manifests Addons from OCI images.
+	ImagePuller imagePuller
+	// Loads charts of Helm Addons from chart repositories.
+	ChartLoader helmChartLoader
 
 	csvEventHandler csvEventHandler
+	catalogPods     catalogPodLister
+	events          eventDeduplicator
+	installers      map[addonsv1alpha1.AddonInstallType]Installer
+	installersOnce  sync.Once
+	installersMux   sync.RWMutex
 	globalPause     bool
 	globalPauseMux  sync.RWMutex
 	addonRequeueCh  chan event.GenericEvent
@@ -53,6 +83,7 @@ func (r *AddonReconciler) setGlobalPause(ctx context.Context, paused bool) error
 	r.globalPauseMux.Lock()
 	defer r.globalPauseMux.Unlock()
 	r.globalPause = paused
+	r.Metrics.SetGlobalPause(paused)
 
 	if err := r.requeueAllAddons(ctx); err != nil {
 		return fmt.Errorf("requeue all Addons: %w", err)
@@ -72,6 +103,20 @@ func (r *AddonReconciler) requeueAllAddons(ctx context.Context) error {
 	return nil
 }
 
+// Records an Event for the given Addon, dropping repetitions.
+func (r *AddonReconciler) recordEvent(
+	addon *addonsv1alpha1.Addon, eventType, reason, messageFmt string, args ...interface{}) {
+	r.events.Eventf(r.Recorder, addon, eventType, reason, messageFmt, args...)
+}
+
+// Returns the reader for objects that are not cached.
+func (r *AddonReconciler) uncachedReader() client.Reader {
+	if r.APIReader == nil {
+		return r.Client
+	}
+	return r.APIReader
+}
+
 type csvEventHandler interface {
 	handler.EventHandler
 	Free(addon *addonsv1alpha1.Addon)
@@ -81,21 +126,116 @@ type csvEventHandler interface {
 func (r *AddonReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.csvEventHandler = internalhandler.NewCSVEventHandler()
 	r.addonRequeueCh = make(chan event.GenericEvent)
-	return ctrl.NewControllerManagedBy(mgr).
+
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return fmt.Errorf("creating clientset: %w", err)
+	}
+	catalogPods := newCatalogPodInformer(clientset)
+	if err := mgr.Add(catalogPods); err != nil {
+		return fmt.Errorf("adding catalog Pod informer: %w", err)
+	}
+	r.catalogPods = catalogPods
+
+	b := ctrl.NewControllerManagedBy(mgr)
+
+	// OLM v1 is optional, only watch its APIs when they are served.
+	for _, gvk := range []schema.GroupVersionKind{clusterCatalogGVK, clusterExtensionGVK} {
+		if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			if isNoMatchError(err) {
+				continue
+			}
+			return fmt.Errorf("checking for %s API: %w", gvk.Kind, err)
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		b = b.Owns(obj)
+	}
+
+	// Pull Secrets are only read from the operator namespace.
+	pullSecretOpts := []builder.WatchesOption{
+		builder.OnlyMetadata,
+		builder.WithPredicates(predicate.NewPredicateFuncs(r.isInOperatorNamespace)),
+	}
+
+	return b.
 		For(&addonsv1alpha1.Addon{}).
 		Owns(&corev1.Namespace{}).
+		// Secrets are only cached by metadata, their data is read from the API server.
+		Owns(&corev1.Secret{}, builder.OnlyMetadata).
+		// Objects applied for Manifests and Helm Addons, to revert drift.
+		Owns(&corev1.ConfigMap{}, builder.OnlyMetadata).
+		Owns(&corev1.Service{}, builder.OnlyMetadata).
+		Owns(&corev1.ServiceAccount{}, builder.OnlyMetadata).
+		Owns(&appsv1.Deployment{}, builder.OnlyMetadata).
+		Owns(&appsv1.StatefulSet{}, builder.OnlyMetadata).
+		Owns(&appsv1.DaemonSet{}, builder.OnlyMetadata).
+		Owns(&batchv1.Job{}, builder.OnlyMetadata).
 		Owns(&operatorsv1.OperatorGroup{}).
 		Owns(&operatorsv1alpha1.CatalogSource{}).
 		Owns(&operatorsv1alpha1.Subscription{}).
 		Watches(&source.Kind{
 			Type: &operatorsv1alpha1.ClusterServiceVersion{},
 		}, r.csvEventHandler).
+		Watches(&source.Kind{ // Requeue Addons when the status of a dependency changes.
+			Type: &addonsv1alpha1.Addon{},
+		}, handler.EnqueueRequestsFromMapFunc(r.enqueueDependentAddons)).
+		Watches(&source.Kind{ // Requeue Addons when the default maintenance windows change.
+			Type: &addonsv1alpha1.AddonOperator{},
+		}, handler.EnqueueRequestsFromMapFunc(r.enqueueAddonsForAddonOperator),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{
+			Type: &operatorsv1alpha1.InstallPlan{},
+		}, handler.EnqueueRequestsFromMapFunc(r.enqueueAddonForInstallPlan)).
+		Watches(&source.Kind{ // Requeue Addons when their pull Secrets are rotated.
+			Type: &corev1.Secret{},
+		}, handler.EnqueueRequestsFromMapFunc(r.enqueueAddonsForPullSecret), pullSecretOpts...).
+		Watches(&source.Kind{ // Requeue Manifests Addons when their ConfigMap changes.
+			Type: &corev1.ConfigMap{},
+		}, handler.EnqueueRequestsFromMapFunc(r.enqueueAddonsForManifestsConfigMap), builder.OnlyMetadata).
 		Watches(&source.Channel{ // Requeue everything when entering/leaving global pause.
 			Source: r.addonRequeueCh,
 		}, &handler.EnqueueRequestForObject{}).
 		Complete(r)
 }
 
+// Maps InstallPlans to the Addon controlling the Subscription that the InstallPlan was created for.
+// Subscriptions of additional packages are not named after their Addon,
+// so the Addon is looked up from the controller reference of the Subscription.
+func (r *AddonReconciler) enqueueAddonForInstallPlan(obj client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+	for _, ownerRef := range obj.GetOwnerReferences() {
+		if ownerRef.Kind != operatorsv1alpha1.SubscriptionKind {
+			continue
+		}
+
+		subscription := &operatorsv1alpha1.Subscription{}
+		err := r.Get(context.Background(), client.ObjectKey{
+			Name:      ownerRef.Name,
+			Namespace: obj.GetNamespace(),
+		}, subscription)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			r.Log.Error(err, "getting Subscription to enqueue for InstallPlan",
+				"installplan", client.ObjectKeyFromObject(obj).String())
+			continue
+		}
+
+		controllerRef := metav1.GetControllerOf(subscription)
+		if controllerRef == nil ||
+			controllerRef.Kind != "Addon" ||
+			controllerRef.APIVersion != addonsv1alpha1.GroupVersion.String() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: controllerRef.Name},
+		})
+	}
+	return requests
+}
+
 // AddonReconciler/Controller entrypoint
 func (r *AddonReconciler) Reconcile(
 	ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -103,20 +243,37 @@ func (r *AddonReconciler) Reconcile(
 
 	addon := &addonsv1alpha1.Addon{}
 	err := r.Get(ctx, req.NamespacedName, addon)
+	if apierrors.IsNotFound(err) {
+		r.Metrics.ForgetAddon(req.Name)
+		return ctrl.Result{}, nil
+	}
 	if err != nil {
-		return ctrl.Result{}, client.IgnoreNotFound(err)
+		return ctrl.Result{}, err
 	}
+	defer r.Metrics.RecordAddon(addon)
+
+	phaseTimer := r.Metrics.NewPhaseTimer()
+	defer phaseTimer.Done()
 
 	// check for global pause
 	r.globalPauseMux.RLock()
 	defer r.globalPauseMux.RUnlock()
 	if r.globalPause {
-		err = r.reportAddonPauseStatus(ctx, addonsv1alpha1.AddonOperatorReasonPaused,
-			addon)
-		if err != nil {
+		// Continue to report status in a read-only mode,
+		// nothing on the cluster is created, updated or deleted.
+		if err := r.observeAddon(ctx, addon); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to observe Addon: %w", err)
+		}
+		r.setAddonPauseCondition(addon, addonsv1alpha1.AddonOperatorReasonPaused)
+		if err := r.reportPhaseStatus(ctx, addon); err != nil {
 			return ctrl.Result{}, err
 		}
-		// TODO: figure out how we can continue to report status
+		if !meta.IsStatusConditionTrue(addon.Status.Conditions, addonsv1alpha1.Available) {
+			log.Info("requeuing", "reason", "observing unavailable Addon while paused")
+			return ctrl.Result{
+				RequeueAfter: defaultRetryAfterTime,
+			}, nil
+		}
 		return ctrl.Result{}, nil
 	}
 
@@ -136,7 +293,16 @@ func (r *AddonReconciler) Reconcile(
 	}
 
 	if !addon.DeletionTimestamp.IsZero() {
-		return ctrl.Result{}, r.handleAddonDeletion(ctx, addon)
+		requeue, err := r.handleAddonDeletion(ctx, addon)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if requeue {
+			return ctrl.Result{
+				RequeueAfter: defaultRetryAfterTime,
+			}, nil
+		}
+		return ctrl.Result{}, nil
 	}
 
 	// Phase 0.
@@ -150,6 +316,7 @@ func (r *AddonReconciler) Reconcile(
 
 	// Phase 1.
 	// Ensure wanted namespaces
+	phaseTimer.Phase("ensure_namespaces")
 	if stopAndRetry, err := r.ensureWantedNamespaces(ctx, addon); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to ensure wanted Namespaces: %w", err)
 	} else if stopAndRetry {
@@ -160,61 +327,91 @@ func (r *AddonReconciler) Reconcile(
 
 	// Phase 2.
 	// Ensure unwanted namespaces are removed
+	phaseTimer.Phase("delete_unwanted_namespaces")
 	if err := r.ensureDeletionOfUnwantedNamespaces(ctx, addon); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to ensure deletion of unwanted Namespaces: %w", err)
 	}
 
 	// Phase 3.
-	// Ensure OperatorGroup
-	if stop, err := r.ensureOperatorGroup(ctx, log, addon); err != nil {
-		return ctrl.Result{}, fmt.Errorf("failed to ensure OperatorGroup: %w", err)
-	} else if stop {
-		return ctrl.Result{}, nil
-	}
-
-	// Phase 4.
-	ensureResult, catalogSource, err := r.ensureCatalogSource(ctx, log, addon)
+	// Ensure parameters Secret
+	phaseTimer.Phase("ensure_parameters_secret")
+	parametersSecretResult, err := r.ensureParametersSecret(ctx, log, addon)
 	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("failed to ensure CatalogSource: %w", err)
+		return ctrl.Result{}, fmt.Errorf("failed to ensure parameters Secret: %w", err)
 	}
-	switch ensureResult {
-	case ensureCatalogSourceResultRetry:
-		log.Info("requeuing", "reason", "catalogsource unready")
+	switch parametersSecretResult {
+	case ensureParametersSecretResultRetry:
 		return ctrl.Result{
 			RequeueAfter: defaultRetryAfterTime,
 		}, nil
-	case ensureCatalogSourceResultStop:
+	case ensureParametersSecretResultStop:
 		return ctrl.Result{}, nil
 	}
 
-	// Phase 5.
-	// Ensure Subscription for this Addon.
-	currentCSVKey, requeue, err := r.ensureSubscription(
-		ctx, log.WithName("phase-ensure-subscription"),
-		addon, catalogSource)
+	// Ensure pull Secrets
+	phaseTimer.Phase("ensure_pull_secrets")
+	pullSecretsResult, err := r.ensurePullSecrets(ctx, log, addon)
 	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("failed to ensure Subscription: %w", err)
-	} else if requeue {
+		return ctrl.Result{}, fmt.Errorf("failed to ensure pull Secrets: %w", err)
+	}
+	switch pullSecretsResult {
+	case ensurePullSecretsResultRetry:
 		return ctrl.Result{
 			RequeueAfter: defaultRetryAfterTime,
 		}, nil
+	case ensurePullSecretsResultStop:
+		return ctrl.Result{}, nil
 	}
 
-	// Phase 6.
-	// Observe current csv
-	if requeue, err := r.observeCurrentCSV(ctx, addon, currentCSVKey); err != nil {
-		return ctrl.Result{}, fmt.Errorf("failed to observe current CSV: %w", err)
-	} else if requeue {
-		log.Info("requeuing", "reason", "csv unready")
+	// Phase 4.
+	// Wait for dependencies to become Available
+	phaseTimer.Phase("ensure_dependencies")
+	if stop, err := r.ensureDependencies(ctx, log, addon); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to ensure dependencies: %w", err)
+	} else if stop {
+		return ctrl.Result{}, nil
+	}
+
+	// Phase 5+.
+	// Install the Addon
+	installer := r.installerFor(addon)
+	if installer == nil {
+		// parseAddonInstallConfig already stopped the previous phases
+		return ctrl.Result{}, nil
+	}
+	installResult, err := installer.Ensure(ctx, log, addon, phaseTimer)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to install Addon: %w", err)
+	}
+	switch installResult {
+	case InstallResultRetry:
 		return ctrl.Result{
 			RequeueAfter: defaultRetryAfterTime,
 		}, nil
+	case InstallResultStop:
+		return ctrl.Result{}, nil
+	}
+
+	phaseTimer.Done()
+	if installStatus := installer.Status(addon); len(installStatus.PackageName) > 0 {
+		r.Metrics.RecordAddonInfo(addon, installStatus.PackageName,
+			installStatus.Channel, installStatus.InstalledVersion)
 	}
 
 	// After last phase and if everything is healthy
+	if err := r.pruneAdoptedObjects(ctx, addon); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to prune adopted objects: %w", err)
+	}
 	if err = r.reportReadinessStatus(ctx, addon); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to report readiness status: %w", err)
 	}
 
+	// Requeue for the next maintenance window to approve held upgrades.
+	if requeueAfter := maintenanceWindowRequeueAfter(addon, time.Now()); requeueAfter > 0 {
+		log.Info("requeuing", "reason", "upgrade held until maintenance window",
+			"maintenanceWindowStart", addon.Status.NextMaintenanceWindow.Start)
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+
 	return ctrl.Result{}, nil
 }
@@ -7,9 +7,11 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -31,7 +33,10 @@ type AddonOperatorReconciler struct {
 	client.Client
 	Log                logr.Logger
 	Scheme             *runtime.Scheme
+	Recorder           record.EventRecorder
 	GlobalPauseManager globalPauseManager
+
+	events eventDeduplicator
 }
 
 func (r *AddonOperatorReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -98,6 +103,8 @@ func (r *AddonOperatorReconciler) handleGlobalPause(
 		if err := r.reportAddonOperatorPauseStatus(ctx, addonOperator); err != nil {
 			return fmt.Errorf("report AddonOperator paused: %w", err)
 		}
+		r.events.Eventf(r.Recorder, addonOperator, corev1.EventTypeNormal, eventReasonPaused,
+			"Reconciliation of all Addons paused")
 		return nil
 	}
 
@@ -112,5 +119,7 @@ func (r *AddonOperatorReconciler) handleGlobalPause(
 	if err := r.removeAddonOperatorPauseCondition(ctx, addonOperator); err != nil {
 		return fmt.Errorf("remove AddonOperator paused: %w", err)
 	}
+	r.events.Eventf(r.Recorder, addonOperator, corev1.EventTypeNormal, eventReasonUnpaused,
+		"Reconciliation of all Addons resumed")
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -13,43 +14,151 @@ import (
 )
 
 // Handle the deletion of an Addon.
+// The installation is torn down first and the Namespaces of the Addon afterwards,
+// each stage is waited on and reported in the Addon status.
+// Returns true while waiting for a stage, the finalizer is removed when all stages completed.
 func (r *AddonReconciler) handleAddonDeletion(
 	ctx context.Context, addon *addonsv1alpha1.Addon,
-) error {
+) (requeue bool, err error) {
 	if !controllerutil.ContainsFinalizer(addon, cacheFinalizer) {
 		// The finalizer is already gone and the deletion timestamp is set.
 		// kube-apiserver should have garbage collected this object already,
 		// this delete signal does not need further processing.
-		return nil
+		return false, nil
 	}
 
-	if err := r.reportTerminationStatus(ctx, addon); err != nil {
-		return fmt.Errorf("failed reporting terminiation status: %w", err)
+	done, err := r.teardownAddon(ctx, addon)
+	if err != nil {
+		return false, err
+	}
+	if !done {
+		r.recordEvent(addon, corev1.EventTypeNormal, eventReasonTeardownProgressing,
+			"Waiting for %s to be deleted", addon.Status.Teardown.Stage)
+		if err := r.reportTerminationStatus(ctx, addon); err != nil {
+			return false, fmt.Errorf("failed reporting terminiation status: %w", err)
+		}
+		return true, nil
 	}
 
-	// Clear from CSV Event Handler
-	r.csvEventHandler.Free(addon)
+	setTeardownStage(addon, addonsv1alpha1.AddonTeardownStageCompleted, nil)
+	if err := r.reportTerminationStatus(ctx, addon); err != nil {
+		return false, fmt.Errorf("failed reporting terminiation status: %w", err)
+	}
 
 	controllerutil.RemoveFinalizer(addon, cacheFinalizer)
 	if err := r.Update(ctx, addon); err != nil {
-		return fmt.Errorf("failed to remove finalizer: %w", err)
+		return false, fmt.Errorf("failed to remove finalizer: %w", err)
 	}
+	r.recordEvent(addon, corev1.EventTypeNormal, eventReasonFinalizerRemoved,
+		"Removed finalizer %q", cacheFinalizer)
+	r.events.Free(addon.UID)
+
+	return false, nil
+}
 
-	return nil
+// Runs the teardown stages of the Installer followed by the Namespaces stage.
+// Returns false while a stage is still waiting for objects to be deleted.
+func (r *AddonReconciler) teardownAddon(
+	ctx context.Context, addon *addonsv1alpha1.Addon) (done bool, err error) {
+	if installer := r.installerFor(addon); installer != nil {
+		log := r.Log.WithValues("addon", addon.Name)
+		result, err := installer.Teardown(ctx, log, addon)
+		if err != nil {
+			return false, err
+		}
+		if result == InstallResultRetry {
+			return false, nil
+		}
+	}
+
+	done, err = r.teardownNamespaces(ctx, addon)
+	if err != nil {
+		return false, fmt.Errorf("failed to tear down Namespaces: %w", err)
+	}
+	return done, nil
+}
+
+// Phase conditions of an Addon in the order they are reconciled,
+// the ones of its Installer follow the common phases.
+func (r *AddonReconciler) addonPhaseConditionTypes(addon *addonsv1alpha1.Addon) []string {
+	conditionTypes := []string{
+		addonsv1alpha1.NamespacesReady,
+		addonsv1alpha1.ParametersReady,
+		addonsv1alpha1.PullSecretsReady,
+		addonsv1alpha1.DependenciesReady,
+	}
+	if installer := r.installerFor(addon); installer != nil {
+		conditionTypes = append(conditionTypes, installer.Status(addon).ConditionTypes...)
+	}
+	return conditionTypes
 }
 
 // Report Addon status to communicate that everything is alright
 func (r *AddonReconciler) reportReadinessStatus(
 	ctx context.Context, addon *addonsv1alpha1.Addon) error {
+	return r.reportPhaseStatus(ctx, addon)
+}
+
+// Report Addon status computed from the phase conditions.
+func (r *AddonReconciler) reportPhaseStatus(
+	ctx context.Context, addon *addonsv1alpha1.Addon) error {
+	r.setAvailableCondition(addon)
+	addon.Status.ObservedGeneration = addon.Generation
+	return r.Status().Update(ctx, addon)
+}
+
+// Sets a phase condition of the Addon.
+// Phases report their condition on every reconcile,
+// Available is computed from them by (*AddonReconciler).setAvailableCondition.
+func setPhaseCondition(
+	addon *addonsv1alpha1.Addon, conditionType string,
+	status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&addon.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: addon.Generation,
+	})
+}
+
+// Computes the Available condition from the phase conditions.
+// The Addon is Available if all phase conditions are True,
+// otherwise the first phase condition that is not True is reported.
+func (r *AddonReconciler) setAvailableCondition(addon *addonsv1alpha1.Addon) {
+	for _, conditionType := range r.addonPhaseConditionTypes(addon) {
+		cond := meta.FindStatusCondition(addon.Status.Conditions, conditionType)
+		if cond == nil {
+			meta.SetStatusCondition(&addon.Status.Conditions, metav1.Condition{
+				Type:               addonsv1alpha1.Available,
+				Status:             metav1.ConditionFalse,
+				Reason:             addonsv1alpha1.AddonReasonUnknown,
+				Message:            fmt.Sprintf("%s has not been reported yet", conditionType),
+				ObservedGeneration: addon.Generation,
+			})
+			addon.Status.Phase = addonsv1alpha1.PhasePending
+			return
+		}
+		if cond.Status != metav1.ConditionTrue {
+			meta.SetStatusCondition(&addon.Status.Conditions, metav1.Condition{
+				Type:               addonsv1alpha1.Available,
+				Status:             metav1.ConditionFalse,
+				Reason:             cond.Reason,
+				Message:            cond.Message,
+				ObservedGeneration: addon.Generation,
+			})
+			addon.Status.Phase = addonsv1alpha1.PhasePending
+			return
+		}
+	}
+
 	meta.SetStatusCondition(&addon.Status.Conditions, metav1.Condition{
 		Type:               addonsv1alpha1.Available,
 		Status:             metav1.ConditionTrue,
 		Reason:             addonsv1alpha1.AddonReasonFullyReconciled,
 		ObservedGeneration: addon.Generation,
 	})
-	addon.Status.ObservedGeneration = addon.Generation
 	addon.Status.Phase = addonsv1alpha1.PhaseReady
-	return r.Status().Update(ctx, addon)
 }
 
 // Report Addon status to communicate that the Addon is terminating
@@ -59,6 +168,7 @@ func (r *AddonReconciler) reportTerminationStatus(
 		Type:               addonsv1alpha1.Available,
 		Status:             metav1.ConditionFalse,
 		Reason:             addonsv1alpha1.AddonReasonTerminating,
+		Message:            teardownMessage(addon),
 		ObservedGeneration: addon.Generation,
 	})
 	addon.Status.ObservedGeneration = addon.Generation
@@ -86,6 +196,20 @@ func (r *AddonReconciler) reportConfigurationError(
 func (r *AddonReconciler) reportAddonPauseStatus(
 	ctx context.Context, reason string,
 	addon *addonsv1alpha1.Addon) error {
+	r.setAddonPauseCondition(addon, reason)
+	addon.Status.ObservedGeneration = addon.Generation
+	addon.Status.Phase = addonsv1alpha1.PhaseReady
+	return r.Status().Update(ctx, addon)
+}
+
+// Sets the Paused condition of the Addon.
+func (r *AddonReconciler) setAddonPauseCondition(
+	addon *addonsv1alpha1.Addon, reason string) {
+	message := "Reconciliation paused"
+	if reason == addonsv1alpha1.AddonOperatorReasonPaused {
+		message = "Reconciliation of all Addons paused by the AddonOperator"
+	}
+	r.recordEvent(addon, corev1.EventTypeNormal, eventReasonPaused, "%s", message)
 	meta.SetStatusCondition(&addon.Status.Conditions, metav1.Condition{
 		Type:               addonsv1alpha1.Paused,
 		Status:             metav1.ConditionTrue,
@@ -93,58 +217,29 @@ func (r *AddonReconciler) reportAddonPauseStatus(
 		Message:            "",
 		ObservedGeneration: addon.Generation,
 	})
-	addon.Status.ObservedGeneration = addon.Generation
-	addon.Status.Phase = addonsv1alpha1.PhaseReady
-	return r.Status().Update(ctx, addon)
 }
 
 // remove Paused condition from Addon
 func (r *AddonReconciler) removeAddonPauseCondition(ctx context.Context,
 	addon *addonsv1alpha1.Addon) error {
+	if meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.Paused) != nil {
+		r.recordEvent(addon, corev1.EventTypeNormal, eventReasonUnpaused,
+			"Reconciliation resumed")
+	}
 	meta.RemoveStatusCondition(&addon.Status.Conditions, addonsv1alpha1.Paused)
 	addon.Status.ObservedGeneration = addon.Generation
 	addon.Status.Phase = addonsv1alpha1.PhaseReady
 	return r.Status().Update(ctx, addon)
 }
 
-// Validate addon.Spec.Install then extract
+// Validate addon.Spec.Install with the Installer of the Addon, then extract
 // targetNamespace and catalogSourceImage from it
 func (r *AddonReconciler) parseAddonInstallConfig(
 	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon) (
 	targetNamespace, catalogSourceImage string, stop bool, err error,
 ) {
-	switch addon.Spec.Install.Type {
-	case addonsv1alpha1.OLMOwnNamespace:
-		if addon.Spec.Install.OLMOwnNamespace == nil ||
-			len(addon.Spec.Install.OLMOwnNamespace.Namespace) == 0 {
-			// invalid/missing configuration
-			return "", "", true, r.reportConfigurationError(ctx, addon,
-				".spec.install.ownNamespace.namespace is required when .spec.install.type = OwnNamespace")
-		}
-		targetNamespace = addon.Spec.Install.OLMOwnNamespace.Namespace
-		if len(addon.Spec.Install.OLMOwnNamespace.CatalogSourceImage) == 0 {
-			// invalid/missing configuration
-			return "", "", true, r.reportConfigurationError(ctx, addon,
-				".spec.install.ownNamespacee.catalogSourceImage is required when .spec.install.type = OwnNamespace")
-		}
-		catalogSourceImage = addon.Spec.Install.OLMOwnNamespace.CatalogSourceImage
-
-	case addonsv1alpha1.OLMAllNamespaces:
-		if addon.Spec.Install.OLMAllNamespaces == nil ||
-			len(addon.Spec.Install.OLMAllNamespaces.Namespace) == 0 {
-			// invalid/missing configuration
-			return "", "", true, r.reportConfigurationError(ctx, addon,
-				".spec.install.allNamespaces.namespace is required when .spec.install.type = AllNamespaces")
-		}
-		targetNamespace = addon.Spec.Install.OLMAllNamespaces.Namespace
-		if len(addon.Spec.Install.OLMAllNamespaces.CatalogSourceImage) == 0 {
-			// invalid/missing configuration
-			return "", "", true, r.reportConfigurationError(ctx, addon,
-				".spec.install.allNamespaces.catalogSourceImage is required when .spec.install.type = AllNamespaces")
-		}
-		catalogSourceImage = addon.Spec.Install.OLMAllNamespaces.CatalogSourceImage
-
-	default:
+	installer := r.installerFor(addon)
+	if installer == nil {
 		// Unsupported Install Type
 		// This should never happen, unless the schema validation is wrong.
 		// The .install.type property is set to only allow known enum values.
@@ -152,7 +247,70 @@ func (r *AddonReconciler) parseAddonInstallConfig(
 		return "", "", true, nil
 	}
 
-	return targetNamespace, catalogSourceImage, false, nil
+	installConfig, err := installer.Config(addon)
+	if err != nil {
+		// invalid/missing configuration
+		return "", "", true, r.reportConfigurationError(ctx, addon, err.Error())
+	}
+	return installConfig.TargetNamespace, installConfig.CatalogSourceImage, false, nil
+}
+
+// Returns the install options common to all OLM install types of the given Addon.
+func getCommonInstallOptions(addon *addonsv1alpha1.Addon) (
+	commonInstallOptions addonsv1alpha1.AddonInstallOLMCommon) {
+	switch {
+	case addon.Spec.Install.Type == addonsv1alpha1.OLMAllNamespaces &&
+		addon.Spec.Install.OLMAllNamespaces != nil:
+		commonInstallOptions = addon.Spec.Install.
+			OLMAllNamespaces.AddonInstallOLMCommon
+	case addon.Spec.Install.Type == addonsv1alpha1.OLMOwnNamespace &&
+		addon.Spec.Install.OLMOwnNamespace != nil:
+		commonInstallOptions = addon.Spec.Install.
+			OLMOwnNamespace.AddonInstallOLMCommon
+	case addon.Spec.Install.Type == addonsv1alpha1.OLMMultiNamespace &&
+		addon.Spec.Install.OLMMultiNamespace != nil:
+		commonInstallOptions = addon.Spec.Install.
+			OLMMultiNamespace.AddonInstallOLMCommon
+	}
+	return commonInstallOptions
+}
+
+// OLM package of an Addon, installed from its own CatalogSource via its own Subscription.
+type olmPackage struct {
+	// Name of the CatalogSource and Subscription of the package.
+	name               string
+	packageName        string
+	channel            string
+	catalogSourceImage string
+	startingCSV        string
+	config             *addonsv1alpha1.SubscriptionConfig
+}
+
+// Returns the main OLM package of the Addon followed by its additional packages.
+// The CatalogSource and Subscription of the main package are named after the Addon,
+// the ones of additional packages are suffixed with the package name.
+// The Subscription config only applies to the main package,
+// additional packages are installed with the default operator deployment.
+func getOLMPackages(addon *addonsv1alpha1.Addon) []olmPackage {
+	commonInstallOptions := getCommonInstallOptions(addon)
+	packages := []olmPackage{{
+		name:               addon.Name,
+		packageName:        commonInstallOptions.PackageName,
+		channel:            commonInstallOptions.Channel,
+		catalogSourceImage: commonInstallOptions.CatalogSourceImage,
+		startingCSV:        commonInstallOptions.StartingCSV,
+		config:             commonInstallOptions.Config,
+	}}
+	for _, additionalPackage := range commonInstallOptions.AdditionalPackages {
+		packages = append(packages, olmPackage{
+			name:               addon.Name + "-" + additionalPackage.PackageName,
+			packageName:        additionalPackage.PackageName,
+			channel:            additionalPackage.Channel,
+			catalogSourceImage: additionalPackage.CatalogSourceImage,
+			startingCSV:        additionalPackage.StartingCSV,
+		})
+	}
+	return packages
 }
 
 // Tests if the controller reference on `wanted` matches the one on `current`
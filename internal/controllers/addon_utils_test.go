@@ -7,6 +7,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -42,12 +44,16 @@ func TestHandleAddonDeletion(t *testing.T) {
 		c.
 			On("Update", mock.Anything, mock.Anything, mock.Anything).
 			Return(nil)
+		c.
+			On("List", mock.Anything, mock.IsType(&corev1.NamespaceList{}), mock.Anything).
+			Return(nil)
 		csvEventHandlerMock.
 			On("Free", addonToDelete)
 
 		ctx := context.Background()
-		err := r.handleAddonDeletion(ctx, addonToDelete)
+		requeue, err := r.handleAddonDeletion(ctx, addonToDelete)
 		require.NoError(t, err)
+		assert.False(t, requeue)
 
 		assert.Empty(t, addonToDelete.Finalizers)                                    // finalizer is gone
 		assert.Equal(t, addonsv1alpha1.PhaseTerminating, addonToDelete.Status.Phase) // status is set
@@ -57,6 +63,78 @@ func TestHandleAddonDeletion(t *testing.T) {
 		c.StatusMock.AssertExpectations(t)
 	})
 
+	t.Run("uninstalls Helm objects", func(t *testing.T) {
+		addonToDelete := newTestHelmAddon()
+		addonToDelete.Finalizers = []string{cacheFinalizer}
+		addonToDelete.Status.ManifestObjects = []addonsv1alpha1.AddonObjectReference{
+			{APIVersion: "v1", Kind: "ConfigMap", Name: "addon-1", Namespace: "addon-1"},
+		}
+
+		c := testutil.NewClient()
+
+		csvEventHandlerMock := &csvEventHandlerMock{}
+		r := &AddonReconciler{
+			Client:          c,
+			Log:             testutil.NewLogger(t),
+			Scheme:          newTestSchemeWithAddonsv1alpha1(),
+			csvEventHandler: csvEventHandlerMock,
+		}
+
+		c.StatusMock.
+			On("Update", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+		c.
+			On("Update", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+		c.
+			On("Get", mock.Anything, client.ObjectKey{Name: "addon-1", Namespace: "addon-1"}, mock.Anything).
+			Run(func(args mock.Arguments) {
+				obj := args.Get(2).(client.Object)
+				obj.SetName("addon-1")
+				obj.SetNamespace("addon-1")
+				obj.SetOwnerReferences([]metav1.OwnerReference{{
+					APIVersion: addonsv1alpha1.GroupVersion.String(),
+					Kind:       "Addon",
+					Name:       addonToDelete.Name,
+					UID:        addonToDelete.UID,
+					Controller: func(b bool) *bool { return &b }(true),
+				}})
+			}).
+			Return(nil).
+			Once()
+		c.
+			On("Get", mock.Anything, client.ObjectKey{Name: "addon-1", Namespace: "addon-1"}, mock.Anything).
+			Return(newTestErrNotFound())
+		c.
+			On("Delete", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+		c.
+			On("List", mock.Anything, mock.IsType(&corev1.NamespaceList{}), mock.Anything).
+			Return(nil)
+		csvEventHandlerMock.
+			On("Free", addonToDelete)
+
+		ctx := context.Background()
+		requeue, err := r.handleAddonDeletion(ctx, addonToDelete)
+		require.NoError(t, err)
+		assert.True(t, requeue)
+
+		// waits for the objects to be gone
+		assert.Equal(t, []string{cacheFinalizer}, addonToDelete.Finalizers)
+		c.AssertCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+		if assert.NotNil(t, addonToDelete.Status.Teardown) {
+			assert.Equal(t, addonsv1alpha1.AddonTeardownStageManifestObjects,
+				addonToDelete.Status.Teardown.Stage)
+			assert.Equal(t, addonToDelete.Status.ManifestObjects,
+				addonToDelete.Status.Teardown.PendingObjects)
+		}
+
+		requeue, err = r.handleAddonDeletion(ctx, addonToDelete)
+		require.NoError(t, err)
+		assert.False(t, requeue)
+		assert.Empty(t, addonToDelete.Finalizers)
+	})
+
 	t.Run("noop if finalizer already gone", func(t *testing.T) {
 		addonToDelete := &addonsv1alpha1.Addon{}
 
@@ -80,8 +158,9 @@ func TestHandleAddonDeletion(t *testing.T) {
 			On("Free", addonToDelete)
 
 		ctx := context.Background()
-		err := r.handleAddonDeletion(ctx, addonToDelete)
+		requeue, err := r.handleAddonDeletion(ctx, addonToDelete)
 		require.NoError(t, err)
+		assert.False(t, requeue)
 
 		// ensure no API calls are made,
 		// because the object is already deleted.
@@ -129,3 +208,79 @@ func (m *csvEventHandlerMock) ReplaceMap(
 	args := m.Called(addon, csvKeys)
 	return args.Bool(0)
 }
+
+func TestSetAvailableCondition(t *testing.T) {
+	r := &AddonReconciler{}
+	newOLMAddon := func() *addonsv1alpha1.Addon {
+		addon := &addonsv1alpha1.Addon{}
+		addon.Spec.Install.Type = addonsv1alpha1.OLMOwnNamespace
+		return addon
+	}
+
+	t.Run("all phases ready", func(t *testing.T) {
+		addon := newOLMAddon()
+		for _, conditionType := range r.addonPhaseConditionTypes(addon) {
+			setPhaseCondition(addon, conditionType, metav1.ConditionTrue,
+				addonsv1alpha1.AddonReasonReady, "")
+		}
+
+		r.setAvailableCondition(addon)
+		assert.True(t, meta.IsStatusConditionTrue(addon.Status.Conditions, addonsv1alpha1.Available))
+		assert.Equal(t, addonsv1alpha1.PhaseReady, addon.Status.Phase)
+	})
+
+	t.Run("reports first unready phase", func(t *testing.T) {
+		addon := newOLMAddon()
+		for _, conditionType := range r.addonPhaseConditionTypes(addon) {
+			setPhaseCondition(addon, conditionType, metav1.ConditionTrue,
+				addonsv1alpha1.AddonReasonReady, "")
+		}
+		setPhaseCondition(addon, addonsv1alpha1.CatalogSourceReady, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonUnreadyCatalogSource, "catalog")
+		setPhaseCondition(addon, addonsv1alpha1.CSVSucceeded, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonUnreadyCSV, "csv")
+
+		r.setAvailableCondition(addon)
+		availableCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.Available)
+		if assert.NotNil(t, availableCond) {
+			assert.Equal(t, metav1.ConditionFalse, availableCond.Status)
+			assert.Equal(t, addonsv1alpha1.AddonReasonUnreadyCatalogSource, availableCond.Reason)
+			assert.Equal(t, "catalog", availableCond.Message)
+		}
+		// later phases keep their own condition
+		assert.True(t, meta.IsStatusConditionFalse(addon.Status.Conditions, addonsv1alpha1.CSVSucceeded))
+		assert.Equal(t, addonsv1alpha1.PhasePending, addon.Status.Phase)
+	})
+
+	t.Run("missing phase", func(t *testing.T) {
+		addon := newOLMAddon()
+		setPhaseCondition(addon, addonsv1alpha1.NamespacesReady, metav1.ConditionTrue,
+			addonsv1alpha1.AddonReasonReady, "")
+
+		r.setAvailableCondition(addon)
+		availableCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.Available)
+		if assert.NotNil(t, availableCond) {
+			assert.Equal(t, metav1.ConditionFalse, availableCond.Status)
+			assert.Equal(t, addonsv1alpha1.AddonReasonUnknown, availableCond.Reason)
+		}
+	})
+
+	t.Run("manifests install type", func(t *testing.T) {
+		addon := &addonsv1alpha1.Addon{}
+		addon.Spec.Install.Type = addonsv1alpha1.Manifests
+		setPhaseCondition(addon, addonsv1alpha1.NamespacesReady, metav1.ConditionTrue,
+			addonsv1alpha1.AddonReasonReady, "")
+		setPhaseCondition(addon, addonsv1alpha1.ParametersReady, metav1.ConditionTrue,
+			addonsv1alpha1.AddonReasonReady, "")
+		setPhaseCondition(addon, addonsv1alpha1.PullSecretsReady, metav1.ConditionTrue,
+			addonsv1alpha1.AddonReasonReady, "")
+		setPhaseCondition(addon, addonsv1alpha1.DependenciesReady, metav1.ConditionTrue,
+			addonsv1alpha1.AddonReasonReady, "")
+		setPhaseCondition(addon, addonsv1alpha1.ManifestsReady, metav1.ConditionTrue,
+			addonsv1alpha1.AddonReasonReady, "")
+
+		// OLM phases are not required
+		r.setAvailableCondition(addon)
+		assert.True(t, meta.IsStatusConditionTrue(addon.Status.Conditions, addonsv1alpha1.Available))
+	})
+}
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+)
+
+// Set on existing objects adopted by an Addon, the value is the adoption policy that allowed it.
+const adoptedAnnotation = "addons.managed.openshift.io/adopted"
+
+// Returned when an existing object collides with an object of the Addon
+// and the adoption policy of the Addon does not allow adopting it.
+type adoptionCollisionError struct {
+	ref    addonsv1alpha1.AddonObjectReference
+	policy addonsv1alpha1.AddonAdoptionPolicy
+	// one of the AddonReasonAdoptionPrevented, AddonReasonNotLabelledForAdoption
+	// and AddonReasonControlledByOther condition reasons
+	reason string
+	// controller of the existing object, if any
+	controller *metav1.OwnerReference
+}
+
+func (e *adoptionCollisionError) Error() string {
+	name := e.ref.Name
+	if len(e.ref.Namespace) > 0 {
+		name = e.ref.Namespace + "/" + name
+	}
+
+	switch e.reason {
+	case addonsv1alpha1.AddonReasonControlledByOther:
+		return fmt.Sprintf("%s %s already exists and is controlled by %s %s, adoption policy %s does not take it over",
+			e.ref.Kind, name, e.controller.Kind, e.controller.Name, e.policy)
+	case addonsv1alpha1.AddonReasonNotLabelledForAdoption:
+		return fmt.Sprintf("%s %s already exists without the labels of this Addon, adoption policy %s does not adopt it",
+			e.ref.Kind, name, e.policy)
+	default:
+		return fmt.Sprintf("%s %s already exists and is not controlled by this Addon, adoption policy %s prevents adopting it",
+			e.ref.Kind, name, e.policy)
+	}
+}
+
+// Collisions are a special case of objects not owned by us.
+func (e *adoptionCollisionError) Unwrap() error {
+	return errNotOwnedByUs
+}
+
+// Returns the adoption policy of the Addon for objects of the given kind.
+// Without .spec.adoption the deprecated .spec.resourceAdoptionStrategy is honored.
+func adoptionPolicy(addon *addonsv1alpha1.Addon, kind string) addonsv1alpha1.AddonAdoptionPolicy {
+	adoption := addon.Spec.Adoption
+	if adoption == nil {
+		if addon.Spec.ResourceAdoptionStrategy == addonsv1alpha1.ResourceAdoptionAdoptAll {
+			return addonsv1alpha1.AdoptionPolicyAdoptAll
+		}
+		return addonsv1alpha1.AdoptionPolicyPrevent
+	}
+
+	for _, override := range adoption.Overrides {
+		if override.Kind == kind {
+			return override.Policy
+		}
+	}
+	if len(adoption.Policy) == 0 {
+		return addonsv1alpha1.AdoptionPolicyPrevent
+	}
+	return adoption.Policy
+}
+
+// Checks whether the existing object current may be reconciled into the desired object of the Addon.
+// Objects controlled by the Addon always are.
+// Other objects are adopted if the adoption policy of the Addon for their kind allows it:
+// the adopted annotation is set on both objects and the object is listed in the Addon status.
+// Otherwise an *adoptionCollisionError is returned.
+func adoptObject(
+	addon *addonsv1alpha1.Addon, gvk schema.GroupVersionKind, current, desired client.Object) error {
+	return adoptObjectWithPolicy(addon, adoptionPolicy(addon, gvk.Kind), gvk, current, desired)
+}
+
+// Like adoptObject, but with the given adoption policy instead of the policy of the Addon.
+func adoptObjectWithPolicy(
+	addon *addonsv1alpha1.Addon, policy addonsv1alpha1.AddonAdoptionPolicy,
+	gvk schema.GroupVersionKind, current, desired client.Object) error {
+	if metav1.IsControlledBy(current, addon) {
+		// keep the annotation of objects adopted before
+		if policy, ok := current.GetAnnotations()[adoptedAnnotation]; ok {
+			setAnnotation(desired, adoptedAnnotation, policy)
+		}
+		return nil
+	}
+
+	ref := addonsv1alpha1.AddonObjectReference{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Name:       current.GetName(),
+		Namespace:  current.GetNamespace(),
+	}
+	switch policy {
+	case addonsv1alpha1.AdoptionPolicyAdoptAll:
+	case addonsv1alpha1.AdoptionPolicyAdoptIfLabelled:
+		if controller := metav1.GetControllerOf(current); controller != nil {
+			return &adoptionCollisionError{
+				ref: ref, policy: policy, controller: controller,
+				reason: addonsv1alpha1.AddonReasonControlledByOther,
+			}
+		}
+		if !hasCommonLabels(current.GetLabels(), addon) {
+			return &adoptionCollisionError{
+				ref: ref, policy: policy,
+				reason: addonsv1alpha1.AddonReasonNotLabelledForAdoption,
+			}
+		}
+	default:
+		return &adoptionCollisionError{
+			ref: ref, policy: policy,
+			reason: addonsv1alpha1.AddonReasonAdoptionPrevented,
+		}
+	}
+
+	setAnnotation(current, adoptedAnnotation, string(policy))
+	setAnnotation(desired, adoptedAnnotation, string(policy))
+	addon.Status.AdoptedObjects = mergeObjectReferences(
+		addon.Status.AdoptedObjects, []addonsv1alpha1.AddonObjectReference{ref})
+	return nil
+}
+
+// Drops adopted objects from the Addon status that no longer exist
+// or are no longer controlled by the Addon, e.g. because they were removed from the Addon.
+// Adopted objects are rare, so they are looked up directly instead of being cached.
+func (r *AddonReconciler) pruneAdoptedObjects(
+	ctx context.Context, addon *addonsv1alpha1.Addon) error {
+	if len(addon.Status.AdoptedObjects) == 0 {
+		return nil
+	}
+
+	var adoptedObjects []addonsv1alpha1.AddonObjectReference
+	for _, ref := range addon.Status.AdoptedObjects {
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind))
+		err := r.uncachedReader().Get(ctx, client.ObjectKey{
+			Name:      ref.Name,
+			Namespace: ref.Namespace,
+		}, obj)
+		if k8sApiErrors.IsNotFound(err) || isNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("getting adopted %s %s: %w", ref.Kind, ref.Name, err)
+		}
+		if !metav1.IsControlledBy(obj, addon) {
+			continue
+		}
+		adoptedObjects = append(adoptedObjects, ref)
+	}
+	addon.Status.AdoptedObjects = adoptedObjects
+	return nil
+}
+
+func setAnnotation(obj client.Object, key, value string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[key] = value
+	obj.SetAnnotations(annotations)
+}
+
+// Reports that an existing object collides with an object of the Addon,
+// on the given phase condition and in a Warning Event.
+func (r *AddonReconciler) reportAdoptionCollision(
+	ctx context.Context, addon *addonsv1alpha1.Addon,
+	conditionType string, collision *adoptionCollisionError) error {
+	r.recordEvent(addon, corev1.EventTypeWarning, eventReasonAdoptionCollision,
+		"%s", collision.Error())
+
+	setPhaseCondition(addon, conditionType, metav1.ConditionFalse,
+		collision.reason, collision.Error())
+	return r.reportPhaseStatus(ctx, addon)
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/testutil"
+)
+
+func TestAdoptionPolicy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		spec     addonsv1alpha1.AddonSpec
+		kind     string
+		expected addonsv1alpha1.AddonAdoptionPolicy
+	}{
+		{
+			name:     "default",
+			kind:     "Namespace",
+			expected: addonsv1alpha1.AdoptionPolicyPrevent,
+		},
+		{
+			name: "deprecated resourceAdoptionStrategy",
+			spec: addonsv1alpha1.AddonSpec{
+				ResourceAdoptionStrategy: addonsv1alpha1.ResourceAdoptionAdoptAll,
+			},
+			kind:     "Namespace",
+			expected: addonsv1alpha1.AdoptionPolicyAdoptAll,
+		},
+		{
+			name: "adoption replaces resourceAdoptionStrategy",
+			spec: addonsv1alpha1.AddonSpec{
+				ResourceAdoptionStrategy: addonsv1alpha1.ResourceAdoptionAdoptAll,
+				Adoption:                 &addonsv1alpha1.AddonAdoption{},
+			},
+			kind:     "Namespace",
+			expected: addonsv1alpha1.AdoptionPolicyPrevent,
+		},
+		{
+			name: "override",
+			spec: addonsv1alpha1.AddonSpec{
+				Adoption: &addonsv1alpha1.AddonAdoption{
+					Policy: addonsv1alpha1.AdoptionPolicyAdoptIfLabelled,
+					Overrides: []addonsv1alpha1.AddonAdoptionOverride{
+						{Kind: "Subscription", Policy: addonsv1alpha1.AdoptionPolicyAdoptAll},
+					},
+				},
+			},
+			kind:     "Subscription",
+			expected: addonsv1alpha1.AdoptionPolicyAdoptAll,
+		},
+		{
+			name: "kind without override",
+			spec: addonsv1alpha1.AddonSpec{
+				Adoption: &addonsv1alpha1.AddonAdoption{
+					Policy: addonsv1alpha1.AdoptionPolicyAdoptIfLabelled,
+					Overrides: []addonsv1alpha1.AddonAdoptionOverride{
+						{Kind: "Subscription", Policy: addonsv1alpha1.AdoptionPolicyAdoptAll},
+					},
+				},
+			},
+			kind:     "Namespace",
+			expected: addonsv1alpha1.AdoptionPolicyAdoptIfLabelled,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			addon := &addonsv1alpha1.Addon{Spec: tc.spec}
+			assert.Equal(t, tc.expected, adoptionPolicy(addon, tc.kind))
+		})
+	}
+}
+
+func TestAdoptObject_KeepsAdoptedAnnotation(t *testing.T) {
+	addon := newTestAddonWithSingleNamespace()
+
+	current := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "namespace-1",
+			Annotations: map[string]string{
+				adoptedAnnotation: string(addonsv1alpha1.AdoptionPolicyAdoptAll),
+			},
+		},
+	}
+	require.NoError(t, controllerutil.SetControllerReference(
+		addon, current, newTestSchemeWithAddonsv1alpha1()))
+	desired := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "namespace-1"},
+	}
+
+	err := adoptObject(addon, corev1.SchemeGroupVersion.WithKind("Namespace"), current, desired)
+	require.NoError(t, err)
+	assert.Equal(t, string(addonsv1alpha1.AdoptionPolicyAdoptAll), desired.Annotations[adoptedAnnotation])
+	// adopted before, so not recorded again
+	assert.Empty(t, addon.Status.AdoptedObjects)
+}
+
+func TestAdoptObject_AdoptIfLabelled(t *testing.T) {
+	addon := newTestAddonWithSingleNamespace()
+	addon.Spec.Adoption = &addonsv1alpha1.AddonAdoption{
+		Policy: addonsv1alpha1.AdoptionPolicyAdoptIfLabelled,
+	}
+
+	current := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "namespace-1",
+			Labels: map[string]string{},
+		},
+	}
+	addCommonLabels(current.Labels, addon)
+	desired := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "namespace-1"},
+	}
+
+	err := adoptObject(addon, corev1.SchemeGroupVersion.WithKind("Namespace"), current, desired)
+	require.NoError(t, err)
+	assert.Equal(t, string(addonsv1alpha1.AdoptionPolicyAdoptIfLabelled), current.Annotations[adoptedAnnotation])
+	assert.Equal(t, string(addonsv1alpha1.AdoptionPolicyAdoptIfLabelled), desired.Annotations[adoptedAnnotation])
+	assert.Equal(t, []addonsv1alpha1.AddonObjectReference{
+		{APIVersion: "v1", Kind: "Namespace", Name: "namespace-1"},
+	}, addon.Status.AdoptedObjects)
+}
+
+func TestPruneAdoptedObjects(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	scheme := newTestSchemeWithAddonsv1alpha1()
+	newRef := func(name string) addonsv1alpha1.AddonObjectReference {
+		return addonsv1alpha1.AddonObjectReference{
+			APIVersion: "v1",
+			Kind:       "Secret",
+			Name:       name,
+			Namespace:  "addon-1",
+		}
+	}
+	addon.Status.AdoptedObjects = []addonsv1alpha1.AddonObjectReference{
+		newRef("adopted"), newRef("deleted"), newRef("released"),
+	}
+
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "adopted", Namespace: "addon-1"},
+		mock.IsType(&metav1.PartialObjectMetadata{}),
+	).Run(func(args mock.Arguments) {
+		obj := args.Get(2).(*metav1.PartialObjectMetadata)
+		require.NoError(t, controllerutil.SetControllerReference(addon, obj, scheme))
+	}).Return(nil)
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "deleted", Namespace: "addon-1"},
+		mock.IsType(&metav1.PartialObjectMetadata{}),
+	).Return(newTestErrNotFound())
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "released", Namespace: "addon-1"},
+		mock.IsType(&metav1.PartialObjectMetadata{}),
+	).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: scheme,
+	}
+	require.NoError(t, r.pruneAdoptedObjects(context.Background(), addon))
+	c.AssertExpectations(t)
+	assert.Equal(t, []addonsv1alpha1.AddonObjectReference{newRef("adopted")},
+		addon.Status.AdoptedObjects)
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// Index of the catalog Pod informer, keyed by namespace and CatalogSource name.
+const catalogPodIndex = "catalogSource"
+
+// catalogPodLister lists the registry Pods serving a CatalogSource.
+type catalogPodLister interface {
+	ListCatalogPods(namespace, catalogSourceName string) ([]*corev1.Pod, error)
+}
+
+// Watches the registry Pods of CatalogSources.
+// The manager cache would hold every Pod of the cluster,
+// so this informer only watches Pods that OLM labels as registry Pods.
+type catalogPodInformer struct {
+	informer cache.SharedIndexInformer
+}
+
+var (
+	_ catalogPodLister               = (*catalogPodInformer)(nil)
+	_ manager.Runnable               = (*catalogPodInformer)(nil)
+	_ manager.LeaderElectionRunnable = (*catalogPodInformer)(nil)
+)
+
+func newCatalogPodInformer(c kubernetes.Interface) *catalogPodInformer {
+	listWatch := cache.NewFilteredListWatchFromClient(
+		c.CoreV1().RESTClient(), "pods", metav1.NamespaceAll,
+		func(opts *metav1.ListOptions) {
+			opts.LabelSelector = catalogSourcePodLabel
+		})
+	return &catalogPodInformer{
+		informer: cache.NewSharedIndexInformer(listWatch, &corev1.Pod{}, 0, cache.Indexers{
+			catalogPodIndex: indexCatalogPod,
+		}),
+	}
+}
+
+func indexCatalogPod(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil, fmt.Errorf("expected *corev1.Pod, got %T", obj)
+	}
+	return []string{catalogPodIndexKey(pod.Namespace, pod.Labels[catalogSourcePodLabel])}, nil
+}
+
+func catalogPodIndexKey(namespace, catalogSourceName string) string {
+	return namespace + "/" + catalogSourceName
+}
+
+// Runs the informer until the context is cancelled.
+func (i *catalogPodInformer) Start(ctx context.Context) error {
+	i.informer.Run(ctx.Done())
+	return nil
+}
+
+// The informer only reads, so it runs on every replica.
+func (i *catalogPodInformer) NeedLeaderElection() bool {
+	return false
+}
+
+// Lists the registry Pods of the given CatalogSource from the informer.
+// The returned Pods are shared with the informer and must not be modified.
+func (i *catalogPodInformer) ListCatalogPods(
+	namespace, catalogSourceName string) ([]*corev1.Pod, error) {
+	if !i.informer.HasSynced() {
+		return nil, errors.New("catalog Pod informer has not synced yet")
+	}
+
+	objs, err := i.informer.GetIndexer().ByIndex(
+		catalogPodIndex, catalogPodIndexKey(namespace, catalogSourceName))
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]*corev1.Pod, 0, len(objs))
+	for _, obj := range objs {
+		pods = append(pods, obj.(*corev1.Pod))
+	}
+	return pods, nil
+}
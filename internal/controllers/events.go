@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Event reasons
+const (
+	eventReasonNamespaceCreated      = "NamespaceCreated"
+	eventReasonNamespaceCollision    = "NamespaceCollision"
+	eventReasonNamespaceOrphaned     = "NamespaceOrphaned"
+	eventReasonNamespaceRetained     = "NamespaceRetained"
+	eventReasonNamespaceStuck        = "NamespaceStuck"
+	eventReasonCatalogSourceReady    = "CatalogSourceReady"
+	eventReasonCatalogSourceUnready  = "CatalogSourceUnready"
+	eventReasonCatalogUpdated        = "CatalogUpdated"
+	eventReasonForeignOperatorGroups = "ForeignOperatorGroups"
+	eventReasonOperatorGroupRemoved  = "OperatorGroupRemoved"
+	eventReasonSubscriptionCreated   = "SubscriptionCreated"
+	eventReasonSubscriptionFailed    = "SubscriptionFailed"
+	eventReasonCSVPhaseChanged       = "CSVPhaseChanged"
+	eventReasonDeploymentsUnhealthy  = "DeploymentsUnhealthy"
+	eventReasonPaused                = "Paused"
+	eventReasonUnpaused              = "Unpaused"
+	eventReasonTeardownProgressing   = "TeardownProgressing"
+	eventReasonFinalizerRemoved      = "FinalizerRemoved"
+
+	eventReasonAdoptionCollision = "AdoptionCollision"
+
+	eventReasonManifestObjectPruned = "ManifestObjectPruned"
+	eventReasonHelmReleaseInstalled = "HelmReleaseInstalled"
+	eventReasonHelmReleaseUpgraded  = "HelmReleaseUpgraded"
+
+	eventReasonClusterCatalogReady       = "ClusterCatalogReady"
+	eventReasonClusterCatalogUnready     = "ClusterCatalogUnready"
+	eventReasonClusterExtensionInstalled = "ClusterExtensionInstalled"
+)
+
+// Events with reasons in the same group describe the state of the same thing.
+// Only the latest Event of a group is used for deduplication,
+// so flipping back to an earlier state is recorded again.
+var eventReasonGroups = map[string]string{
+	eventReasonCatalogSourceReady:    "CatalogSource",
+	eventReasonCatalogSourceUnready:  "CatalogSource",
+	eventReasonClusterCatalogReady:   "ClusterCatalog",
+	eventReasonClusterCatalogUnready: "ClusterCatalog",
+	eventReasonPaused:                "Pause",
+	eventReasonUnpaused:              "Pause",
+}
+
+type eventKey struct {
+	uid   types.UID
+	group string
+}
+
+// eventDeduplicator drops Events that repeat the last Event
+// recorded for the same object and reason group.
+// The zero value is ready to use.
+type eventDeduplicator struct {
+	mux        sync.Mutex
+	lastEvents map[eventKey]string
+}
+
+// Records an Event for the given object, unless it is a repetition of the last one.
+func (d *eventDeduplicator) Eventf(
+	recorder record.EventRecorder, obj client.Object,
+	eventType, reason, messageFmt string, args ...interface{}) {
+	if recorder == nil {
+		return
+	}
+
+	group, ok := eventReasonGroups[reason]
+	if !ok {
+		group = reason
+	}
+	key := eventKey{uid: obj.GetUID(), group: group}
+	event := eventType + "/" + reason + "/" + fmt.Sprintf(messageFmt, args...)
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	if d.lastEvents == nil {
+		d.lastEvents = map[eventKey]string{}
+	}
+	if d.lastEvents[key] == event {
+		return
+	}
+	d.lastEvents[key] = event
+	recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+}
+
+// Forgets all Events recorded for the object with the given UID.
+func (d *eventDeduplicator) Free(uid types.UID) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	for key := range d.lastEvents {
+		if key.uid == uid {
+			delete(d.lastEvents, key)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+)
+
+func TestEventDeduplicator(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	addon := &addonsv1alpha1.Addon{
+		ObjectMeta: metav1.ObjectMeta{UID: "1234"},
+	}
+
+	var d eventDeduplicator
+	d.Eventf(recorder, addon, corev1.EventTypeNormal, eventReasonPaused, "paused")
+	d.Eventf(recorder, addon, corev1.EventTypeNormal, eventReasonPaused, "paused")
+	d.Eventf(recorder, addon, corev1.EventTypeNormal, eventReasonUnpaused, "resumed")
+	d.Eventf(recorder, addon, corev1.EventTypeNormal, eventReasonPaused, "paused")
+	d.Eventf(recorder, addon, corev1.EventTypeNormal, eventReasonCSVPhaseChanged, "phase %q", "Pending")
+	d.Eventf(recorder, addon, corev1.EventTypeNormal, eventReasonCSVPhaseChanged, "phase %q", "Pending")
+	d.Eventf(recorder, addon, corev1.EventTypeNormal, eventReasonCSVPhaseChanged, "phase %q", "Succeeded")
+
+	d.Free(addon.UID)
+	d.Eventf(recorder, addon, corev1.EventTypeNormal, eventReasonCSVPhaseChanged, "phase %q", "Succeeded")
+	close(recorder.Events)
+
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	assert.Equal(t, []string{
+		"Normal Paused paused",
+		"Normal Unpaused resumed",
+		"Normal Paused paused",
+		`Normal CSVPhaseChanged phase "Pending"`,
+		`Normal CSVPhaseChanged phase "Succeeded"`,
+		`Normal CSVPhaseChanged phase "Succeeded"`,
+	}, events)
+}
+
+func TestEventDeduplicator_NoRecorder(t *testing.T) {
+	var d eventDeduplicator
+	assert.NotPanics(t, func() {
+		d.Eventf(nil, &addonsv1alpha1.Addon{},
+			corev1.EventTypeNormal, eventReasonPaused, "paused")
+	})
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/metrics"
+)
+
+// Installer installs the operator or workload of Addons with one or more install types.
+// The AddonReconciler runs the phases common to all install types
+// (Namespaces, parameters Secret and dependencies) and hands over to the Installer afterwards.
+// Installers report their progress via phase conditions on the Addon,
+// from which the AddonReconciler computes the Available condition.
+type Installer interface {
+	// Validates the install configuration of the Addon.
+	// The returned error is reported as configuration error on the Addon.
+	Config(addon *addonsv1alpha1.Addon) (InstallConfig, error)
+	// Ensures the Addon is installed and healthy.
+	Ensure(
+		ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon,
+		phaseTimer *metrics.PhaseTimer,
+	) (InstallResult, error)
+	// Checks the health of the installation and sets the phase conditions,
+	// without changing anything on the cluster.
+	Observe(ctx context.Context, addon *addonsv1alpha1.Addon) error
+	// Removes the installation of a deleted Addon,
+	// as far as it is not left to the garbage collector.
+	// Returns InstallResultRetry while waiting for objects to be deleted,
+	// the finalizer of the Addon is only removed after InstallResultNil.
+	Teardown(ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon) (InstallResult, error)
+	// Reports what the Installer installs for the Addon.
+	Status(addon *addonsv1alpha1.Addon) InstallStatus
+}
+
+// Install configuration shared with the common phases.
+type InstallConfig struct {
+	// Namespace the Addon is installed into.
+	TargetNamespace string
+	// Catalog image to install the Addon from, if any.
+	CatalogSourceImage string
+}
+
+type InstallResult int
+
+const (
+	// Installation is complete.
+	InstallResultNil InstallResult = iota
+	// Stop reconciling until the Addon changes.
+	InstallResultStop InstallResult = iota
+	// Installation is in progress, reconcile again later.
+	InstallResultRetry InstallResult = iota
+)
+
+// Reported by an Installer about an Addon.
+type InstallStatus struct {
+	// Phase conditions set by the Installer, in the order they are reconciled.
+	ConditionTypes []string
+	// Package, channel and installed version, if known, reported as metrics.
+	PackageName, Channel, InstalledVersion string
+}
+
+// Registers the Installer for the given install types,
+// replacing any Installer registered for them before.
+func (r *AddonReconciler) RegisterInstaller(
+	installer Installer, installTypes ...addonsv1alpha1.AddonInstallType) {
+	r.initInstallers()
+	r.installersMux.Lock()
+	defer r.installersMux.Unlock()
+	for _, installType := range installTypes {
+		r.installers[installType] = installer
+	}
+}
+
+// Returns the Installer for the install type of the Addon,
+// or nil if the install type is not supported.
+func (r *AddonReconciler) installerFor(addon *addonsv1alpha1.Addon) Installer {
+	r.initInstallers()
+	r.installersMux.RLock()
+	defer r.installersMux.RUnlock()
+	return r.installers[addon.Spec.Install.Type]
+}
+
+// Registers the built-in Installers on first use.
+func (r *AddonReconciler) initInstallers() {
+	r.installersOnce.Do(func() {
+		r.installers = map[addonsv1alpha1.AddonInstallType]Installer{}
+		for _, installer := range []struct {
+			installer    Installer
+			installTypes []addonsv1alpha1.AddonInstallType
+		}{
+			{
+				installer: &olmInstaller{r: r},
+				installTypes: []addonsv1alpha1.AddonInstallType{
+					addonsv1alpha1.OLMOwnNamespace,
+					addonsv1alpha1.OLMAllNamespaces,
+					addonsv1alpha1.OLMMultiNamespace,
+				},
+			},
+			{
+				installer: &objectsInstaller{r: r},
+				installTypes: []addonsv1alpha1.AddonInstallType{
+					addonsv1alpha1.Manifests,
+					addonsv1alpha1.Helm,
+				},
+			},
+			{
+				installer: &clusterExtensionInstaller{r: r},
+				installTypes: []addonsv1alpha1.AddonInstallType{
+					addonsv1alpha1.OLMClusterExtension,
+				},
+			},
+		} {
+			for _, installType := range installer.installTypes {
+				r.installers[installType] = installer.installer
+			}
+		}
+	})
+}
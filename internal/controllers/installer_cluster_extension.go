@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/metrics"
+)
+
+// Installs Addons via an OLM v1 ClusterCatalog and ClusterExtension.
+// Handles the OLMClusterExtension install type.
+type clusterExtensionInstaller struct {
+	r *AddonReconciler
+}
+
+var _ Installer = (*clusterExtensionInstaller)(nil)
+
+func (i *clusterExtensionInstaller) Config(addon *addonsv1alpha1.Addon) (InstallConfig, error) {
+	clusterExtensionSpec := addon.Spec.Install.OLMClusterExtension
+	if clusterExtensionSpec == nil ||
+		len(clusterExtensionSpec.Namespace) == 0 {
+		return InstallConfig{}, errors.New(
+			".spec.install.olmClusterExtension.namespace is required when .spec.install.type = OLMClusterExtension")
+	}
+	if len(clusterExtensionSpec.CatalogSourceImage) == 0 ||
+		len(clusterExtensionSpec.PackageName) == 0 ||
+		len(clusterExtensionSpec.ServiceAccountName) == 0 {
+		return InstallConfig{}, errors.New(
+			".spec.install.olmClusterExtension requires .catalogSourceImage, .packageName and .serviceAccountName when .spec.install.type = OLMClusterExtension")
+	}
+	return InstallConfig{
+		TargetNamespace:    clusterExtensionSpec.Namespace,
+		CatalogSourceImage: clusterExtensionSpec.CatalogSourceImage,
+	}, nil
+}
+
+func (i *clusterExtensionInstaller) Ensure(
+	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon,
+	phaseTimer *metrics.PhaseTimer,
+) (InstallResult, error) {
+	// Phase 5.
+	// Ensure ClusterCatalog
+	phaseTimer.Phase("ensure_cluster_catalog")
+	ensureResult, err := i.r.ensureClusterCatalog(ctx, log, addon)
+	if err != nil {
+		return InstallResultNil, fmt.Errorf("failed to ensure ClusterCatalog: %w", err)
+	}
+	switch ensureResult {
+	case ensureClusterExtensionResultRetry:
+		log.Info("requeuing", "reason", "clustercatalog unready")
+		return InstallResultRetry, nil
+	case ensureClusterExtensionResultStop:
+		return InstallResultStop, nil
+	}
+
+	// Phase 6.
+	// Ensure ClusterExtension
+	phaseTimer.Phase("ensure_cluster_extension")
+	ensureResult, err = i.r.ensureClusterExtension(ctx, log, addon)
+	if err != nil {
+		return InstallResultNil, fmt.Errorf("failed to ensure ClusterExtension: %w", err)
+	}
+	switch ensureResult {
+	case ensureClusterExtensionResultRetry:
+		log.Info("requeuing", "reason", "clusterextension not installed")
+		return InstallResultRetry, nil
+	case ensureClusterExtensionResultStop:
+		return InstallResultStop, nil
+	}
+	return InstallResultNil, nil
+}
+
+func (i *clusterExtensionInstaller) Observe(ctx context.Context, addon *addonsv1alpha1.Addon) error {
+	if err := i.r.observeClusterExtension(ctx, addon); err != nil {
+		return fmt.Errorf("observing ClusterExtension: %w", err)
+	}
+	return nil
+}
+
+// Deletes the ClusterExtension and ClusterCatalog in stages,
+// see (*AddonReconciler).teardownClusterExtension.
+func (i *clusterExtensionInstaller) Teardown(
+	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon) (InstallResult, error) {
+	done, err := i.r.teardownClusterExtension(ctx, addon)
+	if err != nil {
+		return InstallResultNil, fmt.Errorf("failed to tear down OLM v1 objects: %w", err)
+	}
+	if !done {
+		log.Info("requeuing", "reason", "waiting for OLM v1 objects to be deleted")
+		return InstallResultRetry, nil
+	}
+	return InstallResultNil, nil
+}
+
+func (i *clusterExtensionInstaller) Status(addon *addonsv1alpha1.Addon) InstallStatus {
+	status := InstallStatus{
+		ConditionTypes: []string{
+			addonsv1alpha1.ClusterCatalogReady,
+			addonsv1alpha1.ClusterExtensionInstalled,
+		},
+	}
+	if clusterExtensionSpec := addon.Spec.Install.OLMClusterExtension; clusterExtensionSpec != nil {
+		status.PackageName = clusterExtensionSpec.PackageName
+		status.Channel = clusterExtensionSpec.Channel
+	}
+	if addon.Status.InstalledBundle != nil {
+		status.InstalledVersion = addon.Status.InstalledBundle.Name
+	}
+	return status
+}
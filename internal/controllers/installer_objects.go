@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/metrics"
+)
+
+// Installs Addons by applying objects directly, without OLM.
+// Handles the Manifests and Helm install types.
+type objectsInstaller struct {
+	r *AddonReconciler
+}
+
+var _ Installer = (*objectsInstaller)(nil)
+
+func (i *objectsInstaller) Config(addon *addonsv1alpha1.Addon) (InstallConfig, error) {
+	switch addon.Spec.Install.Type {
+	case addonsv1alpha1.Manifests:
+		if addon.Spec.Install.Manifests == nil ||
+			len(addon.Spec.Install.Manifests.Namespace) == 0 {
+			return InstallConfig{}, errors.New(
+				".spec.install.manifests.namespace is required when .spec.install.type = Manifests")
+		}
+		if (len(addon.Spec.Install.Manifests.Image) == 0) ==
+			(addon.Spec.Install.Manifests.ConfigMapRef == nil) {
+			return InstallConfig{}, errors.New(
+				"exactly one of .spec.install.manifests.image and .spec.install.manifests.configMapRef is required when .spec.install.type = Manifests")
+		}
+		return InstallConfig{
+			TargetNamespace: addon.Spec.Install.Manifests.Namespace,
+		}, nil
+
+	case addonsv1alpha1.Helm:
+		if addon.Spec.Install.Helm == nil ||
+			len(addon.Spec.Install.Helm.Namespace) == 0 {
+			return InstallConfig{}, errors.New(
+				".spec.install.helm.namespace is required when .spec.install.type = Helm")
+		}
+		chartRef := addon.Spec.Install.Helm.Chart
+		if len(chartRef.RepositoryURL) == 0 || len(chartRef.Name) == 0 || len(chartRef.Version) == 0 {
+			return InstallConfig{}, errors.New(
+				".spec.install.helm.chart requires .repositoryURL, .name and .version when .spec.install.type = Helm")
+		}
+		return InstallConfig{
+			TargetNamespace: addon.Spec.Install.Helm.Namespace,
+		}, nil
+	}
+	return InstallConfig{}, fmt.Errorf("install type %q is not installed by applying objects", addon.Spec.Install.Type)
+}
+
+func (i *objectsInstaller) Ensure(
+	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon,
+	phaseTimer *metrics.PhaseTimer,
+) (InstallResult, error) {
+	// Phase 5.
+	// Apply, prune and health check manifests
+	phaseTimer.Phase("ensure_manifests")
+	ensureResult, err := i.r.ensureManifests(ctx, log, addon)
+	if err != nil {
+		return InstallResultNil, fmt.Errorf("failed to ensure manifests: %w", err)
+	}
+	switch ensureResult {
+	case ensureManifestsResultRetry:
+		log.Info("requeuing", "reason", "manifests unready")
+		return InstallResultRetry, nil
+	case ensureManifestsResultStop:
+		return InstallResultStop, nil
+	}
+	return InstallResultNil, nil
+}
+
+func (i *objectsInstaller) Observe(ctx context.Context, addon *addonsv1alpha1.Addon) error {
+	if err := i.r.observeManifests(ctx, addon); err != nil {
+		return fmt.Errorf("observing manifests: %w", err)
+	}
+	return nil
+}
+
+// Deletes the applied objects, see (*AddonReconciler).teardownManifestObjects.
+func (i *objectsInstaller) Teardown(
+	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon) (InstallResult, error) {
+	done, err := i.r.teardownManifestObjects(ctx, addon)
+	if err != nil {
+		return InstallResultNil, fmt.Errorf("failed to uninstall manifests: %w", err)
+	}
+	if !done {
+		log.Info("requeuing", "reason", "waiting for manifest objects to be deleted")
+		return InstallResultRetry, nil
+	}
+	return InstallResultNil, nil
+}
+
+func (i *objectsInstaller) Status(addon *addonsv1alpha1.Addon) InstallStatus {
+	return InstallStatus{
+		ConditionTypes: []string{addonsv1alpha1.ManifestsReady},
+	}
+}
@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/metrics"
+)
+
+// Installs Addons via an OLM OperatorGroup and a CatalogSource and Subscription per package.
+// Handles the OLMOwnNamespace, OLMAllNamespaces and OLMMultiNamespace install types.
+type olmInstaller struct {
+	r *AddonReconciler
+}
+
+var _ Installer = (*olmInstaller)(nil)
+
+func (i *olmInstaller) Config(addon *addonsv1alpha1.Addon) (InstallConfig, error) {
+	switch addon.Spec.Install.Type {
+	case addonsv1alpha1.OLMOwnNamespace:
+		if addon.Spec.Install.OLMOwnNamespace == nil ||
+			len(addon.Spec.Install.OLMOwnNamespace.Namespace) == 0 {
+			return InstallConfig{}, errors.New(
+				".spec.install.ownNamespace.namespace is required when .spec.install.type = OwnNamespace")
+		}
+		if len(addon.Spec.Install.OLMOwnNamespace.CatalogSourceImage) == 0 {
+			return InstallConfig{}, errors.New(
+				".spec.install.ownNamespacee.catalogSourceImage is required when .spec.install.type = OwnNamespace")
+		}
+		return InstallConfig{
+			TargetNamespace:    addon.Spec.Install.OLMOwnNamespace.Namespace,
+			CatalogSourceImage: addon.Spec.Install.OLMOwnNamespace.CatalogSourceImage,
+		}, nil
+
+	case addonsv1alpha1.OLMAllNamespaces:
+		if addon.Spec.Install.OLMAllNamespaces == nil ||
+			len(addon.Spec.Install.OLMAllNamespaces.Namespace) == 0 {
+			return InstallConfig{}, errors.New(
+				".spec.install.allNamespaces.namespace is required when .spec.install.type = AllNamespaces")
+		}
+		if len(addon.Spec.Install.OLMAllNamespaces.CatalogSourceImage) == 0 {
+			return InstallConfig{}, errors.New(
+				".spec.install.allNamespaces.catalogSourceImage is required when .spec.install.type = AllNamespaces")
+		}
+		return InstallConfig{
+			TargetNamespace:    addon.Spec.Install.OLMAllNamespaces.Namespace,
+			CatalogSourceImage: addon.Spec.Install.OLMAllNamespaces.CatalogSourceImage,
+		}, nil
+
+	case addonsv1alpha1.OLMMultiNamespace:
+		if addon.Spec.Install.OLMMultiNamespace == nil ||
+			len(addon.Spec.Install.OLMMultiNamespace.Namespace) == 0 {
+			return InstallConfig{}, errors.New(
+				".spec.install.olmMultiNamespace.namespace is required when .spec.install.type = OLMMultiNamespace")
+		}
+		if len(addon.Spec.Install.OLMMultiNamespace.CatalogSourceImage) == 0 {
+			return InstallConfig{}, errors.New(
+				".spec.install.olmMultiNamespace.catalogSourceImage is required when .spec.install.type = OLMMultiNamespace")
+		}
+		if len(addon.Spec.Install.OLMMultiNamespace.TargetNamespaces) == 0 {
+			return InstallConfig{}, errors.New(
+				".spec.install.olmMultiNamespace.targetNamespaces is required when .spec.install.type = OLMMultiNamespace")
+		}
+		return InstallConfig{
+			TargetNamespace:    addon.Spec.Install.OLMMultiNamespace.Namespace,
+			CatalogSourceImage: addon.Spec.Install.OLMMultiNamespace.CatalogSourceImage,
+		}, nil
+	}
+	return InstallConfig{}, fmt.Errorf("install type %q is not installed via OLM", addon.Spec.Install.Type)
+}
+
+func (i *olmInstaller) Ensure(
+	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon,
+	phaseTimer *metrics.PhaseTimer,
+) (InstallResult, error) {
+	r := i.r
+
+	// Phase 5.
+	// Ensure OperatorGroup
+	phaseTimer.Phase("ensure_operator_group")
+	operatorGroupResult, err := r.ensureOperatorGroup(ctx, log, addon)
+	if err != nil {
+		return InstallResultNil, fmt.Errorf("failed to ensure OperatorGroup: %w", err)
+	}
+	switch operatorGroupResult {
+	case ensureOperatorGroupResultRetry:
+		return InstallResultRetry, nil
+	case ensureOperatorGroupResultStop:
+		return InstallResultStop, nil
+	}
+
+	// Phase 6.
+	// Ensure CatalogSource
+	phaseTimer.Phase("ensure_catalog_source")
+	ensureResult, catalogSources, err := r.ensureCatalogSource(ctx, log, addon)
+	if err != nil {
+		return InstallResultNil, fmt.Errorf("failed to ensure CatalogSource: %w", err)
+	}
+	switch ensureResult {
+	case ensureCatalogSourceResultRetry:
+		log.Info("requeuing", "reason", "catalogsource unready")
+		return InstallResultRetry, nil
+	case ensureCatalogSourceResultStop:
+		return InstallResultStop, nil
+	}
+
+	// Phase 7.
+	// Ensure Subscriptions for this Addon.
+	phaseTimer.Phase("ensure_subscription")
+	currentCSVKeys, requeue, err := r.ensureSubscription(
+		ctx, log.WithName("phase-ensure-subscription"),
+		addon, catalogSources)
+	if err != nil {
+		return InstallResultNil, fmt.Errorf("failed to ensure Subscription: %w", err)
+	} else if requeue {
+		return InstallResultRetry, nil
+	}
+
+	// Phase 8.
+	// Observe current csvs
+	phaseTimer.Phase("observe_csv")
+	if requeue, err := r.observeCurrentCSV(ctx, addon, currentCSVKeys); err != nil {
+		return InstallResultNil, fmt.Errorf("failed to observe current CSV: %w", err)
+	} else if requeue {
+		log.Info("requeuing", "reason", "csv unready")
+		return InstallResultRetry, nil
+	}
+	return InstallResultNil, nil
+}
+
+func (i *olmInstaller) Observe(ctx context.Context, addon *addonsv1alpha1.Addon) error {
+	r := i.r
+	commonInstallOptions := getCommonInstallOptions(addon)
+	if len(commonInstallOptions.Namespace) == 0 {
+		// misconfigured, nothing to observe
+		return nil
+	}
+
+	if err := r.observeCatalogSource(ctx, addon, commonInstallOptions.Namespace); err != nil {
+		return fmt.Errorf("observing CatalogSource: %w", err)
+	}
+
+	csvKeys, err := r.observeSubscription(ctx, addon, commonInstallOptions.Namespace)
+	if err != nil {
+		return fmt.Errorf("observing Subscription: %w", err)
+	}
+	if csvKeys == nil {
+		return nil
+	}
+
+	if err := r.observeCSV(ctx, addon, csvKeys); err != nil {
+		return fmt.Errorf("observing ClusterServiceVersion: %w", err)
+	}
+	return nil
+}
+
+// Deletes the OLM objects in stages, see (*AddonReconciler).teardownOLM.
+func (i *olmInstaller) Teardown(
+	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon) (InstallResult, error) {
+	done, err := i.r.teardownOLM(ctx, addon)
+	if err != nil {
+		return InstallResultNil, fmt.Errorf("failed to tear down OLM objects: %w", err)
+	}
+	if !done {
+		log.Info("requeuing", "reason", "waiting for OLM objects to be deleted")
+		return InstallResultRetry, nil
+	}
+
+	// Clear from CSV Event Handler,
+	// only now that the ClusterServiceVersions are gone.
+	i.r.csvEventHandler.Free(addon)
+	return InstallResultNil, nil
+}
+
+func (i *olmInstaller) Status(addon *addonsv1alpha1.Addon) InstallStatus {
+	commonInstallOptions := getCommonInstallOptions(addon)
+	var installedCSV string
+	if addon.Status.InstalledBundle != nil {
+		installedCSV = addon.Status.InstalledBundle.Name
+	}
+	return InstallStatus{
+		ConditionTypes: []string{
+			addonsv1alpha1.OperatorGroupReady,
+			addonsv1alpha1.CatalogSourceReady,
+			addonsv1alpha1.SubscriptionReady,
+			addonsv1alpha1.CSVSucceeded,
+			addonsv1alpha1.DeploymentsAvailable,
+		},
+		PackageName:      commonInstallOptions.PackageName,
+		Channel:          commonInstallOptions.Channel,
+		InstalledVersion: installedCSV,
+	}
+}
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/metrics"
+	"github.com/openshift/addon-operator/internal/testutil"
+)
+
+type installerMock struct {
+	mock.Mock
+}
+
+var _ Installer = (*installerMock)(nil)
+
+func (m *installerMock) Config(addon *addonsv1alpha1.Addon) (InstallConfig, error) {
+	args := m.Called(addon)
+	return args.Get(0).(InstallConfig), args.Error(1)
+}
+
+func (m *installerMock) Ensure(
+	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon,
+	phaseTimer *metrics.PhaseTimer,
+) (InstallResult, error) {
+	args := m.Called(ctx, log, addon, phaseTimer)
+	return args.Get(0).(InstallResult), args.Error(1)
+}
+
+func (m *installerMock) Observe(ctx context.Context, addon *addonsv1alpha1.Addon) error {
+	args := m.Called(ctx, addon)
+	return args.Error(0)
+}
+
+func (m *installerMock) Teardown(
+	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon) (InstallResult, error) {
+	args := m.Called(ctx, log, addon)
+	return args.Get(0).(InstallResult), args.Error(1)
+}
+
+func (m *installerMock) Status(addon *addonsv1alpha1.Addon) InstallStatus {
+	args := m.Called(addon)
+	return args.Get(0).(InstallStatus)
+}
+
+func TestInstallerFor(t *testing.T) {
+	r := &AddonReconciler{}
+
+	for _, installType := range []addonsv1alpha1.AddonInstallType{
+		addonsv1alpha1.OLMOwnNamespace,
+		addonsv1alpha1.OLMAllNamespaces,
+		addonsv1alpha1.OLMMultiNamespace,
+	} {
+		addon := &addonsv1alpha1.Addon{}
+		addon.Spec.Install.Type = installType
+		assert.IsType(t, &olmInstaller{}, r.installerFor(addon), installType)
+	}
+
+	addon := &addonsv1alpha1.Addon{}
+	for _, installType := range []addonsv1alpha1.AddonInstallType{
+		addonsv1alpha1.Manifests,
+		addonsv1alpha1.Helm,
+	} {
+		addon.Spec.Install.Type = installType
+		assert.IsType(t, &objectsInstaller{}, r.installerFor(addon), installType)
+	}
+
+	addon.Spec.Install.Type = "something something"
+	assert.Nil(t, r.installerFor(addon))
+
+	// additional installers can be registered
+	installer := &installerMock{}
+	r.RegisterInstaller(installer, "something something")
+	assert.Same(t, installer, r.installerFor(addon))
+}
+
+func TestInstaller_PhaseConditions(t *testing.T) {
+	installer := &installerMock{}
+	installer.On("Status", mock.Anything).Return(InstallStatus{
+		ConditionTypes: []string{"Installed"},
+	})
+
+	r := &AddonReconciler{}
+	r.RegisterInstaller(installer, "Test")
+
+	addon := &addonsv1alpha1.Addon{}
+	addon.Spec.Install.Type = "Test"
+	commonConditionTypes := []string{
+		addonsv1alpha1.NamespacesReady,
+		addonsv1alpha1.ParametersReady,
+		addonsv1alpha1.PullSecretsReady,
+		addonsv1alpha1.DependenciesReady,
+	}
+	assert.Equal(t, append(commonConditionTypes, "Installed"),
+		r.addonPhaseConditionTypes(addon))
+
+	for _, conditionType := range commonConditionTypes {
+		setPhaseCondition(addon, conditionType, metav1.ConditionTrue,
+			addonsv1alpha1.AddonReasonReady, "")
+	}
+	setPhaseCondition(addon, "Installed", metav1.ConditionFalse, "Installing", "")
+	r.setAvailableCondition(addon)
+	availableCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.Available)
+	if assert.NotNil(t, availableCond) {
+		assert.Equal(t, metav1.ConditionFalse, availableCond.Status)
+		assert.Equal(t, "Installing", availableCond.Reason)
+	}
+}
+
+func TestParseAddonInstallConfig_Installer(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		addon := &addonsv1alpha1.Addon{}
+		addon.Spec.Install.Type = "Test"
+
+		installer := &installerMock{}
+		installer.On("Config", addon).Return(InstallConfig{
+			TargetNamespace:    "addon-1",
+			CatalogSourceImage: "quay.io/osd-addons/addon-1-index@sha256:123",
+		}, nil)
+		r := &AddonReconciler{}
+		r.RegisterInstaller(installer, "Test")
+
+		targetNamespace, catalogSourceImage, stop, err := r.parseAddonInstallConfig(
+			context.Background(), testutil.NewLogger(t), addon)
+		require.NoError(t, err)
+		assert.False(t, stop)
+		assert.Equal(t, "addon-1", targetNamespace)
+		assert.Equal(t, "quay.io/osd-addons/addon-1-index@sha256:123", catalogSourceImage)
+	})
+
+	t.Run("configuration error", func(t *testing.T) {
+		addon := &addonsv1alpha1.Addon{}
+		addon.Spec.Install.Type = "Test"
+
+		c := testutil.NewClient()
+		c.StatusMock.
+			On("Update", testutil.IsContext, addon, mock.Anything).
+			Return(nil)
+		installer := &installerMock{}
+		installer.On("Config", addon).Return(InstallConfig{}, errors.New("invalid"))
+		r := &AddonReconciler{Client: c}
+		r.RegisterInstaller(installer, "Test")
+
+		_, _, stop, err := r.parseAddonInstallConfig(
+			context.Background(), testutil.NewLogger(t), addon)
+		require.NoError(t, err)
+		assert.True(t, stop)
+
+		availableCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.Available)
+		if assert.NotNil(t, availableCond) {
+			assert.Equal(t, addonsv1alpha1.AddonReasonConfigError, availableCond.Reason)
+			assert.Equal(t, "invalid", availableCond.Message)
+		}
+		c.StatusMock.AssertExpectations(t)
+	})
+}
+
+func TestHandleAddonDeletion_InstallerTeardown(t *testing.T) {
+	addon := &addonsv1alpha1.Addon{
+		ObjectMeta: metav1.ObjectMeta{
+			Finalizers: []string{cacheFinalizer},
+		},
+	}
+	addon.Spec.Install.Type = "Test"
+
+	c := testutil.NewClient()
+	c.StatusMock.
+		On("Update", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+	c.
+		On("Update", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+	c.
+		On("List", mock.Anything, mock.IsType(&corev1.NamespaceList{}), mock.Anything).
+		Return(nil)
+	installer := &installerMock{}
+	installer.On("Teardown", mock.Anything, mock.Anything, addon).Return(InstallResultNil, nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+	r.RegisterInstaller(installer, "Test")
+
+	requeue, err := r.handleAddonDeletion(context.Background(), addon)
+	require.NoError(t, err)
+	assert.False(t, requeue)
+	assert.Empty(t, addon.Finalizers)
+	installer.AssertExpectations(t)
+}
@@ -27,3 +27,25 @@ func commonLabelsAsLabelSelector(addon *addonsv1alpha1.Addon) labels.Selector {
 	labelSet[commonInstanceLabel] = addon.Name
 	return labelSet.AsSelector()
 }
+
+// Sets the desired entries on the given labels or annotations,
+// leaving all other entries untouched.
+// Returns true if the map was changed.
+func mergeStringMap(current *map[string]string, desired map[string]string) (changed bool) {
+	for key, value := range desired {
+		if existing, ok := (*current)[key]; ok && existing == value {
+			continue
+		}
+		if *current == nil {
+			*current = map[string]string{}
+		}
+		(*current)[key] = value
+		changed = true
+	}
+	return changed
+}
+
+// Returns true if the given labels contain the common labels of the Addon.
+func hasCommonLabels(objLabels map[string]string, addon *addonsv1alpha1.Addon) bool {
+	return commonLabelsAsLabelSelector(addon).Matches(labels.Set(objLabels))
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+)
+
+// Returns the maintenance windows of the given Addon,
+// falling back to the cluster-wide default of the AddonOperator.
+func (r *AddonReconciler) getMaintenanceWindows(
+	ctx context.Context, addon *addonsv1alpha1.Addon,
+) ([]addonsv1alpha1.AddonMaintenanceWindow, error) {
+	if len(addon.Spec.MaintenanceWindows) > 0 {
+		return addon.Spec.MaintenanceWindows, nil
+	}
+
+	addonOperator := &addonsv1alpha1.AddonOperator{}
+	err := r.Get(ctx, client.ObjectKey{
+		Name: addonsv1alpha1.DefaultAddonOperatorName,
+	}, addonOperator)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting AddonOperator: %w", err)
+	}
+	return addonOperator.Spec.MaintenanceWindows, nil
+}
+
+// Maps the AddonOperator to all Addons,
+// as Addons without maintenance windows use its defaults.
+func (r *AddonReconciler) enqueueAddonsForAddonOperator(obj client.Object) []reconcile.Request {
+	addonList := &addonsv1alpha1.AddonList{}
+	if err := r.List(context.Background(), addonList); err != nil {
+		r.Log.Error(err, "listing Addons to enqueue for AddonOperator",
+			"addonoperator", obj.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(addonList.Items))
+	for _, addon := range addonList.Items {
+		if len(addon.Spec.MaintenanceWindows) > 0 {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: addon.Name},
+		})
+	}
+	return requests
+}
+
+// Returns the maintenance window that is open at the given time
+// or the one opening next, if none is open.
+// Returns nil if no maintenance windows are given.
+func nextMaintenanceWindow(
+	windows []addonsv1alpha1.AddonMaintenanceWindow, now time.Time,
+) (*addonsv1alpha1.AddonMaintenanceWindowStatus, error) {
+	var next *addonsv1alpha1.AddonMaintenanceWindowStatus
+	for _, window := range windows {
+		schedule, err := cron.ParseStandard(window.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("parsing maintenance window schedule %q: %w", window.Schedule, err)
+		}
+
+		// The first activation after (now - duration) is either
+		// still open or the next one to open.
+		start := schedule.Next(now.Add(-window.Duration.Duration))
+		if start.IsZero() {
+			// schedule never activates
+			continue
+		}
+		end := start.Add(window.Duration.Duration)
+		if !end.After(now) {
+			// zero duration windows are never open
+			start = schedule.Next(now)
+			end = start.Add(window.Duration.Duration)
+		}
+
+		if next == nil || start.Before(next.Start.Time) {
+			next = &addonsv1alpha1.AddonMaintenanceWindowStatus{
+				Start: metav1.NewTime(start),
+				End:   metav1.NewTime(end),
+			}
+		}
+	}
+	return next, nil
+}
+
+// Checks if the given maintenance window is open at the given time.
+func isMaintenanceWindowOpen(
+	window *addonsv1alpha1.AddonMaintenanceWindowStatus, now time.Time) bool {
+	return window != nil &&
+		!window.Start.Time.After(now) && window.End.Time.After(now)
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/testutil"
+)
+
+func TestNextMaintenanceWindow(t *testing.T) {
+	// Saturday
+	now := time.Date(2021, time.May, 15, 3, 0, 0, 0, time.UTC)
+	saturdayNight := addonsv1alpha1.AddonMaintenanceWindow{
+		Schedule: "0 2 * * SAT",
+		Duration: metav1.Duration{Duration: 4 * time.Hour},
+	}
+	weekdayEvening := addonsv1alpha1.AddonMaintenanceWindow{
+		Schedule: "0 20 * * 1-5",
+		Duration: metav1.Duration{Duration: time.Hour},
+	}
+
+	tests := []struct {
+		name          string
+		windows       []addonsv1alpha1.AddonMaintenanceWindow
+		expectedStart time.Time
+		expectedEnd   time.Time
+		expectedOpen  bool
+	}{
+		{
+			name:          "open",
+			windows:       []addonsv1alpha1.AddonMaintenanceWindow{saturdayNight},
+			expectedStart: time.Date(2021, time.May, 15, 2, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2021, time.May, 15, 6, 0, 0, 0, time.UTC),
+			expectedOpen:  true,
+		},
+		{
+			name:          "next",
+			windows:       []addonsv1alpha1.AddonMaintenanceWindow{weekdayEvening},
+			expectedStart: time.Date(2021, time.May, 17, 20, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2021, time.May, 17, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "open window wins",
+			windows: []addonsv1alpha1.AddonMaintenanceWindow{
+				weekdayEvening, saturdayNight,
+			},
+			expectedStart: time.Date(2021, time.May, 15, 2, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2021, time.May, 15, 6, 0, 0, 0, time.UTC),
+			expectedOpen:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			window, err := nextMaintenanceWindow(test.windows, now)
+			require.NoError(t, err)
+			require.NotNil(t, window)
+
+			assert.True(t, test.expectedStart.Equal(window.Start.Time), window.Start.String())
+			assert.True(t, test.expectedEnd.Equal(window.End.Time), window.End.String())
+			assert.Equal(t, test.expectedOpen, isMaintenanceWindowOpen(window, now))
+		})
+	}
+}
+
+func TestNextMaintenanceWindow_NoWindows(t *testing.T) {
+	window, err := nextMaintenanceWindow(nil, time.Now())
+	require.NoError(t, err)
+	assert.Nil(t, window)
+}
+
+func TestNextMaintenanceWindow_InvalidSchedule(t *testing.T) {
+	_, err := nextMaintenanceWindow([]addonsv1alpha1.AddonMaintenanceWindow{
+		{Schedule: "never"},
+	}, time.Now())
+	assert.Error(t, err)
+}
+
+func TestEnsureSubscription_InvalidDefaultMaintenanceWindows(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("Get",
+		mock.Anything,
+		mock.Anything,
+		mock.IsType(&addonsv1alpha1.AddonOperator{}),
+	).Run(func(args mock.Arguments) {
+		addonOperator := args.Get(2).(*addonsv1alpha1.AddonOperator)
+		addonOperator.Spec.MaintenanceWindows = []addonsv1alpha1.AddonMaintenanceWindow{
+			{Schedule: "never"},
+		}
+	}).Return(nil)
+	c.StatusMock.On("Update",
+		mock.Anything,
+		testutil.IsAddonsv1alpha1AddonPtr,
+		mock.Anything,
+	).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	addon := newTestAddonWithCatalogSourceImage()
+	_, requeue, err := r.ensureSubscription(
+		context.Background(), testutil.NewLogger(t), addon, nil)
+	require.NoError(t, err)
+	assert.True(t, requeue)
+	c.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+
+	cond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.SubscriptionReady)
+	if assert.NotNil(t, cond) {
+		assert.Equal(t, metav1.ConditionFalse, cond.Status)
+		assert.Equal(t, addonsv1alpha1.AddonReasonConfigError, cond.Reason)
+	}
+}
+
+func TestEnqueueAddonsForAddonOperator(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("List",
+		mock.Anything,
+		mock.IsType(&addonsv1alpha1.AddonList{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		list := args.Get(1).(*addonsv1alpha1.AddonList)
+		list.Items = []addonsv1alpha1.Addon{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "addon-1"},
+			},
+			{
+				// not affected by the defaults
+				ObjectMeta: metav1.ObjectMeta{Name: "addon-2"},
+				Spec: addonsv1alpha1.AddonSpec{
+					MaintenanceWindows: []addonsv1alpha1.AddonMaintenanceWindow{
+						{Schedule: "0 2 * * *"},
+					},
+				},
+			},
+		}
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+	}
+
+	addonOperator := &addonsv1alpha1.AddonOperator{}
+	addonOperator.Name = addonsv1alpha1.DefaultAddonOperatorName
+	requests := r.enqueueAddonsForAddonOperator(addonOperator)
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, "addon-1", requests[0].Name)
+	}
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+)
+
+// Checks the health of the installation of the Addon via its Installer
+// and sets the respective phase conditions, without changing anything on the cluster.
+// Used while reconciliation is globally paused, so the Addon status does not go stale.
+// Conditions of phases that are not observed keep their last reported value.
+func (r *AddonReconciler) observeAddon(
+	ctx context.Context, addon *addonsv1alpha1.Addon) error {
+	installer := r.installerFor(addon)
+	if installer == nil {
+		// unsupported install type, nothing to observe
+		return nil
+	}
+	return installer.Observe(ctx, addon)
+}
+
+// Observes the CatalogSources of all OLM packages of the Addon.
+func (r *AddonReconciler) observeCatalogSource(
+	ctx context.Context, addon *addonsv1alpha1.Addon, namespace string) error {
+	for _, pkg := range getOLMPackages(addon) {
+		catalogSource := &operatorsv1alpha1.CatalogSource{}
+		err := r.Get(ctx, client.ObjectKey{
+			Name:      pkg.name,
+			Namespace: namespace,
+		}, catalogSource)
+		if k8sApiErrors.IsNotFound(err) {
+			setPhaseCondition(addon, addonsv1alpha1.CatalogSourceReady, metav1.ConditionFalse,
+				addonsv1alpha1.AddonReasonUnreadyCatalogSource, "CatalogSource not found")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if message := catalogSourceUnreadyMessage(catalogSource); message != "" {
+			setPhaseCondition(addon, addonsv1alpha1.CatalogSourceReady, metav1.ConditionFalse,
+				addonsv1alpha1.AddonReasonUnreadyCatalogSource,
+				fmt.Sprintf("CatalogSource connection is not ready: %s", message))
+			return nil
+		}
+	}
+	setPhaseCondition(addon, addonsv1alpha1.CatalogSourceReady, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+	return nil
+}
+
+// Observes the Subscriptions of all OLM packages of the Addon.
+// Returns the keys of the ClusterServiceVersions to observe,
+// or nil if a Subscription does not link one.
+func (r *AddonReconciler) observeSubscription(
+	ctx context.Context, addon *addonsv1alpha1.Addon, namespace string,
+) ([]client.ObjectKey, error) {
+	var csvKeys []client.ObjectKey
+	for _, pkg := range getOLMPackages(addon) {
+		subscription := &operatorsv1alpha1.Subscription{}
+		err := r.Get(ctx, client.ObjectKey{
+			Name:      pkg.name,
+			Namespace: namespace,
+		}, subscription)
+		if k8sApiErrors.IsNotFound(err) {
+			setPhaseCondition(addon, addonsv1alpha1.SubscriptionReady, metav1.ConditionFalse,
+				addonsv1alpha1.AddonReasonUnreadySubscription, "Subscription not found")
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		reason, message, err := r.subscriptionFailure(ctx, subscription)
+		if err != nil {
+			return nil, err
+		}
+		if len(reason) > 0 {
+			setPhaseCondition(addon, addonsv1alpha1.SubscriptionReady, metav1.ConditionFalse,
+				reason, message)
+			return nil, nil
+		}
+
+		if len(subscription.Status.InstalledCSV) == 0 ||
+			len(subscription.Status.CurrentCSV) == 0 {
+			setPhaseCondition(addon, addonsv1alpha1.SubscriptionReady, metav1.ConditionFalse,
+				addonsv1alpha1.AddonReasonUnreadySubscription,
+				"ClusterServiceVersion is not yet linked in the Subscription")
+			return nil, nil
+		}
+
+		csvKey := client.ObjectKey{
+			Name:      subscription.Status.CurrentCSV,
+			Namespace: namespace,
+		}
+		if addon.Status.PendingUpgrade != nil {
+			// the current CSV is held back, see ensureSubscription
+			csvKey.Name = subscription.Status.InstalledCSV
+		}
+		csvKeys = append(csvKeys, csvKey)
+	}
+	setPhaseCondition(addon, addonsv1alpha1.SubscriptionReady, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+	return csvKeys, nil
+}
+
+func (r *AddonReconciler) observeCSV(
+	ctx context.Context, addon *addonsv1alpha1.Addon, csvKeys []client.ObjectKey) error {
+	var csvs []*operatorsv1alpha1.ClusterServiceVersion
+	for _, csvKey := range csvKeys {
+		csv := &operatorsv1alpha1.ClusterServiceVersion{}
+		err := r.Get(ctx, csvKey, csv)
+		if k8sApiErrors.IsNotFound(err) {
+			setPhaseCondition(addon, addonsv1alpha1.CSVSucceeded, metav1.ConditionFalse,
+				addonsv1alpha1.AddonReasonUnreadyCSV, "ClusterServiceVersion not found")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if message := csvUnreadyMessage(csv); message != "" {
+			setPhaseCondition(addon, addonsv1alpha1.CSVSucceeded, metav1.ConditionFalse,
+				addonsv1alpha1.AddonReasonUnreadyCSV, message)
+			return nil
+		}
+		csvs = append(csvs, csv)
+	}
+	setPhaseCondition(addon, addonsv1alpha1.CSVSucceeded, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+
+	for _, csv := range csvs {
+		message, err := r.csvDeploymentsUnhealthyMessage(ctx, csv)
+		if err != nil {
+			return err
+		}
+		if message != "" {
+			setPhaseCondition(addon, addonsv1alpha1.DeploymentsAvailable, metav1.ConditionFalse,
+				addonsv1alpha1.AddonReasonUnhealthyDeployments, message)
+			return nil
+		}
+	}
+	setPhaseCondition(addon, addonsv1alpha1.DeploymentsAvailable, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+	return nil
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/testutil"
+)
+
+func TestObserveAddon(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "addon-1", Namespace: "addon-1"},
+		testutil.IsOperatorsV1Alpha1CatalogSourcePtr,
+	).Run(func(args mock.Arguments) {
+		cs := args.Get(2).(*operatorsv1alpha1.CatalogSource)
+		cs.Status.GRPCConnectionState = &operatorsv1alpha1.GRPCConnectionState{
+			LastObservedState: "READY",
+		}
+	}).Return(nil)
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "addon-1", Namespace: "addon-1"},
+		mock.IsType(&operatorsv1alpha1.Subscription{}),
+	).Run(func(args mock.Arguments) {
+		sub := args.Get(2).(*operatorsv1alpha1.Subscription)
+		sub.Status.InstalledCSV = "addon-1.v1.0.0"
+		sub.Status.CurrentCSV = "addon-1.v1.0.0"
+	}).Return(nil)
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "addon-1.v1.0.0", Namespace: "addon-1"},
+		mock.IsType(&operatorsv1alpha1.ClusterServiceVersion{}),
+	).Run(func(args mock.Arguments) {
+		csv := args.Get(2).(*operatorsv1alpha1.ClusterServiceVersion)
+		csv.Status.Phase = operatorsv1alpha1.CSVPhaseFailed
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	// Any Create, Update or Delete call would fail the test,
+	// because no expectations are registered for them.
+	addon := newTestAddonWithCatalogSourceImage()
+	err := r.observeAddon(context.Background(), addon)
+	require.NoError(t, err)
+	c.AssertExpectations(t)
+
+	assert.True(t, meta.IsStatusConditionTrue(
+		addon.Status.Conditions, addonsv1alpha1.CatalogSourceReady))
+	assert.True(t, meta.IsStatusConditionTrue(
+		addon.Status.Conditions, addonsv1alpha1.SubscriptionReady))
+	csvCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.CSVSucceeded)
+	if assert.NotNil(t, csvCond) {
+		assert.Equal(t, metav1.ConditionFalse, csvCond.Status)
+		assert.Equal(t, addonsv1alpha1.AddonReasonUnreadyCSV, csvCond.Reason)
+	}
+}
+
+func TestObserveAddon_SubscriptionNotFound(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		testutil.IsObjectKey,
+		testutil.IsOperatorsV1Alpha1CatalogSourcePtr,
+	).Return(newTestErrNotFound())
+	c.On("Get",
+		testutil.IsContext,
+		testutil.IsObjectKey,
+		mock.IsType(&operatorsv1alpha1.Subscription{}),
+	).Return(newTestErrNotFound())
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	addon := newTestAddonWithCatalogSourceImage()
+	err := r.observeAddon(context.Background(), addon)
+	require.NoError(t, err)
+	c.AssertExpectations(t)
+	// the ClusterServiceVersion is unknown without a Subscription
+	c.AssertNotCalled(t, "Get", testutil.IsContext, testutil.IsObjectKey,
+		mock.IsType(&operatorsv1alpha1.ClusterServiceVersion{}))
+
+	catalogSourceCond := meta.FindStatusCondition(
+		addon.Status.Conditions, addonsv1alpha1.CatalogSourceReady)
+	if assert.NotNil(t, catalogSourceCond) {
+		assert.Equal(t, metav1.ConditionFalse, catalogSourceCond.Status)
+		assert.Equal(t, addonsv1alpha1.AddonReasonUnreadyCatalogSource, catalogSourceCond.Reason)
+	}
+	subscriptionCond := meta.FindStatusCondition(
+		addon.Status.Conditions, addonsv1alpha1.SubscriptionReady)
+	if assert.NotNil(t, subscriptionCond) {
+		assert.Equal(t, metav1.ConditionFalse, subscriptionCond.Status)
+		assert.Equal(t, addonsv1alpha1.AddonReasonUnreadySubscription, subscriptionCond.Reason)
+	}
+	assert.Nil(t, meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.CSVSucceeded))
+}
+
+func TestObserveAddon_AdditionalPackages(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		testutil.IsObjectKey,
+		testutil.IsOperatorsV1Alpha1CatalogSourcePtr,
+	).Run(func(args mock.Arguments) {
+		cs := args.Get(2).(*operatorsv1alpha1.CatalogSource)
+		cs.Status.GRPCConnectionState = &operatorsv1alpha1.GRPCConnectionState{
+			LastObservedState: "READY",
+		}
+	}).Return(nil)
+	c.On("Get",
+		testutil.IsContext,
+		testutil.IsObjectKey,
+		mock.IsType(&operatorsv1alpha1.Subscription{}),
+	).Run(func(args mock.Arguments) {
+		key := args.Get(1).(client.ObjectKey)
+		sub := args.Get(2).(*operatorsv1alpha1.Subscription)
+		sub.Status.InstalledCSV = key.Name + ".v1.0.0"
+		sub.Status.CurrentCSV = key.Name + ".v1.0.0"
+	}).Return(nil)
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "addon-1.v1.0.0", Namespace: "addon-1"},
+		mock.IsType(&operatorsv1alpha1.ClusterServiceVersion{}),
+	).Run(func(args mock.Arguments) {
+		csv := args.Get(2).(*operatorsv1alpha1.ClusterServiceVersion)
+		csv.Status.Phase = operatorsv1alpha1.CSVPhaseSucceeded
+	}).Return(nil)
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "addon-1-companion-operator.v1.0.0", Namespace: "addon-1"},
+		mock.IsType(&operatorsv1alpha1.ClusterServiceVersion{}),
+	).Run(func(args mock.Arguments) {
+		csv := args.Get(2).(*operatorsv1alpha1.ClusterServiceVersion)
+		csv.Status.Phase = operatorsv1alpha1.CSVPhaseInstalling
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	addon := newTestAddonWithCatalogSourceImage()
+	addon.Spec.Install.OLMOwnNamespace.AdditionalPackages = []addonsv1alpha1.AddonInstallOLMPackage{
+		{
+			PackageName:        "companion-operator",
+			Channel:            "alpha",
+			CatalogSourceImage: "quay.io/osd-addons/companion-index@sha256:123",
+		},
+	}
+	err := r.observeAddon(context.Background(), addon)
+	require.NoError(t, err)
+	c.AssertExpectations(t)
+	c.AssertNumberOfCalls(t, "Get", 6)
+
+	assert.True(t, meta.IsStatusConditionTrue(
+		addon.Status.Conditions, addonsv1alpha1.CatalogSourceReady))
+	assert.True(t, meta.IsStatusConditionTrue(
+		addon.Status.Conditions, addonsv1alpha1.SubscriptionReady))
+	// the CSV of the additional package is still installing
+	assert.True(t, meta.IsStatusConditionFalse(
+		addon.Status.Conditions, addonsv1alpha1.CSVSucceeded))
+}
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-logr/logr"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+)
+
+// Approves the InstallPlans referenced by the given Subscriptions,
+// if it is allowed by the upgrade policy and maintenance windows of the Addon.
+// Returns true when an InstallPlan is held back,
+// the first held InstallPlan is reported as pending upgrade.
+func (r *AddonReconciler) ensureInstallPlanApproval(
+	ctx context.Context,
+	log logr.Logger,
+	addon *addonsv1alpha1.Addon,
+	upgradePolicy *addonsv1alpha1.AddonUpgradePolicy,
+	maintenanceWindows []addonsv1alpha1.AddonMaintenanceWindow,
+	subscriptions ...*operatorsv1alpha1.Subscription,
+) (held bool, err error) {
+	now := time.Now().UTC()
+	nextWindow, err := nextMaintenanceWindow(maintenanceWindows, now)
+	if err != nil {
+		// Maintenance windows are validated before, see ensureSubscription.
+		// Hold upgrades instead of installing them at any time.
+		log.Error(err, "invalid maintenance windows, holding upgrades")
+	}
+	inMaintenanceWindow := len(maintenanceWindows) == 0 ||
+		isMaintenanceWindowOpen(nextWindow, now)
+
+	var pendingUpgrade *addonsv1alpha1.AddonPendingUpgrade
+	for _, subscription := range subscriptions {
+		heldUpgrade, err := r.approveInstallPlan(
+			ctx, log, upgradePolicy, inMaintenanceWindow, subscription)
+		if err != nil {
+			return false, err
+		}
+		if pendingUpgrade == nil {
+			pendingUpgrade = heldUpgrade
+		}
+	}
+	return pendingUpgrade != nil, r.reportUpgradeStatus(ctx, addon, pendingUpgrade, nextWindow)
+}
+
+// Approves the InstallPlan referenced by the given Subscription if allowed,
+// otherwise returns the upgrade that is held back.
+func (r *AddonReconciler) approveInstallPlan(
+	ctx context.Context,
+	log logr.Logger,
+	upgradePolicy *addonsv1alpha1.AddonUpgradePolicy,
+	inMaintenanceWindow bool,
+	subscription *operatorsv1alpha1.Subscription,
+) (*addonsv1alpha1.AddonPendingUpgrade, error) {
+	if subscription.Status.InstallPlanRef == nil {
+		return nil, nil
+	}
+	if upgradePolicy == nil {
+		if subscription.Spec.InstallPlanApproval == operatorsv1alpha1.ApprovalManual {
+			return nil, nil
+		}
+		// OLM does not approve InstallPlans that were created while the
+		// Subscription was set to Manual, after it was reset to Automatic.
+		upgradePolicy = &addonsv1alpha1.AddonUpgradePolicy{
+			Type: addonsv1alpha1.UpgradePolicyAutomatic,
+		}
+	}
+
+	installPlan := &operatorsv1alpha1.InstallPlan{}
+	err := r.Get(ctx, client.ObjectKey{
+		Name:      subscription.Status.InstallPlanRef.Name,
+		Namespace: subscription.Status.InstallPlanRef.Namespace,
+	}, installPlan)
+	if k8sApiErrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting InstallPlan: %w", err)
+	}
+
+	if installPlan.Spec.Approved {
+		return nil, nil
+	}
+
+	initialInstall := len(subscription.Status.InstalledCSV) == 0
+	approve, err := isInstallPlanApprovedByPolicy(
+		upgradePolicy, initialInstall, installPlan.Spec.ClusterServiceVersionNames)
+	if err != nil {
+		log.Error(err, "holding InstallPlan", "installplan", installPlan.Name)
+	}
+	// the initial installation is not bound to maintenance windows
+	if !approve || (!initialInstall && !inMaintenanceWindow) {
+		return &addonsv1alpha1.AddonPendingUpgrade{
+			InstallPlanName:            installPlan.Name,
+			ClusterServiceVersionNames: installPlan.Spec.ClusterServiceVersionNames,
+		}, nil
+	}
+
+	log.Info("approving InstallPlan",
+		"installplan", installPlan.Name,
+		"csvs", installPlan.Spec.ClusterServiceVersionNames)
+	installPlan.Spec.Approved = true
+	if err := r.Update(ctx, installPlan); err != nil {
+		return nil, fmt.Errorf("approving InstallPlan: %w", err)
+	}
+	return nil, nil
+}
+
+// Checks if an InstallPlan for the given ClusterServiceVersions may be approved under the upgrade policy.
+func isInstallPlanApprovedByPolicy(
+	upgradePolicy *addonsv1alpha1.AddonUpgradePolicy,
+	initialInstall bool,
+	csvNames []string,
+) (bool, error) {
+	switch upgradePolicy.Type {
+	case addonsv1alpha1.UpgradePolicyAutomatic:
+		return true, nil
+
+	case addonsv1alpha1.UpgradePolicyManual:
+		return initialInstall, nil
+
+	case addonsv1alpha1.UpgradePolicyApproveUpTo:
+		maxVersion, err := semver.ParseTolerant(upgradePolicy.Version)
+		if err != nil {
+			return false, fmt.Errorf("parsing upgrade policy version: %w", err)
+		}
+
+		for _, csvName := range csvNames {
+			version, err := csvVersionFromName(csvName)
+			if err != nil {
+				return false, err
+			}
+			if version.GT(maxVersion) {
+				return false, nil
+			}
+		}
+		return true, nil
+
+	default:
+		return false, fmt.Errorf("unknown upgrade policy type: %q", upgradePolicy.Type)
+	}
+}
+
+// Extracts the version from a ClusterServiceVersion name,
+// following the OLM naming convention of <package>.v<version>.
+func csvVersionFromName(csvName string) (semver.Version, error) {
+	for i := strings.Index(csvName, ".v"); i != -1; {
+		version, err := semver.Parse(csvName[i+2:])
+		if err == nil {
+			return version, nil
+		}
+
+		next := strings.Index(csvName[i+2:], ".v")
+		if next == -1 {
+			break
+		}
+		i += next + 2
+	}
+	return semver.Version{}, fmt.Errorf("no version found in ClusterServiceVersion name %q", csvName)
+}
+
+// Records the upgrade held back and the next maintenance window in the Addon status.
+func (r *AddonReconciler) reportUpgradeStatus(
+	ctx context.Context, addon *addonsv1alpha1.Addon,
+	pendingUpgrade *addonsv1alpha1.AddonPendingUpgrade,
+	nextMaintenanceWindow *addonsv1alpha1.AddonMaintenanceWindowStatus) error {
+	if equality.Semantic.DeepEqual(addon.Status.PendingUpgrade, pendingUpgrade) &&
+		equality.Semantic.DeepEqual(addon.Status.NextMaintenanceWindow, nextMaintenanceWindow) {
+		return nil
+	}
+	addon.Status.PendingUpgrade = pendingUpgrade
+	addon.Status.NextMaintenanceWindow = nextMaintenanceWindow
+	return r.Status().Update(ctx, addon)
+}
+
+// Returns the time until held upgrades may be approved,
+// or zero if no upgrade is waiting for a maintenance window.
+func maintenanceWindowRequeueAfter(addon *addonsv1alpha1.Addon, now time.Time) time.Duration {
+	if addon.Status.PendingUpgrade == nil ||
+		addon.Status.NextMaintenanceWindow == nil {
+		return 0
+	}
+	return addon.Status.NextMaintenanceWindow.Start.Sub(now)
+}
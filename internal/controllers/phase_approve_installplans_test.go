@@ -0,0 +1,324 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/testutil"
+)
+
+func TestCSVVersionFromName(t *testing.T) {
+	tests := []struct {
+		csvName         string
+		expectedVersion string
+		expectErr       bool
+	}{
+		{csvName: "reference-addon.v0.1.3", expectedVersion: "0.1.3"},
+		{csvName: "my.vendor.operator.v1.2.3-rc.1", expectedVersion: "1.2.3-rc.1"},
+		{csvName: "reference-addon", expectErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.csvName, func(t *testing.T) {
+			version, err := csvVersionFromName(test.csvName)
+			if test.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedVersion, version.String())
+		})
+	}
+}
+
+func TestIsInstallPlanApprovedByPolicy(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         addonsv1alpha1.AddonUpgradePolicy
+		initialInstall bool
+		csvNames       []string
+		expected       bool
+	}{
+		{
+			name:     "Automatic",
+			policy:   addonsv1alpha1.AddonUpgradePolicy{Type: addonsv1alpha1.UpgradePolicyAutomatic},
+			csvNames: []string{"test.v1.0.0"},
+			expected: true,
+		},
+		{
+			name:           "Manual initial install",
+			policy:         addonsv1alpha1.AddonUpgradePolicy{Type: addonsv1alpha1.UpgradePolicyManual},
+			initialInstall: true,
+			csvNames:       []string{"test.v1.0.0"},
+			expected:       true,
+		},
+		{
+			name:     "Manual upgrade",
+			policy:   addonsv1alpha1.AddonUpgradePolicy{Type: addonsv1alpha1.UpgradePolicyManual},
+			csvNames: []string{"test.v1.0.0"},
+			expected: false,
+		},
+		{
+			name: "ApproveUpTo lower",
+			policy: addonsv1alpha1.AddonUpgradePolicy{
+				Type: addonsv1alpha1.UpgradePolicyApproveUpTo, Version: "1.2.0"},
+			csvNames: []string{"test.v1.1.0"},
+			expected: true,
+		},
+		{
+			name: "ApproveUpTo equal",
+			policy: addonsv1alpha1.AddonUpgradePolicy{
+				Type: addonsv1alpha1.UpgradePolicyApproveUpTo, Version: "v1.2.0"},
+			csvNames: []string{"test.v1.2.0"},
+			expected: true,
+		},
+		{
+			name: "ApproveUpTo higher",
+			policy: addonsv1alpha1.AddonUpgradePolicy{
+				Type: addonsv1alpha1.UpgradePolicyApproveUpTo, Version: "1.2.0"},
+			csvNames: []string{"test.v1.3.0"},
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			approved, err := isInstallPlanApprovedByPolicy(
+				&test.policy, test.initialInstall, test.csvNames)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, approved)
+		})
+	}
+}
+
+func TestEnsureInstallPlanApproval(t *testing.T) {
+	subscription := &operatorsv1alpha1.Subscription{
+		Status: operatorsv1alpha1.SubscriptionStatus{
+			InstalledCSV: "test.v1.0.0",
+			InstallPlanRef: &corev1.ObjectReference{
+				Name:      "install-abcde",
+				Namespace: "addon-1",
+			},
+		},
+	}
+
+	t.Run("approves", func(t *testing.T) {
+		c := testutil.NewClient()
+		c.On("Get",
+			mock.Anything,
+			client.ObjectKey{Name: "install-abcde", Namespace: "addon-1"},
+			mock.IsType(&operatorsv1alpha1.InstallPlan{}),
+		).Run(func(args mock.Arguments) {
+			ip := args.Get(2).(*operatorsv1alpha1.InstallPlan)
+			ip.Spec.ClusterServiceVersionNames = []string{"test.v1.1.0"}
+		}).Return(nil)
+		var updatedInstallPlan *operatorsv1alpha1.InstallPlan
+		c.On("Update",
+			mock.Anything,
+			mock.IsType(&operatorsv1alpha1.InstallPlan{}),
+			mock.Anything,
+		).Run(func(args mock.Arguments) {
+			updatedInstallPlan = args.Get(1).(*operatorsv1alpha1.InstallPlan)
+		}).Return(nil)
+
+		r := &AddonReconciler{Client: c}
+		held, err := r.ensureInstallPlanApproval(
+			context.Background(), testutil.NewLogger(t), &addonsv1alpha1.Addon{},
+			&addonsv1alpha1.AddonUpgradePolicy{Type: addonsv1alpha1.UpgradePolicyAutomatic},
+			nil, subscription)
+		require.NoError(t, err)
+		assert.False(t, held)
+		c.AssertExpectations(t)
+		if assert.NotNil(t, updatedInstallPlan) {
+			assert.True(t, updatedInstallPlan.Spec.Approved)
+		}
+	})
+
+	t.Run("holds and reports", func(t *testing.T) {
+		c := testutil.NewClient()
+		c.On("Get",
+			mock.Anything,
+			client.ObjectKey{Name: "install-abcde", Namespace: "addon-1"},
+			mock.IsType(&operatorsv1alpha1.InstallPlan{}),
+		).Run(func(args mock.Arguments) {
+			ip := args.Get(2).(*operatorsv1alpha1.InstallPlan)
+			ip.Name = "install-abcde"
+			ip.Spec.ClusterServiceVersionNames = []string{"test.v1.1.0"}
+		}).Return(nil)
+		c.StatusMock.On("Update",
+			mock.Anything,
+			testutil.IsAddonsv1alpha1AddonPtr,
+			mock.Anything,
+		).Return(nil)
+
+		addon := &addonsv1alpha1.Addon{}
+		r := &AddonReconciler{Client: c}
+		held, err := r.ensureInstallPlanApproval(
+			context.Background(), testutil.NewLogger(t), addon,
+			&addonsv1alpha1.AddonUpgradePolicy{Type: addonsv1alpha1.UpgradePolicyManual},
+			nil, subscription)
+		require.NoError(t, err)
+		assert.True(t, held)
+		c.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+		c.StatusMock.AssertExpectations(t)
+		if assert.NotNil(t, addon.Status.PendingUpgrade) {
+			assert.Equal(t, "install-abcde", addon.Status.PendingUpgrade.InstallPlanName)
+		}
+	})
+}
+
+func TestEnsureInstallPlanApproval_WithoutUpgradePolicy(t *testing.T) {
+	newSubscription := func(approval operatorsv1alpha1.Approval) *operatorsv1alpha1.Subscription {
+		return &operatorsv1alpha1.Subscription{
+			Spec: &operatorsv1alpha1.SubscriptionSpec{
+				InstallPlanApproval: approval,
+			},
+			Status: operatorsv1alpha1.SubscriptionStatus{
+				InstalledCSV: "test.v1.0.0",
+				InstallPlanRef: &corev1.ObjectReference{
+					Name:      "install-abcde",
+					Namespace: "addon-1",
+				},
+			},
+		}
+	}
+
+	t.Run("approves InstallPlans left from Manual approval", func(t *testing.T) {
+		c := testutil.NewClient()
+		c.On("Get",
+			mock.Anything,
+			client.ObjectKey{Name: "install-abcde", Namespace: "addon-1"},
+			mock.IsType(&operatorsv1alpha1.InstallPlan{}),
+		).Run(func(args mock.Arguments) {
+			ip := args.Get(2).(*operatorsv1alpha1.InstallPlan)
+			ip.Spec.ClusterServiceVersionNames = []string{"test.v1.1.0"}
+		}).Return(nil)
+		var updatedInstallPlan *operatorsv1alpha1.InstallPlan
+		c.On("Update",
+			mock.Anything,
+			mock.IsType(&operatorsv1alpha1.InstallPlan{}),
+			mock.Anything,
+		).Run(func(args mock.Arguments) {
+			updatedInstallPlan = args.Get(1).(*operatorsv1alpha1.InstallPlan)
+		}).Return(nil)
+
+		r := &AddonReconciler{Client: c}
+		held, err := r.ensureInstallPlanApproval(
+			context.Background(), testutil.NewLogger(t), &addonsv1alpha1.Addon{},
+			nil, nil, newSubscription(operatorsv1alpha1.ApprovalAutomatic))
+		require.NoError(t, err)
+		assert.False(t, held)
+		c.AssertExpectations(t)
+		if assert.NotNil(t, updatedInstallPlan) {
+			assert.True(t, updatedInstallPlan.Spec.Approved)
+		}
+	})
+
+	t.Run("ignores unmanaged Manual approval", func(t *testing.T) {
+		c := testutil.NewClient()
+
+		r := &AddonReconciler{Client: c}
+		held, err := r.ensureInstallPlanApproval(
+			context.Background(), testutil.NewLogger(t), &addonsv1alpha1.Addon{},
+			nil, nil, newSubscription(operatorsv1alpha1.ApprovalManual))
+		require.NoError(t, err)
+		assert.False(t, held)
+		c.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestEnsureInstallPlanApproval_OutsideMaintenanceWindow(t *testing.T) {
+	now := time.Now().UTC()
+	// opens in roughly a day and never includes now
+	schedule := fmt.Sprintf("%d %d * * *", now.Minute(), (now.Hour()+23)%24)
+
+	c := testutil.NewClient()
+	c.On("Get",
+		mock.Anything,
+		mock.Anything,
+		mock.IsType(&operatorsv1alpha1.InstallPlan{}),
+	).Run(func(args mock.Arguments) {
+		ip := args.Get(2).(*operatorsv1alpha1.InstallPlan)
+		ip.Name = "install-abcde"
+		ip.Spec.ClusterServiceVersionNames = []string{"test.v1.1.0"}
+	}).Return(nil)
+	c.StatusMock.On("Update",
+		mock.Anything,
+		testutil.IsAddonsv1alpha1AddonPtr,
+		mock.Anything,
+	).Return(nil)
+
+	addon := &addonsv1alpha1.Addon{}
+	r := &AddonReconciler{Client: c}
+	held, err := r.ensureInstallPlanApproval(
+		context.Background(), testutil.NewLogger(t), addon,
+		&addonsv1alpha1.AddonUpgradePolicy{Type: addonsv1alpha1.UpgradePolicyAutomatic},
+		[]addonsv1alpha1.AddonMaintenanceWindow{
+			{Schedule: schedule, Duration: metav1.Duration{Duration: time.Hour}},
+		},
+		&operatorsv1alpha1.Subscription{
+			Status: operatorsv1alpha1.SubscriptionStatus{
+				InstalledCSV: "test.v1.0.0",
+				InstallPlanRef: &corev1.ObjectReference{
+					Name:      "install-abcde",
+					Namespace: "addon-1",
+				},
+			},
+		})
+	require.NoError(t, err)
+	assert.True(t, held)
+	c.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	assert.NotNil(t, addon.Status.PendingUpgrade)
+	if assert.NotNil(t, addon.Status.NextMaintenanceWindow) {
+		assert.True(t, addon.Status.NextMaintenanceWindow.Start.After(now))
+	}
+	assert.Greater(t, int64(maintenanceWindowRequeueAfter(addon, now)), int64(0))
+}
+
+func TestEnqueueAddonForInstallPlan(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("Get",
+		mock.Anything,
+		client.ObjectKey{Name: "addon-1-companion", Namespace: "addon-1"},
+		mock.IsType(&operatorsv1alpha1.Subscription{}),
+	).Run(func(args mock.Arguments) {
+		sub := args.Get(2).(*operatorsv1alpha1.Subscription)
+		controller := true
+		sub.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: addonsv1alpha1.GroupVersion.String(),
+			Kind:       "Addon",
+			Name:       "addon-1",
+			Controller: &controller,
+		}}
+	}).Return(nil)
+
+	r := &AddonReconciler{Client: c, Log: testutil.NewLogger(t)}
+	installPlan := &operatorsv1alpha1.InstallPlan{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "install-abcde",
+			Namespace: "addon-1",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: operatorsv1alpha1.SchemeGroupVersion.String(),
+				Kind:       operatorsv1alpha1.SubscriptionKind,
+				Name:       "addon-1-companion",
+			}},
+		},
+	}
+
+	requests := r.enqueueAddonForInstallPlan(installPlan)
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, "addon-1", requests[0].Name)
+	}
+	c.AssertExpectations(t)
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
@@ -12,7 +13,12 @@ import (
 	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
 )
 
-// Ensure cleanup of Namespaces that are not needed anymore for the given Addon resource
+// Records the deletion policy of a Namespace,
+// so it is still known after the Namespace was removed from the Addon.
+const namespaceDeletionPolicyAnnotation = "addons.managed.openshift.io/namespace-deletion-policy"
+
+// Ensure cleanup of Namespaces that are not needed anymore for the given Addon resource.
+// Namespaces are deleted, orphaned or retained according to their deletion policy.
 func (r *AddonReconciler) ensureDeletionOfUnwantedNamespaces(
 	ctx context.Context, addon *addonsv1alpha1.Addon) error {
 	currentNamespaces, err := getOwnedNamespacesViaCommonLabels(ctx, r.Client, addon)
@@ -25,22 +31,141 @@ func (r *AddonReconciler) ensureDeletionOfUnwantedNamespaces(
 		wantedNamespaceNames[namespace.Name] = struct{}{}
 	}
 
-	for _, namespace := range currentNamespaces {
+	var (
+		retainedNamespaces []string
+		stuckNamespaces    []addonsv1alpha1.AddonStuckNamespace
+	)
+	for i := range currentNamespaces {
+		namespace := &currentNamespaces[i]
 		_, isWanted := wantedNamespaceNames[namespace.Name]
 		if isWanted {
 			// don't delete
 			continue
 		}
 
-		err := ensureNamespaceDeletion(ctx, r.Client, namespace.Name)
-		if err != nil {
+		policy := namespaceDeletionPolicy(addon, namespace)
+		if policy == addonsv1alpha1.NamespaceDeletionPolicyDelete {
+			if stuck := stuckNamespaceDiagnostics(namespace); stuck != nil {
+				stuckNamespaces = append(stuckNamespaces, *stuck)
+			}
+			if err := ensureNamespaceDeletion(ctx, r.Client, namespace.Name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := r.releaseNamespace(ctx, addon, namespace, policy); err != nil {
 			return err
 		}
+		if policy == addonsv1alpha1.NamespaceDeletionPolicyRetain {
+			retainedNamespaces = append(retainedNamespaces, namespace.Name)
+		}
+	}
+	addon.Status.RetainedNamespaces = retainedNamespaces
+	r.reportStuckNamespaces(addon, func(name string) bool {
+		_, isWanted := wantedNamespaceNames[name]
+		return !isWanted
+	}, stuckNamespaces)
+
+	return nil
+}
+
+// Releases the given Namespace from the Addon without deleting it,
+// by removing the owner reference of the Addon, so the garbage collector keeps it.
+// Orphaned Namespaces additionally lose the common labels and the deletion policy annotation,
+// retained Namespaces keep them to still be recognized as Namespace of the Addon.
+func (r *AddonReconciler) releaseNamespace(
+	ctx context.Context, addon *addonsv1alpha1.Addon,
+	namespace *corev1.Namespace, policy addonsv1alpha1.AddonNamespaceDeletionPolicy,
+) error {
+	ownerRefs, changed := withoutOwnerReferencesOf(namespace.OwnerReferences, addon)
+	namespace.OwnerReferences = ownerRefs
+
+	if policy == addonsv1alpha1.NamespaceDeletionPolicyOrphan {
+		for _, label := range []string{commonManagedByLabel, commonInstanceLabel} {
+			if _, ok := namespace.Labels[label]; ok {
+				delete(namespace.Labels, label)
+				changed = true
+			}
+		}
+		if _, ok := namespace.Annotations[namespaceDeletionPolicyAnnotation]; ok {
+			delete(namespace.Annotations, namespaceDeletionPolicyAnnotation)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
 	}
 
+	if err := r.Update(ctx, namespace); err != nil {
+		return fmt.Errorf("releasing Namespace: %w", err)
+	}
+	eventReason := eventReasonNamespaceRetained
+	if policy == addonsv1alpha1.NamespaceDeletionPolicyOrphan {
+		eventReason = eventReasonNamespaceOrphaned
+	}
+	r.recordEvent(addon, corev1.EventTypeNormal, eventReason,
+		"Released Namespace %q with deletion policy %s", namespace.Name, policy)
 	return nil
 }
 
+// Returns the given owner references without those pointing to the Addon
+// and whether any were removed.
+func withoutOwnerReferencesOf(
+	ownerRefs []metav1.OwnerReference, addon *addonsv1alpha1.Addon) ([]metav1.OwnerReference, bool) {
+	var (
+		removed bool
+		kept    []metav1.OwnerReference
+	)
+	for _, ownerRef := range ownerRefs {
+		if ownerRef.UID == addon.UID {
+			removed = true
+			continue
+		}
+		kept = append(kept, ownerRef)
+	}
+	return kept, removed
+}
+
+// Returns the deletion policy of the given Namespace of the Addon.
+// Namespaces listed in the Addon use the policy from the spec,
+// Namespaces removed from the Addon the policy recorded on the Namespace by ensureNamespace.
+func namespaceDeletionPolicy(
+	addon *addonsv1alpha1.Addon, namespace *corev1.Namespace) addonsv1alpha1.AddonNamespaceDeletionPolicy {
+	for _, addonNamespace := range addon.Spec.Namespaces {
+		if addonNamespace.Name == namespace.Name {
+			return specNamespaceDeletionPolicy(addon, addonNamespace)
+		}
+	}
+	if policy, ok := namespace.Annotations[namespaceDeletionPolicyAnnotation]; ok {
+		return addonsv1alpha1.AddonNamespaceDeletionPolicy(policy)
+	}
+	return specNamespaceDeletionPolicy(addon, addonsv1alpha1.AddonNamespace{})
+}
+
+// Returns the deletion policy of the given Namespace entry,
+// falling back to the default of the Addon and then to Delete.
+func specNamespaceDeletionPolicy(
+	addon *addonsv1alpha1.Addon, namespace addonsv1alpha1.AddonNamespace) addonsv1alpha1.AddonNamespaceDeletionPolicy {
+	if len(namespace.DeletionPolicy) > 0 {
+		return namespace.DeletionPolicy
+	}
+	if len(addon.Spec.NamespaceDeletionPolicy) > 0 {
+		return addon.Spec.NamespaceDeletionPolicy
+	}
+	return addonsv1alpha1.NamespaceDeletionPolicyDelete
+}
+
+// Returns true if the Namespace with the given name was retained after being removed from the Addon.
+func isRetainedNamespace(addon *addonsv1alpha1.Addon, name string) bool {
+	for _, retainedNamespace := range addon.Status.RetainedNamespaces {
+		if retainedNamespace == name {
+			return true
+		}
+	}
+	return false
+}
+
 // Ensure that the given Namespace is deleted
 func ensureNamespaceDeletion(ctx context.Context, c client.Client, name string) error {
 	namespace := &corev1.Namespace{
@@ -74,3 +199,99 @@ func getOwnedNamespacesViaCommonLabels(
 
 	return list.Items, nil
 }
+
+// Returns why the given terminating Namespace can not be finalized,
+// or nil if it is not terminating or its status conditions report no problem.
+func stuckNamespaceDiagnostics(namespace *corev1.Namespace) *addonsv1alpha1.AddonStuckNamespace {
+	if namespace.DeletionTimestamp.IsZero() &&
+		namespace.Status.Phase != corev1.NamespaceTerminating {
+		return nil
+	}
+
+	stuck := &addonsv1alpha1.AddonStuckNamespace{Name: namespace.Name}
+	for _, cond := range namespace.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case corev1.NamespaceContentRemaining:
+			stuck.RemainingResources = append(stuck.RemainingResources,
+				splitNamespaceConditionMessage(cond.Message)...)
+		case corev1.NamespaceFinalizersRemaining:
+			stuck.RemainingFinalizers = append(stuck.RemainingFinalizers,
+				splitNamespaceConditionMessage(cond.Message)...)
+		case corev1.NamespaceDeletionDiscoveryFailure,
+			corev1.NamespaceDeletionContentFailure,
+			corev1.NamespaceDeletionGVParsingFailure:
+			stuck.Failures = append(stuck.Failures, cond.Message)
+		}
+	}
+	if len(stuck.RemainingResources) == 0 &&
+		len(stuck.RemainingFinalizers) == 0 &&
+		len(stuck.Failures) == 0 {
+		return nil
+	}
+	return stuck
+}
+
+// Splits the list of a Namespace condition message into its items, e.g.
+// "Some resources are remaining: foos.example.com has 2 resource instances, pods. has 1 resource instances".
+func splitNamespaceConditionMessage(message string) []string {
+	if i := strings.Index(message, ": "); i >= 0 {
+		message = message[i+2:]
+	}
+
+	var items []string
+	for _, item := range strings.Split(message, ", ") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Describes what blocks the finalization of the given stuck Namespaces.
+func stuckNamespacesMessage(stuckNamespaces []addonsv1alpha1.AddonStuckNamespace) string {
+	messages := make([]string, len(stuckNamespaces))
+	for i, stuck := range stuckNamespaces {
+		messages[i] = stuckNamespaceMessage(stuck)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Describes what blocks the finalization of the given stuck Namespace.
+func stuckNamespaceMessage(stuck addonsv1alpha1.AddonStuckNamespace) string {
+	var details []string
+	if len(stuck.RemainingResources) > 0 {
+		details = append(details,
+			"remaining resources: "+strings.Join(stuck.RemainingResources, ", "))
+	}
+	if len(stuck.RemainingFinalizers) > 0 {
+		details = append(details,
+			"remaining finalizers: "+strings.Join(stuck.RemainingFinalizers, ", "))
+	}
+	details = append(details, stuck.Failures...)
+	return fmt.Sprintf("Namespace %s is stuck terminating, %s",
+		stuck.Name, strings.Join(details, "; "))
+}
+
+// Replaces the stuck Namespaces in the Addon status that the calling phase is responsible for
+// and records a Warning Event for them.
+func (r *AddonReconciler) reportStuckNamespaces(
+	addon *addonsv1alpha1.Addon, isResponsible func(name string) bool,
+	stuckNamespaces []addonsv1alpha1.AddonStuckNamespace,
+) {
+	var reported []addonsv1alpha1.AddonStuckNamespace
+	for _, stuck := range addon.Status.StuckNamespaces {
+		if !isResponsible(stuck.Name) {
+			reported = append(reported, stuck)
+		}
+	}
+	addon.Status.StuckNamespaces = append(reported, stuckNamespaces...)
+	if len(stuckNamespaces) == 0 {
+		return
+	}
+
+	r.recordEvent(addon, corev1.EventTypeWarning, eventReasonNamespaceStuck,
+		"%s", stuckNamespacesMessage(stuckNamespaces))
+}
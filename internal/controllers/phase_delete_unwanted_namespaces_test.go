@@ -5,12 +5,16 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
 	"github.com/openshift/addon-operator/internal/testutil"
 )
 
@@ -137,3 +141,191 @@ func TestEnsureDeletionOfUnwantedNamespaces_NoNamespacesInSpec_WithClientError(t
 	require.EqualError(t, errors.Unwrap(err), timeoutErr.Error())
 	c.AssertExpectations(t)
 }
+
+func TestEnsureDeletionOfUnwantedNamespaces_DeletionPolicy(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         addonsv1alpha1.AddonNamespaceDeletionPolicy
+		expectedLabels map[string]string
+		retained       []string
+	}{
+		{
+			name:           "Orphan",
+			policy:         addonsv1alpha1.NamespaceDeletionPolicyOrphan,
+			expectedLabels: map[string]string{"team": "addons"},
+		},
+		{
+			name:   "Retain",
+			policy: addonsv1alpha1.NamespaceDeletionPolicyRetain,
+			expectedLabels: map[string]string{
+				"team":               "addons",
+				commonManagedByLabel: commonManagedByValue,
+				commonInstanceLabel:  "addon-1",
+			},
+			retained: []string{"namespace-1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addon := newTestAddonWithoutNamespace()
+			addon.UID = "addon-uid"
+
+			existingNamespace := newTestExistingNamespaceWithoutOwner()
+			existingNamespace.Labels = map[string]string{"team": "addons"}
+			addCommonLabels(existingNamespace.Labels, addon)
+			existingNamespace.Annotations = map[string]string{
+				namespaceDeletionPolicyAnnotation: string(test.policy),
+			}
+			require.NoError(t, controllerutil.SetControllerReference(
+				addon, existingNamespace, newTestSchemeWithAddonsv1alpha1()))
+
+			c := testutil.NewClient()
+			c.On("List", testutil.IsContext, testutil.IsCoreV1NamespaceListPtr, mock.Anything).
+				Run(func(args mock.Arguments) {
+					arg := args.Get(1).(*corev1.NamespaceList)
+					arg.Items = []corev1.Namespace{*existingNamespace}
+				}).
+				Return(nil)
+			var updatedNamespace *corev1.Namespace
+			c.On("Update", testutil.IsContext, testutil.IsCoreV1NamespacePtr, mock.Anything).
+				Run(func(args mock.Arguments) {
+					updatedNamespace = args.Get(1).(*corev1.Namespace)
+				}).
+				Return(nil)
+
+			r := &AddonReconciler{
+				Client: c,
+				Log:    testutil.NewLogger(t),
+				Scheme: newTestSchemeWithAddonsv1alpha1(),
+			}
+
+			ctx := context.Background()
+			err := r.ensureDeletionOfUnwantedNamespaces(ctx, addon)
+			require.NoError(t, err)
+			c.AssertExpectations(t)
+			c.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+
+			if assert.NotNil(t, updatedNamespace) {
+				assert.Empty(t, updatedNamespace.OwnerReferences)
+				assert.Equal(t, test.expectedLabels, updatedNamespace.Labels)
+			}
+			assert.Equal(t, test.retained, addon.Status.RetainedNamespaces)
+		})
+	}
+}
+
+func TestNamespaceDeletionPolicy(t *testing.T) {
+	addon := newTestAddonWithoutNamespace()
+	addon.Spec.Namespaces = []addonsv1alpha1.AddonNamespace{
+		{Name: "data", DeletionPolicy: addonsv1alpha1.NamespaceDeletionPolicyRetain},
+		{Name: "operator"},
+	}
+
+	namespace := func(name, policy string) *corev1.Namespace {
+		namespace := &corev1.Namespace{}
+		namespace.Name = name
+		if len(policy) > 0 {
+			namespace.Annotations = map[string]string{
+				namespaceDeletionPolicyAnnotation: policy,
+			}
+		}
+		return namespace
+	}
+
+	// spec wins over a stale annotation
+	assert.Equal(t, addonsv1alpha1.NamespaceDeletionPolicyRetain,
+		namespaceDeletionPolicy(addon, namespace("data", "Delete")))
+	assert.Equal(t, addonsv1alpha1.NamespaceDeletionPolicyDelete,
+		namespaceDeletionPolicy(addon, namespace("operator", "")))
+	// removed Namespaces use the recorded policy
+	assert.Equal(t, addonsv1alpha1.NamespaceDeletionPolicyOrphan,
+		namespaceDeletionPolicy(addon, namespace("removed", "Orphan")))
+
+	addon.Spec.NamespaceDeletionPolicy = addonsv1alpha1.NamespaceDeletionPolicyOrphan
+	assert.Equal(t, addonsv1alpha1.NamespaceDeletionPolicyOrphan,
+		namespaceDeletionPolicy(addon, namespace("operator", "")))
+	assert.Equal(t, addonsv1alpha1.NamespaceDeletionPolicyOrphan,
+		namespaceDeletionPolicy(addon, namespace("removed", "")))
+}
+
+func TestEnsureDeletionOfUnwantedNamespaces_StuckNamespace(t *testing.T) {
+	addon := newTestAddonWithoutNamespace()
+	addon.Status.StuckNamespaces = []addonsv1alpha1.AddonStuckNamespace{
+		{Name: "gone", Failures: []string{"stale"}},
+	}
+
+	now := metav1.Now()
+	existingNamespace := newTestNamespace()
+	existingNamespace.DeletionTimestamp = &now
+	existingNamespace.Status.Conditions = []corev1.NamespaceCondition{
+		{
+			Type:   corev1.NamespaceDeletionDiscoveryFailure,
+			Status: corev1.ConditionTrue,
+			Message: "Discovery failed for some groups, 1 failing: unable to retrieve the complete list of server APIs: " +
+				"metrics.k8s.io/v1beta1: the server is currently unable to handle the request",
+		},
+		{
+			Type:   corev1.NamespaceContentRemaining,
+			Status: corev1.ConditionFalse,
+		},
+	}
+
+	c := testutil.NewClient()
+	c.On("List", testutil.IsContext, testutil.IsCoreV1NamespaceListPtr, mock.Anything).
+		Run(func(args mock.Arguments) {
+			arg := args.Get(1).(*corev1.NamespaceList)
+			arg.Items = []corev1.Namespace{*existingNamespace}
+		}).
+		Return(nil)
+	c.On("Delete", testutil.IsContext, testutil.IsCoreV1NamespacePtr, mock.Anything).
+		Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	ctx := context.Background()
+	err := r.ensureDeletionOfUnwantedNamespaces(ctx, addon)
+	require.NoError(t, err)
+	c.AssertExpectations(t)
+
+	assert.Equal(t, []addonsv1alpha1.AddonStuckNamespace{
+		{
+			Name: "namespace-1",
+			Failures: []string{
+				"Discovery failed for some groups, 1 failing: unable to retrieve the complete list of server APIs: " +
+					"metrics.k8s.io/v1beta1: the server is currently unable to handle the request",
+			},
+		},
+	}, addon.Status.StuckNamespaces)
+}
+
+func TestStuckNamespaceDiagnostics(t *testing.T) {
+	namespace := &corev1.Namespace{}
+	namespace.Name = "namespace-1"
+	namespace.Status.Conditions = []corev1.NamespaceCondition{
+		{
+			Type:    corev1.NamespaceContentRemaining,
+			Status:  corev1.ConditionTrue,
+			Message: "Some resources are remaining: foos.example.com has 2 resource instances, pods. has 1 resource instances",
+		},
+	}
+	// not terminating
+	assert.Nil(t, stuckNamespaceDiagnostics(namespace))
+
+	namespace.Status.Phase = corev1.NamespaceTerminating
+	assert.Equal(t, &addonsv1alpha1.AddonStuckNamespace{
+		Name: "namespace-1",
+		RemainingResources: []string{
+			"foos.example.com has 2 resource instances",
+			"pods. has 1 resource instances",
+		},
+	}, stuckNamespaceDiagnostics(namespace))
+
+	// terminating without problems
+	namespace.Status.Conditions[0].Status = corev1.ConditionFalse
+	assert.Nil(t, stuckNamespaceDiagnostics(namespace))
+}
@@ -2,13 +2,15 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/go-logr/logr"
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -16,7 +18,11 @@ import (
 	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
 )
 
-const catalogSourcePublisher = "OSD Red Hat Addons"
+const (
+	catalogSourcePublisher = "OSD Red Hat Addons"
+	// Label OLM puts on the registry Pods serving a CatalogSource.
+	catalogSourcePodLabel = "olm.catalogSource"
+)
 
 type ensureCatalogSourceResult int
 
@@ -26,13 +32,13 @@ const (
 	ensureCatalogSourceResultRetry ensureCatalogSourceResult = iota
 )
 
-// Ensure existence of the CatalogSource specified in the given Addon resource
+// Ensure existence of the CatalogSources of all OLM packages specified in the given Addon resource
 // returns an ensureCatalogSourceResult that signals the caller if they have to
 // stop or retry reconciliation of the surrounding Addon resource
 func (r *AddonReconciler) ensureCatalogSource(
 	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon,
-) (ensureCatalogSourceResult, *operatorsv1alpha1.CatalogSource, error) {
-	targetNamespace, catalogSourceImage, stop, err := r.parseAddonInstallConfig(ctx, log, addon)
+) (ensureCatalogSourceResult, []*operatorsv1alpha1.CatalogSource, error) {
+	targetNamespace, _, stop, err := r.parseAddonInstallConfig(ctx, log, addon)
 	if err != nil {
 		return ensureCatalogSourceResultNil, nil, err
 	}
@@ -40,58 +46,173 @@ func (r *AddonReconciler) ensureCatalogSource(
 		return ensureCatalogSourceResultStop, nil, nil
 	}
 
-	catalogSource := &operatorsv1alpha1.CatalogSource{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      addon.Name,
-			Namespace: targetNamespace,
-		},
-		Spec: operatorsv1alpha1.CatalogSourceSpec{
-			SourceType:  operatorsv1alpha1.SourceTypeGrpc,
-			Publisher:   catalogSourcePublisher,
-			DisplayName: addon.Spec.DisplayName,
-			Image:       catalogSourceImage,
-		},
-	}
+	updateStrategy := newCatalogSourceUpdateStrategy(
+		getCommonInstallOptions(addon).CatalogUpdatePolicy)
+	var observedCatalogSources []*operatorsv1alpha1.CatalogSource
+	for _, pkg := range getOLMPackages(addon) {
+		catalogSource := &operatorsv1alpha1.CatalogSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pkg.name,
+				Namespace: targetNamespace,
+				Labels:    map[string]string{},
+			},
+			Spec: operatorsv1alpha1.CatalogSourceSpec{
+				SourceType:     operatorsv1alpha1.SourceTypeGrpc,
+				Publisher:      catalogSourcePublisher,
+				DisplayName:    addon.Spec.DisplayName,
+				Image:          pkg.catalogSourceImage,
+				Secrets:        pullSecretNames(addon),
+				UpdateStrategy: updateStrategy,
+			},
+		}
 
-	addCommonLabels(catalogSource.Labels, addon)
+		addCommonLabels(catalogSource.Labels, addon)
 
-	if err := controllerutil.SetControllerReference(addon, catalogSource, r.Scheme); err != nil {
-		return ensureCatalogSourceResultNil, nil, err
-	}
+		if err := controllerutil.SetControllerReference(addon, catalogSource, r.Scheme); err != nil {
+			return ensureCatalogSourceResultNil, nil, err
+		}
 
-	var observedCatalogSource *operatorsv1alpha1.CatalogSource
-	{
-		var err error
-		observedCatalogSource, err = reconcileCatalogSource(ctx, r.Client, catalogSource)
+		observedCatalogSource, err := reconcileCatalogSource(ctx, r.Client, addon, catalogSource)
+		var collision *adoptionCollisionError
+		if errors.As(err, &collision) {
+			return ensureCatalogSourceResultRetry, nil,
+				r.reportAdoptionCollision(ctx, addon, addonsv1alpha1.CatalogSourceReady, collision)
+		}
 		if err != nil {
 			return ensureCatalogSourceResultNil, nil, err
 		}
+
+		if message := catalogSourceUnreadyMessage(observedCatalogSource); message != "" {
+			err := r.reportCatalogSourceUnreadinessStatus(ctx, addon, observedCatalogSource, message)
+			if err != nil {
+				return ensureCatalogSourceResultNil, nil, err
+			}
+			return ensureCatalogSourceResultRetry, nil, nil
+		}
+		observedCatalogSources = append(observedCatalogSources, observedCatalogSource)
+	}
+
+	setPhaseCondition(addon, addonsv1alpha1.CatalogSourceReady, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+	for _, catalogSource := range observedCatalogSources {
+		r.recordEvent(addon, corev1.EventTypeNormal, eventReasonCatalogSourceReady,
+			"CatalogSource %s/%s is ready", catalogSource.Namespace, catalogSource.Name)
+	}
+
+	if err := r.observeCatalogUpdates(ctx, addon, observedCatalogSources); err != nil {
+		return ensureCatalogSourceResultNil, nil, fmt.Errorf("observing catalog updates: %w", err)
+	}
+	return ensureCatalogSourceResultNil, observedCatalogSources, nil
+}
+
+// Converts the Addon catalog update policy into the CatalogSource update strategy.
+func newCatalogSourceUpdateStrategy(
+	policy *addonsv1alpha1.AddonCatalogUpdatePolicy) *operatorsv1alpha1.UpdateStrategy {
+	if policy == nil {
+		return nil
+	}
+	return &operatorsv1alpha1.UpdateStrategy{
+		RegistryPoll: &operatorsv1alpha1.RegistryPoll{
+			Interval: &metav1.Duration{Duration: policy.Interval.Duration},
+		},
 	}
+}
 
-	if observedCatalogSource.Status.GRPCConnectionState == nil {
-		err := r.reportCatalogSourceUnreadinessStatus(ctx, addon, observedCatalogSource, ".Status.GRPCConnectionState is nil")
+// Records the digests of the catalog images served by the given CatalogSources
+// and when OLM last polled them in the Addon status.
+func (r *AddonReconciler) observeCatalogUpdates(
+	ctx context.Context, addon *addonsv1alpha1.Addon,
+	catalogSources []*operatorsv1alpha1.CatalogSource) error {
+	lastStatuses := map[string]addonsv1alpha1.AddonCatalogStatus{}
+	for _, lastStatus := range addon.Status.Catalogs {
+		lastStatuses[lastStatus.CatalogSource] = lastStatus
+	}
+
+	catalogStatuses := make([]addonsv1alpha1.AddonCatalogStatus, 0, len(catalogSources))
+	for _, catalogSource := range catalogSources {
+		digest, err := r.getCatalogDigest(catalogSource)
 		if err != nil {
-			return ensureCatalogSourceResultNil, nil, err
+			return err
 		}
-		return ensureCatalogSourceResultRetry, nil, nil
-	}
-	if observedCatalogSource.Status.GRPCConnectionState.LastObservedState != "READY" {
-		err := r.reportCatalogSourceUnreadinessStatus(
-			ctx,
-			addon,
-			observedCatalogSource,
-			fmt.Sprintf(
-				".Status.GRPCConnectionState.LastObservedState == %s",
-				observedCatalogSource.Status.GRPCConnectionState.LastObservedState,
-			),
-		)
-		if err != nil {
-			return ensureCatalogSourceResultNil, nil, err
+
+		catalogStatus := addonsv1alpha1.AddonCatalogStatus{
+			CatalogSource: catalogSource.Name,
+			Digest:        digest,
+			LastPollTime:  catalogSource.Status.LatestImageRegistryPoll,
+		}
+		if lastStatus, ok := lastStatuses[catalogSource.Name]; ok {
+			catalogStatus.LastUpdateTime = lastStatus.LastUpdateTime
+			if len(lastStatus.Digest) > 0 && len(digest) > 0 && lastStatus.Digest != digest {
+				now := metav1.Now()
+				catalogStatus.LastUpdateTime = &now
+				r.recordEvent(addon, corev1.EventTypeNormal, eventReasonCatalogUpdated,
+					"CatalogSource %s/%s picked up catalog image %s",
+					catalogSource.Namespace, catalogSource.Name, digest)
+			}
+		}
+		catalogStatuses = append(catalogStatuses, catalogStatus)
+	}
+	addon.Status.Catalogs = catalogStatuses
+	return nil
+}
+
+// Returns the digest of the catalog image served by the given CatalogSource.
+// The digest is looked up from the ready registry Pod of the CatalogSource,
+// falling back to the digest of the catalog image reference.
+// Returns an empty string if the digest is not known.
+func (r *AddonReconciler) getCatalogDigest(
+	catalogSource *operatorsv1alpha1.CatalogSource) (string, error) {
+	pods, err := r.catalogPods.ListCatalogPods(catalogSource.Namespace, catalogSource.Name)
+	if err != nil {
+		return "", fmt.Errorf("listing catalog Pods: %w", err)
+	}
+
+	var (
+		newestPod *corev1.Pod
+		digest    string
+	)
+	for _, pod := range pods {
+		if newestPod != nil &&
+			!newestPod.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			continue
+		}
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			if !containerStatus.Ready {
+				continue
+			}
+			if podDigest := imageDigest(containerStatus.ImageID); len(podDigest) > 0 {
+				newestPod, digest = pod, podDigest
+				break
+			}
 		}
-		return ensureCatalogSourceResultRetry, nil, err
 	}
+	if len(digest) > 0 {
+		return digest, nil
+	}
+	return imageDigest(catalogSource.Spec.Image), nil
+}
+
+// Returns the digest of the given image reference, or an empty string if it has none.
+func imageDigest(imageRef string) string {
+	i := strings.LastIndex(imageRef, "@")
+	if i == -1 {
+		return ""
+	}
+	return imageRef[i+1:]
+}
 
-	return ensureCatalogSourceResultNil, observedCatalogSource, nil
+// Returns why the given CatalogSource is not ready, or an empty string if it is ready.
+func catalogSourceUnreadyMessage(catalogSource *operatorsv1alpha1.CatalogSource) string {
+	if catalogSource.Status.GRPCConnectionState == nil {
+		return ".Status.GRPCConnectionState is nil"
+	}
+	if catalogSource.Status.GRPCConnectionState.LastObservedState != "READY" {
+		return fmt.Sprintf(
+			".Status.GRPCConnectionState.LastObservedState == %s",
+			catalogSource.Status.GRPCConnectionState.LastObservedState,
+		)
+	}
+	return ""
 }
 
 // Marks Addon as unavailable because the CatalogSource is unready
@@ -100,24 +221,22 @@ func (r *AddonReconciler) reportCatalogSourceUnreadinessStatus(
 	addon *addonsv1alpha1.Addon,
 	catalogSource *operatorsv1alpha1.CatalogSource,
 	message string) error {
-	meta.SetStatusCondition(&addon.Status.Conditions, metav1.Condition{
-		Type:   addonsv1alpha1.Available,
-		Status: metav1.ConditionFalse,
-		Reason: addonsv1alpha1.AddonReasonUnreadyCatalogSource,
-		Message: fmt.Sprintf(
+	setPhaseCondition(addon, addonsv1alpha1.CatalogSourceReady, metav1.ConditionFalse,
+		addonsv1alpha1.AddonReasonUnreadyCatalogSource,
+		fmt.Sprintf(
 			"CatalogSource connection is not ready: %s",
-			message),
-		ObservedGeneration: addon.Generation,
-	})
-	addon.Status.ObservedGeneration = addon.Generation
-	addon.Status.Phase = addonsv1alpha1.PhasePending
-	return r.Status().Update(ctx, addon)
+			message))
+	r.recordEvent(addon, corev1.EventTypeWarning, eventReasonCatalogSourceUnready,
+		"CatalogSource %s/%s connection is not ready: %s",
+		catalogSource.Namespace, catalogSource.Name, message)
+	return r.reportPhaseStatus(ctx, addon)
 }
 
 // reconciles a CatalogSource and returns a new CatalogSource object with observed state.
-// Warning: Will adopt existing CatalogSource
-func reconcileCatalogSource(ctx context.Context, c client.Client, catalogSource *operatorsv1alpha1.CatalogSource) (
-	*operatorsv1alpha1.CatalogSource, error) {
+// Existing CatalogSources are only adopted as allowed by the adoption policy of the Addon.
+func reconcileCatalogSource(
+	ctx context.Context, c client.Client, addon *addonsv1alpha1.Addon,
+	catalogSource *operatorsv1alpha1.CatalogSource) (*operatorsv1alpha1.CatalogSource, error) {
 	currentCatalogSource := &operatorsv1alpha1.CatalogSource{}
 
 	{
@@ -133,12 +252,25 @@ func reconcileCatalogSource(ctx context.Context, c client.Client, catalogSource
 		}
 	}
 
+	if err := adoptObject(addon, operatorsv1alpha1.SchemeGroupVersion.WithKind(
+		operatorsv1alpha1.CatalogSourceKind), currentCatalogSource, catalogSource); err != nil {
+		return nil, err
+	}
+
+	// labels and annotations are merged, to keep entries added by others
+	changed := mergeStringMap(&currentCatalogSource.Labels, catalogSource.Labels)
+	if mergeStringMap(&currentCatalogSource.Annotations, catalogSource.Annotations) {
+		changed = true
+	}
 	// only update when spec or ownerReference has changed
 	if !equality.Semantic.DeepEqual(catalogSource.Spec, currentCatalogSource.Spec) ||
 		!equality.Semantic.DeepEqual(catalogSource.OwnerReferences, currentCatalogSource.OwnerReferences) {
 		// copy new spec into existing object and update in the k8s api
 		currentCatalogSource.Spec = catalogSource.Spec
 		currentCatalogSource.OwnerReferences = catalogSource.OwnerReferences
+		changed = true
+	}
+	if changed {
 		return currentCatalogSource, c.Update(ctx, currentCatalogSource)
 	}
 
@@ -3,13 +3,19 @@ package controllers
 import (
 	"context"
 	"testing"
+	"time"
 
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
 	"github.com/openshift/addon-operator/internal/testutil"
 )
 
@@ -28,7 +34,8 @@ func TestReconcileCatalogSource_NotExistingYet_HappyPath(t *testing.T) {
 
 	ctx := context.Background()
 	catalogSource := newTestCatalogSource()
-	reconciledCatalogSource, err := reconcileCatalogSource(ctx, c, catalogSource.DeepCopy())
+	reconciledCatalogSource, err := reconcileCatalogSource(
+		ctx, c, newTestAddonWithCatalogSourceImage(), catalogSource.DeepCopy())
 	assert.NoError(t, err)
 	assert.NotNil(t, reconciledCatalogSource)
 	c.AssertExpectations(t)
@@ -51,7 +58,7 @@ func TestReconcileCatalogSource_NotExistingYet_WithClientErrorGet(t *testing.T)
 	).Return(timeoutErr)
 
 	ctx := context.Background()
-	_, err := reconcileCatalogSource(ctx, c, newTestCatalogSource())
+	_, err := reconcileCatalogSource(ctx, c, newTestAddonWithCatalogSourceImage(), newTestCatalogSource())
 	assert.Error(t, err)
 	assert.EqualError(t, err, timeoutErr.Error())
 	c.AssertExpectations(t)
@@ -73,13 +80,17 @@ func TestReconcileCatalogSource_NotExistingYet_WithClientErrorCreate(t *testing.
 	).Return(timeoutErr)
 
 	ctx := context.Background()
-	_, err := reconcileCatalogSource(ctx, c, newTestCatalogSource())
+	_, err := reconcileCatalogSource(ctx, c, newTestAddonWithCatalogSourceImage(), newTestCatalogSource())
 	assert.Error(t, err)
 	assert.EqualError(t, err, timeoutErr.Error())
 	c.AssertExpectations(t)
 }
 
 func TestReconcileCatalogSource_Adoption(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	addon.Spec.Adoption = &addonsv1alpha1.AddonAdoption{
+		Policy: addonsv1alpha1.AdoptionPolicyAdoptAll,
+	}
 	catalogSource := newTestCatalogSource()
 
 	c := testutil.NewClient()
@@ -91,24 +102,85 @@ func TestReconcileCatalogSource_Adoption(t *testing.T) {
 		arg := args.Get(2).(*operatorsv1alpha1.CatalogSource)
 		newTestCatalogSourceWithoutOwner().DeepCopyInto(arg)
 	}).Return(nil)
-	// TODO: remove this Update call once resourceAdoptionStrategy is discontinued
 	// This update call changes the ownerRef to AddonOperator
 	c.On("Update",
 		testutil.IsContext,
 		testutil.IsOperatorsV1Alpha1CatalogSourcePtr,
 		mock.Anything,
 	).Return(nil)
-	c.StatusMock.On("Update",
-		testutil.IsContext,
-		testutil.IsAddonsv1alpha1AddonPtr,
-		mock.Anything,
-	).Return(nil)
 
 	ctx := context.Background()
-	reconciledCatalogSource, err := reconcileCatalogSource(ctx, c, catalogSource.DeepCopy())
+	reconciledCatalogSource, err := reconcileCatalogSource(ctx, c, addon, catalogSource.DeepCopy())
 	assert.NoError(t, err)
 	assert.NotNil(t, reconciledCatalogSource)
 	c.AssertExpectations(t)
+
+	assert.Equal(t, string(addonsv1alpha1.AdoptionPolicyAdoptAll),
+		reconciledCatalogSource.Annotations[adoptedAnnotation])
+	assert.Equal(t, []addonsv1alpha1.AddonObjectReference{
+		olmObjectReference(operatorsv1alpha1.CatalogSourceKind, catalogSource.Name, catalogSource.Namespace),
+	}, addon.Status.AdoptedObjects)
+}
+
+func TestReconcileCatalogSource_MergesLabels(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	catalogSource := newTestCatalogSourceWithoutOwner()
+	catalogSource.Labels = map[string]string{}
+	addCommonLabels(catalogSource.Labels, addon)
+	require.NoError(t, controllerutil.SetControllerReference(
+		addon, catalogSource, newTestSchemeWithAddonsv1alpha1()))
+
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		testutil.IsObjectKey,
+		testutil.IsOperatorsV1Alpha1CatalogSourcePtr,
+	).Run(func(args mock.Arguments) {
+		arg := args.Get(2).(*operatorsv1alpha1.CatalogSource)
+		catalogSource.DeepCopyInto(arg)
+		arg.Labels = map[string]string{"team": "sre"}
+	}).Return(nil)
+	var updatedCatalogSource *operatorsv1alpha1.CatalogSource
+	c.On("Update",
+		testutil.IsContext,
+		testutil.IsOperatorsV1Alpha1CatalogSourcePtr,
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		updatedCatalogSource = args.Get(1).(*operatorsv1alpha1.CatalogSource)
+	}).Return(nil)
+
+	ctx := context.Background()
+	_, err := reconcileCatalogSource(ctx, c, addon, catalogSource.DeepCopy())
+	require.NoError(t, err)
+	c.AssertExpectations(t)
+
+	if assert.NotNil(t, updatedCatalogSource) {
+		assert.True(t, hasCommonLabels(updatedCatalogSource.Labels, addon))
+		assert.Equal(t, "sre", updatedCatalogSource.Labels["team"])
+	}
+}
+
+func TestReconcileCatalogSource_AdoptionPrevented(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		testutil.IsObjectKey,
+		testutil.IsOperatorsV1Alpha1CatalogSourcePtr,
+	).Run(func(args mock.Arguments) {
+		arg := args.Get(2).(*operatorsv1alpha1.CatalogSource)
+		newTestCatalogSourceWithoutOwner().DeepCopyInto(arg)
+	}).Return(nil)
+
+	ctx := context.Background()
+	_, err := reconcileCatalogSource(ctx, c, addon, newTestCatalogSource())
+	var collision *adoptionCollisionError
+	if assert.ErrorAs(t, err, &collision) {
+		assert.Equal(t, addonsv1alpha1.AddonReasonAdoptionPrevented, collision.reason)
+	}
+	c.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	assert.Empty(t, addon.Status.AdoptedObjects)
 }
 
 func TestEnsureCatalogSource_Create(t *testing.T) {
@@ -132,9 +204,10 @@ func TestEnsureCatalogSource_Create(t *testing.T) {
 	}).Return(nil)
 
 	r := &AddonReconciler{
-		Client: c,
-		Log:    testutil.NewLogger(t),
-		Scheme: newTestSchemeWithAddonsv1alpha1(),
+		Client:      c,
+		Log:         testutil.NewLogger(t),
+		Scheme:      newTestSchemeWithAddonsv1alpha1(),
+		catalogPods: staticCatalogPods{},
 	}
 
 	log := testutil.NewLogger(t)
@@ -159,6 +232,8 @@ func TestEnsureCatalogSource_Update(t *testing.T) {
 		arg.Status.GRPCConnectionState = &operatorsv1alpha1.GRPCConnectionState{
 			LastObservedState: "READY",
 		}
+		require.NoError(t, controllerutil.SetControllerReference(
+			addon, arg, newTestSchemeWithAddonsv1alpha1()))
 	}).Return(nil)
 	c.On("Update",
 		testutil.IsContext,
@@ -167,9 +242,10 @@ func TestEnsureCatalogSource_Update(t *testing.T) {
 	).Return(nil)
 
 	r := &AddonReconciler{
-		Client: c,
-		Log:    testutil.NewLogger(t),
-		Scheme: newTestSchemeWithAddonsv1alpha1(),
+		Client:      c,
+		Log:         testutil.NewLogger(t),
+		Scheme:      newTestSchemeWithAddonsv1alpha1(),
+		catalogPods: staticCatalogPods{},
 	}
 
 	log := testutil.NewLogger(t)
@@ -182,3 +258,181 @@ func TestEnsureCatalogSource_Update(t *testing.T) {
 	c.AssertNumberOfCalls(t, "Get", 1)
 	c.AssertNumberOfCalls(t, "Update", 1)
 }
+
+func TestEnsureCatalogSource_AdditionalPackages(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	addon.Spec.Install.OLMOwnNamespace.AdditionalPackages = []addonsv1alpha1.AddonInstallOLMPackage{
+		{
+			PackageName:        "companion-operator",
+			Channel:            "alpha",
+			CatalogSourceImage: "quay.io/osd-addons/companion-index@sha256:123",
+		},
+	}
+
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		testutil.IsObjectKey,
+		testutil.IsOperatorsV1Alpha1CatalogSourcePtr,
+	).Return(newTestErrNotFound())
+	c.On("Create",
+		testutil.IsContext,
+		testutil.IsOperatorsV1Alpha1CatalogSourcePtr,
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		arg := args.Get(1).(*operatorsv1alpha1.CatalogSource)
+		arg.Status.GRPCConnectionState = &operatorsv1alpha1.GRPCConnectionState{
+			LastObservedState: "READY",
+		}
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client:      c,
+		Log:         testutil.NewLogger(t),
+		Scheme:      newTestSchemeWithAddonsv1alpha1(),
+		catalogPods: staticCatalogPods{},
+	}
+
+	log := testutil.NewLogger(t)
+
+	ctx := context.Background()
+	ensureResult, catalogSources, err := r.ensureCatalogSource(ctx, log, addon)
+	require.NoError(t, err)
+	assert.Equal(t, ensureCatalogSourceResultNil, ensureResult)
+	c.AssertNumberOfCalls(t, "Create", 2)
+	if assert.Len(t, catalogSources, 2) {
+		assert.Equal(t, "addon-1", catalogSources[0].Name)
+		assert.Equal(t, "addon-1-companion-operator", catalogSources[1].Name)
+		assert.Equal(t, "quay.io/osd-addons/companion-index@sha256:123", catalogSources[1].Spec.Image)
+	}
+}
+
+func TestEnsureCatalogSource_CatalogUpdatePolicy(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	addon.Spec.Install.OLMOwnNamespace.CatalogUpdatePolicy = &addonsv1alpha1.AddonCatalogUpdatePolicy{
+		Interval: metav1.Duration{Duration: 45 * time.Minute},
+	}
+
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		testutil.IsObjectKey,
+		testutil.IsOperatorsV1Alpha1CatalogSourcePtr,
+	).Return(newTestErrNotFound())
+	var createdCatalogSource *operatorsv1alpha1.CatalogSource
+	c.On("Create",
+		testutil.IsContext,
+		testutil.IsOperatorsV1Alpha1CatalogSourcePtr,
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		createdCatalogSource = args.Get(1).(*operatorsv1alpha1.CatalogSource)
+		createdCatalogSource.Status.GRPCConnectionState = &operatorsv1alpha1.GRPCConnectionState{
+			LastObservedState: "READY",
+		}
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client:      c,
+		Log:         testutil.NewLogger(t),
+		Scheme:      newTestSchemeWithAddonsv1alpha1(),
+		catalogPods: staticCatalogPods{},
+	}
+
+	ctx := context.Background()
+	ensureResult, _, err := r.ensureCatalogSource(ctx, testutil.NewLogger(t), addon)
+	require.NoError(t, err)
+	assert.Equal(t, ensureCatalogSourceResultNil, ensureResult)
+	if assert.NotNil(t, createdCatalogSource) &&
+		assert.NotNil(t, createdCatalogSource.Spec.UpdateStrategy) {
+		assert.Equal(t, 45*time.Minute,
+			createdCatalogSource.Spec.UpdateStrategy.RegistryPoll.Interval.Duration)
+	}
+}
+
+// Serves registry Pods by namespace and CatalogSource name.
+type staticCatalogPods map[string][]*corev1.Pod
+
+func (s staticCatalogPods) ListCatalogPods(
+	namespace, catalogSourceName string) ([]*corev1.Pod, error) {
+	return s[catalogPodIndexKey(namespace, catalogSourceName)], nil
+}
+
+func TestObserveCatalogUpdates(t *testing.T) {
+	lastPoll := metav1.Now()
+	newCatalogSource := func(name, image string) *operatorsv1alpha1.CatalogSource {
+		return &operatorsv1alpha1.CatalogSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "addon-1",
+			},
+			Spec: operatorsv1alpha1.CatalogSourceSpec{
+				Image: image,
+			},
+			Status: operatorsv1alpha1.CatalogSourceStatus{
+				LatestImageRegistryPoll: &lastPoll,
+			},
+		}
+	}
+	catalogSources := []*operatorsv1alpha1.CatalogSource{
+		newCatalogSource("addon-1", "quay.io/osd-addons/addon-1-index:latest"),
+		newCatalogSource("addon-1-companion", "quay.io/osd-addons/companion-index@sha256:companion"),
+	}
+
+	oldPod := &corev1.Pod{}
+	oldPod.CreationTimestamp = metav1.NewTime(lastPoll.Add(-time.Hour))
+	oldPod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Ready:   true,
+		ImageID: "quay.io/osd-addons/addon-1-index@sha256:old",
+	}}
+	newPod := &corev1.Pod{}
+	newPod.CreationTimestamp = lastPoll
+	newPod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Ready:   true,
+		ImageID: "docker-pullable://quay.io/osd-addons/addon-1-index@sha256:new",
+	}}
+
+	r := &AddonReconciler{
+		Client: testutil.NewClient(),
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+		catalogPods: staticCatalogPods{
+			"addon-1/addon-1": {newPod, oldPod},
+		},
+	}
+
+	addon := newTestAddonWithCatalogSourceImage()
+	addon.Status.Catalogs = []addonsv1alpha1.AddonCatalogStatus{
+		{CatalogSource: "addon-1", Digest: "sha256:old"},
+		{CatalogSource: "removed", Digest: "sha256:removed"},
+	}
+	ctx := context.Background()
+	require.NoError(t, r.observeCatalogUpdates(ctx, addon, catalogSources))
+	if assert.Len(t, addon.Status.Catalogs, 2) {
+		mainCatalog := addon.Status.Catalogs[0]
+		assert.Equal(t, "addon-1", mainCatalog.CatalogSource)
+		assert.Equal(t, "sha256:new", mainCatalog.Digest)
+		assert.Equal(t, &lastPoll, mainCatalog.LastPollTime)
+		assert.NotNil(t, mainCatalog.LastUpdateTime)
+
+		// falls back to the digest of the image reference, without registry Pods
+		companionCatalog := addon.Status.Catalogs[1]
+		assert.Equal(t, "addon-1-companion", companionCatalog.CatalogSource)
+		assert.Equal(t, "sha256:companion", companionCatalog.Digest)
+		assert.Nil(t, companionCatalog.LastUpdateTime)
+	}
+}
+
+func TestIndexCatalogPod(t *testing.T) {
+	pod := &corev1.Pod{}
+	pod.Namespace = "addon-1"
+	pod.Labels = map[string]string{catalogSourcePodLabel: "addon-1-companion"}
+
+	keys, err := indexCatalogPod(pod)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"addon-1/addon-1-companion"}, keys)
+}
+
+func TestImageDigest(t *testing.T) {
+	assert.Equal(t, "sha256:123", imageDigest("quay.io/osd-addons/addon-1-index@sha256:123"))
+	assert.Equal(t, "", imageDigest("quay.io/osd-addons/addon-1-index:latest"))
+}
@@ -0,0 +1,374 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+)
+
+// OLM v1 APIs, handled as unstructured objects,
+// so the Addon Operator does not depend on OLM v1 being installed.
+var (
+	clusterCatalogGVK = schema.GroupVersionKind{
+		Group: "olm.operatorframework.io", Version: "v1", Kind: "ClusterCatalog",
+	}
+	clusterExtensionGVK = schema.GroupVersionKind{
+		Group: "olm.operatorframework.io", Version: "v1", Kind: "ClusterExtension",
+	}
+)
+
+const (
+	// Label OLM v1 sets on every ClusterCatalog, used to select the catalog of the Addon.
+	clusterCatalogNameLabel = "olm.operatorframework.io/metadata.name"
+
+	clusterCatalogConditionServing     = "Serving"
+	clusterExtensionConditionInstalled = "Installed"
+	olmV1ConditionProgressing          = "Progressing"
+)
+
+type ensureClusterExtensionResult int
+
+const (
+	ensureClusterExtensionResultNil   ensureClusterExtensionResult = iota
+	ensureClusterExtensionResultStop  ensureClusterExtensionResult = iota
+	ensureClusterExtensionResultRetry ensureClusterExtensionResult = iota
+)
+
+// Ensures the ClusterCatalog of an OLMClusterExtension Addon exists and is serving.
+// Takes the place of the CatalogSource of other OLM install types.
+func (r *AddonReconciler) ensureClusterCatalog(
+	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon,
+) (ensureClusterExtensionResult, error) {
+	_, catalogSourceImage, stop, err := r.parseAddonInstallConfig(ctx, log, addon)
+	if err != nil {
+		return ensureClusterExtensionResultNil, err
+	}
+	if stop {
+		return ensureClusterExtensionResultStop, nil
+	}
+
+	clusterCatalog := newClusterCatalog(addon, catalogSourceImage)
+	if err := r.prepareClusterExtensionObject(addon, clusterCatalog); err != nil {
+		return ensureClusterExtensionResultNil, err
+	}
+
+	if result, err := r.applyClusterExtensionObject(ctx, addon, clusterCatalog,
+		addonsv1alpha1.ClusterCatalogReady, addonsv1alpha1.AddonReasonUnreadyClusterCatalog,
+	); err != nil || result != ensureClusterExtensionResultNil {
+		return result, err
+	}
+
+	message, err := clusterCatalogUnreadyMessage(clusterCatalog)
+	if err != nil {
+		return ensureClusterExtensionResultNil, err
+	}
+	if message != "" {
+		setPhaseCondition(addon, addonsv1alpha1.ClusterCatalogReady, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonUnreadyClusterCatalog,
+			fmt.Sprintf("ClusterCatalog is not serving: %s", message))
+		r.recordEvent(addon, corev1.EventTypeWarning, eventReasonClusterCatalogUnready,
+			"ClusterCatalog %s is not serving: %s", clusterCatalog.GetName(), message)
+		return ensureClusterExtensionResultRetry, r.reportPhaseStatus(ctx, addon)
+	}
+
+	setPhaseCondition(addon, addonsv1alpha1.ClusterCatalogReady, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+	r.recordEvent(addon, corev1.EventTypeNormal, eventReasonClusterCatalogReady,
+		"ClusterCatalog %s is serving", clusterCatalog.GetName())
+	return ensureClusterExtensionResultNil, nil
+}
+
+// Ensures the ClusterExtension of an OLMClusterExtension Addon exists and has installed a bundle.
+// Takes the place of the Subscription of other OLM install types.
+func (r *AddonReconciler) ensureClusterExtension(
+	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon,
+) (ensureClusterExtensionResult, error) {
+	targetNamespace, _, stop, err := r.parseAddonInstallConfig(ctx, log, addon)
+	if err != nil {
+		return ensureClusterExtensionResultNil, err
+	}
+	if stop {
+		return ensureClusterExtensionResultStop, nil
+	}
+
+	clusterExtension := newClusterExtension(addon, targetNamespace)
+	if err := r.prepareClusterExtensionObject(addon, clusterExtension); err != nil {
+		return ensureClusterExtensionResultNil, err
+	}
+
+	if result, err := r.applyClusterExtensionObject(ctx, addon, clusterExtension,
+		addonsv1alpha1.ClusterExtensionInstalled, addonsv1alpha1.AddonReasonUnreadyClusterExtension,
+	); err != nil || result != ensureClusterExtensionResultNil {
+		return result, err
+	}
+
+	bundle, message, err := clusterExtensionStatus(clusterExtension)
+	if err != nil {
+		return ensureClusterExtensionResultNil, err
+	}
+	if message != "" {
+		setPhaseCondition(addon, addonsv1alpha1.ClusterExtensionInstalled, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonUnreadyClusterExtension,
+			fmt.Sprintf("ClusterExtension is not installed: %s", message))
+		return ensureClusterExtensionResultRetry, r.reportPhaseStatus(ctx, addon)
+	}
+
+	if bundle != nil {
+		r.recordEvent(addon, corev1.EventTypeNormal, eventReasonClusterExtensionInstalled,
+			"ClusterExtension %s installed bundle %s", clusterExtension.GetName(), bundle.Name)
+	}
+	addon.Status.InstalledBundle = bundle
+	setPhaseCondition(addon, addonsv1alpha1.ClusterExtensionInstalled, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+	return ensureClusterExtensionResultNil, nil
+}
+
+// Checks the health of the ClusterCatalog and ClusterExtension of the Addon,
+// without changing anything on the cluster.
+func (r *AddonReconciler) observeClusterExtension(
+	ctx context.Context, addon *addonsv1alpha1.Addon) error {
+	clusterCatalog := &unstructured.Unstructured{}
+	clusterCatalog.SetGroupVersionKind(clusterCatalogGVK)
+	err := r.Get(ctx, client.ObjectKey{Name: addon.Name}, clusterCatalog)
+	switch {
+	case k8sApiErrors.IsNotFound(err) || meta.IsNoMatchError(err):
+		setPhaseCondition(addon, addonsv1alpha1.ClusterCatalogReady, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonUnreadyClusterCatalog, "ClusterCatalog not found")
+	case err != nil:
+		return err
+	default:
+		message, err := clusterCatalogUnreadyMessage(clusterCatalog)
+		if err != nil {
+			return err
+		}
+		if message != "" {
+			setPhaseCondition(addon, addonsv1alpha1.ClusterCatalogReady, metav1.ConditionFalse,
+				addonsv1alpha1.AddonReasonUnreadyClusterCatalog,
+				fmt.Sprintf("ClusterCatalog is not serving: %s", message))
+		} else {
+			setPhaseCondition(addon, addonsv1alpha1.ClusterCatalogReady, metav1.ConditionTrue,
+				addonsv1alpha1.AddonReasonReady, "")
+		}
+	}
+
+	clusterExtension := &unstructured.Unstructured{}
+	clusterExtension.SetGroupVersionKind(clusterExtensionGVK)
+	err = r.Get(ctx, client.ObjectKey{Name: addon.Name}, clusterExtension)
+	if k8sApiErrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		setPhaseCondition(addon, addonsv1alpha1.ClusterExtensionInstalled, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonUnreadyClusterExtension, "ClusterExtension not found")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	bundle, message, err := clusterExtensionStatus(clusterExtension)
+	if err != nil {
+		return err
+	}
+	if message != "" {
+		setPhaseCondition(addon, addonsv1alpha1.ClusterExtensionInstalled, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonUnreadyClusterExtension,
+			fmt.Sprintf("ClusterExtension is not installed: %s", message))
+		return nil
+	}
+	addon.Status.InstalledBundle = bundle
+	setPhaseCondition(addon, addonsv1alpha1.ClusterExtensionInstalled, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+	return nil
+}
+
+// ClusterCatalogs are cluster-scoped and named after their Addon.
+func newClusterCatalog(addon *addonsv1alpha1.Addon, catalogSourceImage string) *unstructured.Unstructured {
+	clusterCatalog := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"source": map[string]interface{}{
+				"type": "Image",
+				"image": map[string]interface{}{
+					"ref": catalogSourceImage,
+				},
+			},
+		},
+	}}
+	clusterCatalog.SetGroupVersionKind(clusterCatalogGVK)
+	clusterCatalog.SetName(addon.Name)
+	return clusterCatalog
+}
+
+// ClusterExtensions are cluster-scoped, named after their Addon
+// and only install bundles from the ClusterCatalog of the Addon.
+func newClusterExtension(addon *addonsv1alpha1.Addon, targetNamespace string) *unstructured.Unstructured {
+	clusterExtensionSpec := addon.Spec.Install.OLMClusterExtension
+	catalog := map[string]interface{}{
+		"packageName": clusterExtensionSpec.PackageName,
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{
+				clusterCatalogNameLabel: addon.Name,
+			},
+		},
+	}
+	if len(clusterExtensionSpec.Channel) > 0 {
+		catalog["channels"] = []interface{}{clusterExtensionSpec.Channel}
+	}
+	if len(clusterExtensionSpec.Version) > 0 {
+		catalog["version"] = clusterExtensionSpec.Version
+	}
+
+	clusterExtension := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"namespace": targetNamespace,
+			"serviceAccount": map[string]interface{}{
+				"name": clusterExtensionSpec.ServiceAccountName,
+			},
+			"source": map[string]interface{}{
+				"sourceType": "Catalog",
+				"catalog":    catalog,
+			},
+		},
+	}}
+	clusterExtension.SetGroupVersionKind(clusterExtensionGVK)
+	clusterExtension.SetName(addon.Name)
+	return clusterExtension
+}
+
+// Adds common labels and the controller reference.
+func (r *AddonReconciler) prepareClusterExtensionObject(
+	addon *addonsv1alpha1.Addon, obj *unstructured.Unstructured) error {
+	labels := map[string]string{}
+	addCommonLabels(labels, addon)
+	obj.SetLabels(labels)
+	return controllerutil.SetControllerReference(addon, obj, r.Scheme)
+}
+
+// Applies the given ClusterCatalog or ClusterExtension.
+// Reports the given phase condition and returns a non-nil result,
+// if OLM v1 is not installed or the object collides with an existing one.
+func (r *AddonReconciler) applyClusterExtensionObject(
+	ctx context.Context, addon *addonsv1alpha1.Addon, obj *unstructured.Unstructured,
+	conditionType, unreadyReason string,
+) (ensureClusterExtensionResult, error) {
+	err := r.applyManifestObject(ctx, addon, obj)
+	var collision *adoptionCollisionError
+	if errors.As(err, &collision) {
+		return ensureClusterExtensionResultRetry,
+			r.reportAdoptionCollision(ctx, addon, conditionType, collision)
+	}
+	if isNoMatchError(err) {
+		setPhaseCondition(addon, conditionType, metav1.ConditionFalse, unreadyReason,
+			fmt.Sprintf("%s API is not available, is OLM v1 installed?", obj.GetKind()))
+		return ensureClusterExtensionResultRetry, r.reportPhaseStatus(ctx, addon)
+	}
+	if err != nil {
+		return ensureClusterExtensionResultNil, fmt.Errorf("applying %s: %w", manifestObjectName(obj), err)
+	}
+	return ensureClusterExtensionResultNil, nil
+}
+
+// Returns why the given ClusterCatalog is not serving, or an empty string if it is serving.
+func clusterCatalogUnreadyMessage(clusterCatalog *unstructured.Unstructured) (string, error) {
+	conditions, err := unstructuredConditions(clusterCatalog)
+	if err != nil {
+		return "", err
+	}
+
+	serving := meta.FindStatusCondition(conditions, clusterCatalogConditionServing)
+	if serving != nil && serving.Status == metav1.ConditionTrue &&
+		serving.ObservedGeneration >= clusterCatalog.GetGeneration() {
+		return "", nil
+	}
+	if message := conditionsMessage(conditions,
+		olmV1ConditionProgressing, clusterCatalogConditionServing); message != "" {
+		return message, nil
+	}
+	return "waiting for catalog to be unpacked", nil
+}
+
+// Returns the bundle installed by the given ClusterExtension,
+// or why it has not installed a bundle of the requested generation yet.
+func clusterExtensionStatus(
+	clusterExtension *unstructured.Unstructured,
+) (*addonsv1alpha1.AddonInstalledBundle, string, error) {
+	conditions, err := unstructuredConditions(clusterExtension)
+	if err != nil {
+		return nil, "", err
+	}
+
+	installed := meta.FindStatusCondition(conditions, clusterExtensionConditionInstalled)
+	if installed == nil || installed.Status != metav1.ConditionTrue ||
+		installed.ObservedGeneration < clusterExtension.GetGeneration() {
+		if message := conditionsMessage(conditions,
+			olmV1ConditionProgressing, clusterExtensionConditionInstalled); message != "" {
+			return nil, message, nil
+		}
+		return nil, "waiting for bundle to be installed", nil
+	}
+
+	name, _, err := unstructured.NestedString(clusterExtension.Object, "status", "install", "bundle", "name")
+	if err != nil {
+		return nil, "", err
+	}
+	if len(name) == 0 {
+		return nil, "", nil
+	}
+	version, _, err := unstructured.NestedString(clusterExtension.Object, "status", "install", "bundle", "version")
+	if err != nil {
+		return nil, "", err
+	}
+	return &addonsv1alpha1.AddonInstalledBundle{Name: name, Version: version}, "", nil
+}
+
+// Returns the message of the first condition of the given types that has a message.
+func conditionsMessage(conditions []metav1.Condition, conditionTypes ...string) string {
+	for _, conditionType := range conditionTypes {
+		cond := meta.FindStatusCondition(conditions, conditionType)
+		if cond != nil && len(cond.Message) > 0 {
+			return fmt.Sprintf("%s: %s", cond.Reason, cond.Message)
+		}
+	}
+	return ""
+}
+
+func unstructuredConditions(obj *unstructured.Unstructured) ([]metav1.Condition, error) {
+	rawConditions, _, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil {
+		return nil, fmt.Errorf("reading conditions of %s: %w", manifestObjectName(obj), err)
+	}
+
+	conditions := make([]metav1.Condition, 0, len(rawConditions))
+	for _, rawCondition := range rawConditions {
+		rawConditionMap, ok := rawCondition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var cond metav1.Condition
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawConditionMap, &cond); err != nil {
+			return nil, fmt.Errorf("converting conditions of %s: %w", manifestObjectName(obj), err)
+		}
+		conditions = append(conditions, cond)
+	}
+	return conditions, nil
+}
+
+// Like meta.IsNoMatchError, but also detects wrapped errors.
+func isNoMatchError(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if meta.IsNoMatchError(err) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/testutil"
+)
+
+func newTestClusterExtensionAddon() *addonsv1alpha1.Addon {
+	return &addonsv1alpha1.Addon{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "addon-1",
+			UID:  "addon-uid",
+		},
+		Spec: addonsv1alpha1.AddonSpec{
+			Install: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.OLMClusterExtension,
+				OLMClusterExtension: &addonsv1alpha1.AddonInstallOLMClusterExtension{
+					Namespace:          "addon-1",
+					CatalogSourceImage: "quay.io/osd-addons/addon-1-index@sha256:123",
+					Channel:            "stable",
+					PackageName:        "addon-1",
+					ServiceAccountName: "addon-1-installer",
+				},
+			},
+		},
+	}
+}
+
+func newTestUnstructuredCondition(conditionType string, status metav1.ConditionStatus, reason, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":               conditionType,
+		"status":             string(status),
+		"reason":             reason,
+		"message":            message,
+		"lastTransitionTime": "2021-01-01T00:00:00Z",
+	}
+}
+
+func TestEnsureClusterExtension(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "addon-1"},
+		mock.IsType(&unstructured.Unstructured{}),
+	).Return(newTestErrNotFound())
+
+	var applied []*unstructured.Unstructured
+	c.On("Patch",
+		testutil.IsContext,
+		mock.IsType(&unstructured.Unstructured{}),
+		mock.Anything,
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		obj := args.Get(1).(*unstructured.Unstructured)
+		applied = append(applied, obj.DeepCopy())
+
+		// simulate OLM v1 reporting success
+		switch obj.GroupVersionKind() {
+		case clusterCatalogGVK:
+			obj.Object["status"] = map[string]interface{}{
+				"conditions": []interface{}{
+					newTestUnstructuredCondition("Serving", metav1.ConditionTrue, "Available", ""),
+				},
+			}
+		case clusterExtensionGVK:
+			obj.Object["status"] = map[string]interface{}{
+				"conditions": []interface{}{
+					newTestUnstructuredCondition("Installed", metav1.ConditionTrue, "Succeeded", ""),
+				},
+				"install": map[string]interface{}{
+					"bundle": map[string]interface{}{
+						"name":    "addon-1.v1.0.0",
+						"version": "1.0.0",
+					},
+				},
+			}
+		}
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+	addon := newTestClusterExtensionAddon()
+	ctx := context.Background()
+
+	result, err := r.ensureClusterCatalog(ctx, r.Log, addon)
+	require.NoError(t, err)
+	assert.Equal(t, ensureClusterExtensionResultNil, result)
+	assert.True(t, meta.IsStatusConditionTrue(addon.Status.Conditions, addonsv1alpha1.ClusterCatalogReady))
+
+	result, err = r.ensureClusterExtension(ctx, r.Log, addon)
+	require.NoError(t, err)
+	assert.Equal(t, ensureClusterExtensionResultNil, result)
+	assert.True(t, meta.IsStatusConditionTrue(addon.Status.Conditions, addonsv1alpha1.ClusterExtensionInstalled))
+	assert.Equal(t, &addonsv1alpha1.AddonInstalledBundle{
+		Name: "addon-1.v1.0.0", Version: "1.0.0",
+	}, addon.Status.InstalledBundle)
+
+	require.Len(t, applied, 2)
+	imageRef, _, _ := unstructured.NestedString(applied[0].Object, "spec", "source", "image", "ref")
+	assert.Equal(t, addon.Spec.Install.OLMClusterExtension.CatalogSourceImage, imageRef)
+	assert.True(t, metav1.IsControlledBy(applied[0], addon))
+
+	catalog, _, _ := unstructured.NestedMap(applied[1].Object, "spec", "source", "catalog")
+	assert.Equal(t, "addon-1", catalog["packageName"])
+	assert.Equal(t, []interface{}{"stable"}, catalog["channels"])
+	assert.Equal(t, map[string]interface{}{
+		"matchLabels": map[string]interface{}{clusterCatalogNameLabel: "addon-1"},
+	}, catalog["selector"])
+	serviceAccountName, _, _ := unstructured.NestedString(applied[1].Object, "spec", "serviceAccount", "name")
+	assert.Equal(t, "addon-1-installer", serviceAccountName)
+	c.AssertExpectations(t)
+}
+
+func TestEnsureClusterCatalog_NotServing(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "addon-1"},
+		mock.IsType(&unstructured.Unstructured{}),
+	).Return(newTestErrNotFound())
+	c.On("Patch",
+		testutil.IsContext,
+		mock.IsType(&unstructured.Unstructured{}),
+		mock.Anything,
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		obj := args.Get(1).(*unstructured.Unstructured)
+		obj.Object["status"] = map[string]interface{}{
+			"conditions": []interface{}{
+				newTestUnstructuredCondition("Progressing", metav1.ConditionTrue, "Retrying", "pulling image failed"),
+				newTestUnstructuredCondition("Serving", metav1.ConditionFalse, "Unavailable", ""),
+			},
+		}
+	}).Return(nil)
+	c.StatusMock.On("Update", testutil.IsContext, mock.IsType(&addonsv1alpha1.Addon{}), mock.Anything).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+	addon := newTestClusterExtensionAddon()
+
+	result, err := r.ensureClusterCatalog(context.Background(), r.Log, addon)
+	require.NoError(t, err)
+	assert.Equal(t, ensureClusterExtensionResultRetry, result)
+
+	cond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.ClusterCatalogReady)
+	if assert.NotNil(t, cond) {
+		assert.Equal(t, metav1.ConditionFalse, cond.Status)
+		assert.Equal(t, addonsv1alpha1.AddonReasonUnreadyClusterCatalog, cond.Reason)
+		assert.Contains(t, cond.Message, "pulling image failed")
+	}
+	c.AssertExpectations(t)
+}
+
+func TestClusterExtensionStatus(t *testing.T) {
+	clusterExtension := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	clusterExtension.SetGeneration(2)
+
+	// no status yet
+	bundle, message, err := clusterExtensionStatus(clusterExtension)
+	require.NoError(t, err)
+	assert.Nil(t, bundle)
+	assert.Equal(t, "waiting for bundle to be installed", message)
+
+	// installed, but for an older generation
+	installed := newTestUnstructuredCondition("Installed", metav1.ConditionTrue, "Succeeded", "")
+	installed["observedGeneration"] = int64(1)
+	clusterExtension.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{installed},
+		"install": map[string]interface{}{
+			"bundle": map[string]interface{}{"name": "addon-1.v1.0.0", "version": "1.0.0"},
+		},
+	}
+	bundle, message, err = clusterExtensionStatus(clusterExtension)
+	require.NoError(t, err)
+	assert.Nil(t, bundle)
+	assert.NotEmpty(t, message)
+
+	installed["observedGeneration"] = int64(2)
+	bundle, message, err = clusterExtensionStatus(clusterExtension)
+	require.NoError(t, err)
+	assert.Empty(t, message)
+	assert.Equal(t, &addonsv1alpha1.AddonInstalledBundle{
+		Name: "addon-1.v1.0.0", Version: "1.0.0",
+	}, bundle)
+}
+
+func TestAddonPhaseConditionTypes_ClusterExtension(t *testing.T) {
+	assert.Equal(t, []string{
+		addonsv1alpha1.NamespacesReady,
+		addonsv1alpha1.ParametersReady,
+		addonsv1alpha1.PullSecretsReady,
+		addonsv1alpha1.DependenciesReady,
+		addonsv1alpha1.ClusterCatalogReady,
+		addonsv1alpha1.ClusterExtensionInstalled,
+	}, (&AddonReconciler{}).addonPhaseConditionTypes(newTestClusterExtensionAddon()))
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+)
+
+// Ensures that all dependencies of the given Addon are Available
+// and reports them on the DependenciesReady condition.
+// Stops reconciliation until they are, if the installation of the Addon has not started yet.
+// Installed Addons keep being reconciled while a dependency is unavailable.
+// Status changes of dependencies requeue the Addon, see enqueueDependentAddons.
+func (r *AddonReconciler) ensureDependencies(
+	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon) (stop bool, err error) {
+	var unreadyDependencies []string
+	for _, dependency := range addon.Spec.Dependencies {
+		dependencyAddon := &addonsv1alpha1.Addon{}
+		err := r.Get(ctx, client.ObjectKey{Name: dependency.Name}, dependencyAddon)
+		if apierrors.IsNotFound(err) {
+			unreadyDependencies = append(unreadyDependencies, dependency.Name+" (not found)")
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("getting dependency Addon %q: %w", dependency.Name, err)
+		}
+
+		if !meta.IsStatusConditionTrue(
+			dependencyAddon.Status.Conditions, addonsv1alpha1.Available) {
+			unreadyDependencies = append(unreadyDependencies, dependency.Name)
+		}
+	}
+
+	if len(unreadyDependencies) == 0 {
+		setPhaseCondition(addon, addonsv1alpha1.DependenciesReady, metav1.ConditionTrue,
+			addonsv1alpha1.AddonReasonReady, "")
+		return false, nil
+	}
+
+	setPhaseCondition(addon, addonsv1alpha1.DependenciesReady, metav1.ConditionFalse,
+		addonsv1alpha1.AddonReasonDependenciesNotReady,
+		fmt.Sprintf("Waiting for dependencies to become Available: %s",
+			strings.Join(unreadyDependencies, ", ")))
+	if r.installStarted(addon) {
+		log.Info("dependencies unavailable, continuing with installed Addon", "addons", unreadyDependencies)
+		return false, nil
+	}
+
+	log.Info("waiting for dependencies", "addons", unreadyDependencies)
+	return true, r.reportPhaseStatus(ctx, addon)
+}
+
+// Returns true if the installation of the given Addon has started,
+// which is the case once its Installer reported any of its phase conditions.
+func (r *AddonReconciler) installStarted(addon *addonsv1alpha1.Addon) bool {
+	installer := r.installerFor(addon)
+	if installer == nil {
+		return false
+	}
+	for _, conditionType := range installer.Status(addon).ConditionTypes {
+		if meta.FindStatusCondition(addon.Status.Conditions, conditionType) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Maps an Addon to all Addons depending on it.
+func (r *AddonReconciler) enqueueDependentAddons(obj client.Object) []reconcile.Request {
+	addonList := &addonsv1alpha1.AddonList{}
+	if err := r.List(context.Background(), addonList); err != nil {
+		r.Log.Error(err, "listing Addons to enqueue dependents", "addon", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, addon := range addonList.Items {
+		for _, dependency := range addon.Spec.Dependencies {
+			if dependency.Name != obj.GetName() {
+				continue
+			}
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: addon.Name},
+			})
+			break
+		}
+	}
+	return requests
+}
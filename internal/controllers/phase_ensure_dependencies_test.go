@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/testutil"
+)
+
+func TestEnsureDependencies(t *testing.T) {
+	addon := &addonsv1alpha1.Addon{
+		Spec: addonsv1alpha1.AddonSpec{
+			Dependencies: []addonsv1alpha1.AddonDependency{
+				{Name: "logging"},
+				{Name: "monitoring"},
+			},
+		},
+	}
+	// phases before the dependencies
+	setPhaseCondition(addon, addonsv1alpha1.NamespacesReady, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+	setPhaseCondition(addon, addonsv1alpha1.ParametersReady, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+	setPhaseCondition(addon, addonsv1alpha1.PullSecretsReady, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+
+	c := testutil.NewClient()
+	c.On("Get",
+		mock.Anything,
+		client.ObjectKey{Name: "logging"},
+		testutil.IsAddonsv1alpha1AddonPtr,
+	).Run(func(args mock.Arguments) {
+		dependency := args.Get(2).(*addonsv1alpha1.Addon)
+		meta.SetStatusCondition(&dependency.Status.Conditions, metav1.Condition{
+			Type:   addonsv1alpha1.Available,
+			Status: metav1.ConditionTrue,
+		})
+	}).Return(nil)
+	c.On("Get",
+		mock.Anything,
+		client.ObjectKey{Name: "monitoring"},
+		testutil.IsAddonsv1alpha1AddonPtr,
+	).Return(newTestErrNotFound())
+	c.StatusMock.On("Update",
+		mock.Anything,
+		testutil.IsAddonsv1alpha1AddonPtr,
+		mock.Anything,
+	).Return(nil)
+
+	r := &AddonReconciler{Client: c}
+	stop, err := r.ensureDependencies(context.Background(), testutil.NewLogger(t), addon)
+	require.NoError(t, err)
+	assert.True(t, stop)
+	c.AssertExpectations(t)
+
+	dependenciesCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.DependenciesReady)
+	if assert.NotNil(t, dependenciesCond) {
+		assert.Equal(t, metav1.ConditionFalse, dependenciesCond.Status)
+		assert.Equal(t, addonsv1alpha1.AddonReasonDependenciesNotReady, dependenciesCond.Reason)
+		assert.Contains(t, dependenciesCond.Message, "monitoring (not found)")
+		assert.NotContains(t, dependenciesCond.Message, "logging")
+	}
+	availableCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.Available)
+	if assert.NotNil(t, availableCond) {
+		assert.Equal(t, metav1.ConditionFalse, availableCond.Status)
+		assert.Equal(t, addonsv1alpha1.AddonReasonDependenciesNotReady, availableCond.Reason)
+	}
+	assert.Equal(t, addonsv1alpha1.PhasePending, addon.Status.Phase)
+}
+
+func TestEnsureDependencies_Installed(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	addon.Spec.Dependencies = []addonsv1alpha1.AddonDependency{{Name: "logging"}}
+	// installed before the dependency became unavailable
+	setPhaseCondition(addon, addonsv1alpha1.OperatorGroupReady, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+
+	c := testutil.NewClient()
+	c.On("Get",
+		mock.Anything,
+		client.ObjectKey{Name: "logging"},
+		testutil.IsAddonsv1alpha1AddonPtr,
+	).Return(nil)
+
+	r := &AddonReconciler{Client: c}
+	stop, err := r.ensureDependencies(context.Background(), testutil.NewLogger(t), addon)
+	require.NoError(t, err)
+	assert.False(t, stop)
+	// status is reported at the end of the reconcile
+	c.StatusMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+
+	assert.True(t, meta.IsStatusConditionFalse(addon.Status.Conditions, addonsv1alpha1.DependenciesReady))
+}
+
+func TestEnsureDependencies_NoDependencies(t *testing.T) {
+	c := testutil.NewClient()
+	r := &AddonReconciler{Client: c}
+
+	stop, err := r.ensureDependencies(
+		context.Background(), testutil.NewLogger(t), &addonsv1alpha1.Addon{})
+	require.NoError(t, err)
+	assert.False(t, stop)
+	c.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEnqueueDependentAddons(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("List",
+		mock.Anything,
+		mock.IsType(&addonsv1alpha1.AddonList{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		list := args.Get(1).(*addonsv1alpha1.AddonList)
+		list.Items = []addonsv1alpha1.Addon{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "monitoring"},
+				Spec: addonsv1alpha1.AddonSpec{
+					Dependencies: []addonsv1alpha1.AddonDependency{{Name: "logging"}},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "standalone"},
+			},
+		}
+	}).Return(nil)
+
+	r := &AddonReconciler{Client: c, Log: testutil.NewLogger(t)}
+	requests := r.enqueueDependentAddons(&addonsv1alpha1.Addon{
+		ObjectMeta: metav1.ObjectMeta{Name: "logging"},
+	})
+	assert.Equal(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: "monitoring"}},
+	}, requests)
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/chart"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/helm"
+)
+
+// Number of hex characters of the chart configuration hash
+// that are kept to detect chart reference and value changes.
+const helmConfigHashPrefixLength = 16
+
+// helmChartLoader loads charts from Helm chart repositories.
+type helmChartLoader interface {
+	Load(ctx context.Context, repositoryURL, name, version string) (*chart.Chart, error)
+}
+
+// Loads and renders the chart of a Helm Addon.
+// The release is named after the Addon and its revision is incremented,
+// whenever the chart version or values change.
+// Reports the ManifestsReady condition and returns a non-nil result, if the chart can't be rendered.
+func (r *AddonReconciler) renderHelmChart(
+	ctx context.Context, addon *addonsv1alpha1.Addon, targetNamespace string,
+) ([]unstructured.Unstructured, *addonsv1alpha1.AddonHelmReleaseStatus, ensureManifestsResult, error) {
+	helmSpec := addon.Spec.Install.Helm
+
+	values, err := helmValues(addon)
+	if err != nil {
+		return nil, nil, ensureManifestsResultStop, r.reportConfigurationError(ctx, addon,
+			fmt.Sprintf("composing chart values: %v", err))
+	}
+	release, err := nextHelmRelease(addon, values)
+	if err != nil {
+		return nil, nil, ensureManifestsResultNil, err
+	}
+
+	if r.ChartLoader == nil {
+		return nil, nil, ensureManifestsResultNil, errors.New("loading charts is not configured")
+	}
+	chrt, err := r.ChartLoader.Load(ctx,
+		helmSpec.Chart.RepositoryURL, helmSpec.Chart.Name, helmSpec.Chart.Version)
+	if err != nil {
+		setPhaseCondition(addon, addonsv1alpha1.ManifestsReady, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonUnreadyManifests,
+			fmt.Sprintf("loading chart: %v", err))
+		return nil, nil, ensureManifestsResultRetry, r.reportPhaseStatus(ctx, addon)
+	}
+
+	objects, err := helm.Render(chrt, values, helm.Release{
+		Name:      addon.Name,
+		Namespace: targetNamespace,
+		Revision:  release.Revision,
+	})
+	if err != nil {
+		return nil, nil, ensureManifestsResultStop, r.reportConfigurationError(ctx, addon,
+			fmt.Sprintf("rendering chart: %v", err))
+	}
+	return objects, release, ensureManifestsResultNil, nil
+}
+
+// Records the given release as applied in the Addon status.
+func (r *AddonReconciler) recordHelmRelease(
+	addon *addonsv1alpha1.Addon, release *addonsv1alpha1.AddonHelmReleaseStatus) {
+	current := addon.Status.HelmRelease
+	switch {
+	case current == nil:
+		r.recordEvent(addon, corev1.EventTypeNormal, eventReasonHelmReleaseInstalled,
+			"Installed chart version %s as revision %d", release.ChartVersion, release.Revision)
+	case current.Revision != release.Revision:
+		r.recordEvent(addon, corev1.EventTypeNormal, eventReasonHelmReleaseUpgraded,
+			"Upgraded to chart version %s as revision %d", release.ChartVersion, release.Revision)
+	}
+	addon.Status.HelmRelease = release
+}
+
+// Returns the release to render the chart of the Addon with.
+// Keeps the revision of the current release if the chart version and values are unchanged.
+func nextHelmRelease(
+	addon *addonsv1alpha1.Addon, values map[string]interface{},
+) (*addonsv1alpha1.AddonHelmReleaseStatus, error) {
+	chartVersion := addon.Spec.Install.Helm.Chart.Version
+	configHash, err := helmConfigHash(addon.Spec.Install.Helm.Chart, values)
+	if err != nil {
+		return nil, err
+	}
+
+	release := &addonsv1alpha1.AddonHelmReleaseStatus{
+		Revision:     1,
+		ChartVersion: chartVersion,
+		ConfigHash:   configHash,
+	}
+	if current := addon.Status.HelmRelease; current != nil {
+		release.Revision = current.Revision
+		if current.ConfigHash != configHash {
+			release.Revision++
+		}
+	}
+	return release, nil
+}
+
+// Composes the values to render the chart of the Addon with.
+// Addon parameters take precedence over .spec.install.helm.values, if enabled.
+func helmValues(addon *addonsv1alpha1.Addon) (map[string]interface{}, error) {
+	helmSpec := addon.Spec.Install.Helm
+	values := map[string]interface{}{}
+	if helmSpec.Values != nil && len(helmSpec.Values.Raw) > 0 {
+		if err := json.Unmarshal(helmSpec.Values.Raw, &values); err != nil {
+			return nil, fmt.Errorf(".spec.install.helm.values must be an object: %w", err)
+		}
+		if values == nil {
+			values = map[string]interface{}{}
+		}
+	}
+
+	if !helmSpec.ValuesFromParameters {
+		return values, nil
+	}
+	for name, raw := range addon.Spec.Parameters {
+		var value interface{}
+		if err := json.Unmarshal(raw.Raw, &value); err != nil {
+			return nil, fmt.Errorf("parameter %q is not valid JSON", name)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// Computes a stable hash of the chart reference and values.
+func helmConfigHash(
+	chartRef addonsv1alpha1.AddonHelmChart, values map[string]interface{}) (string, error) {
+	// json.Marshal sorts map keys, so equal values always encode the same way.
+	valuesJSON, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("encoding chart values: %w", err)
+	}
+
+	h := sha256.New()
+	fields := []string{chartRef.RepositoryURL, chartRef.Name, chartRef.Version, string(valuesJSON)}
+	for _, field := range fields {
+		// write length prefixes so that field boundaries are unambiguous
+		fmt.Fprintf(h, "%d:%s", len(field), field)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:helmConfigHashPrefixLength], nil
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/testutil"
+)
+
+type chartLoaderMock struct {
+	mock.Mock
+}
+
+func (m *chartLoaderMock) Load(
+	ctx context.Context, repositoryURL, name, version string) (*chart.Chart, error) {
+	args := m.Called(ctx, repositoryURL, name, version)
+	chrt, _ := args.Get(0).(*chart.Chart)
+	return chrt, args.Error(1)
+}
+
+func newTestHelmChart() *chart.Chart {
+	return &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion: chart.APIVersionV2,
+			Name:       "test",
+			Version:    "1.0.0",
+		},
+		Values: map[string]interface{}{"greeting": "hello"},
+		Templates: []*chart.File{{
+			Name: "templates/configmap.yaml",
+			Data: []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}
+data:
+  greeting: {{ .Values.greeting }}
+`),
+		}},
+	}
+}
+
+func newTestHelmAddon() *addonsv1alpha1.Addon {
+	return &addonsv1alpha1.Addon{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "addon-1",
+			UID:  "addon-uid",
+		},
+		Spec: addonsv1alpha1.AddonSpec{
+			Install: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.Helm,
+				Helm: &addonsv1alpha1.AddonInstallHelm{
+					Namespace: "addon-1",
+					Chart: addonsv1alpha1.AddonHelmChart{
+						RepositoryURL: "https://charts.example.com",
+						Name:          "test",
+						Version:       "1.0.0",
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestEnsureManifests_Helm(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("RESTMapper").Return(newTestManifestsRESTMapper())
+	c.On("Get",
+		testutil.IsContext,
+		mock.Anything,
+		mock.IsType(&unstructured.Unstructured{}),
+	).Return(newTestErrNotFound())
+	var greetings []string
+	c.On("Patch",
+		testutil.IsContext,
+		mock.IsType(&unstructured.Unstructured{}),
+		mock.Anything,
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		obj := args.Get(1).(*unstructured.Unstructured)
+		assert.Equal(t, "addon-1", obj.GetName())
+		assert.Equal(t, "addon-1", obj.GetNamespace())
+		greeting, _, _ := unstructured.NestedString(obj.Object, "data", "greeting")
+		greetings = append(greetings, greeting)
+	}).Return(nil)
+
+	chartLoader := &chartLoaderMock{}
+	chartLoader.
+		On("Load", testutil.IsContext, "https://charts.example.com", "test", "1.0.0").
+		Return(newTestHelmChart(), nil)
+
+	r := &AddonReconciler{
+		Client:      c,
+		Log:         testutil.NewLogger(t),
+		Scheme:      newTestSchemeWithAddonsv1alpha1(),
+		ChartLoader: chartLoader,
+	}
+
+	addon := newTestHelmAddon()
+	ctx := context.Background()
+
+	// install
+	result, err := r.ensureManifests(ctx, r.Log, addon)
+	require.NoError(t, err)
+	assert.Equal(t, ensureManifestsResultNil, result)
+	require.NotNil(t, addon.Status.HelmRelease)
+	assert.Equal(t, 1, addon.Status.HelmRelease.Revision)
+	assert.Equal(t, "1.0.0", addon.Status.HelmRelease.ChartVersion)
+	assert.True(t, meta.IsStatusConditionTrue(addon.Status.Conditions, addonsv1alpha1.ManifestsReady))
+
+	// unchanged
+	_, err = r.ensureManifests(ctx, r.Log, addon)
+	require.NoError(t, err)
+	assert.Equal(t, 1, addon.Status.HelmRelease.Revision)
+
+	// upgrade
+	addon.Spec.Install.Helm.Values = &apiextensionsv1.JSON{Raw: []byte(`{"greeting": "hi"}`)}
+	_, err = r.ensureManifests(ctx, r.Log, addon)
+	require.NoError(t, err)
+	assert.Equal(t, 2, addon.Status.HelmRelease.Revision)
+
+	assert.Equal(t, []string{"hello", "hello", "hi"}, greetings)
+	assert.Equal(t, []addonsv1alpha1.AddonObjectReference{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "addon-1", Namespace: "addon-1"},
+	}, addon.Status.ManifestObjects)
+	c.AssertExpectations(t)
+	chartLoader.AssertExpectations(t)
+}
+
+func TestHelmValues(t *testing.T) {
+	addon := newTestHelmAddon()
+	addon.Spec.Install.Helm.Values = &apiextensionsv1.JSON{
+		Raw: []byte(`{"greeting": "hello", "replicas": 1}`),
+	}
+	addon.Spec.Parameters = map[string]apiextensionsv1.JSON{
+		"replicas": {Raw: []byte(`3`)},
+	}
+
+	values, err := helmValues(addon)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"greeting": "hello", "replicas": float64(1),
+	}, values)
+
+	// parameters take precedence
+	addon.Spec.Install.Helm.ValuesFromParameters = true
+	values, err = helmValues(addon)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"greeting": "hello", "replicas": float64(3),
+	}, values)
+}
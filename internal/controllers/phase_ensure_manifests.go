@@ -0,0 +1,491 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/manifests"
+)
+
+const manifestsFieldOwner = "addon-operator"
+
+// imagePuller pulls manifest files from OCI images.
+type imagePuller interface {
+	Pull(ctx context.Context, image string) (map[string][]byte, error)
+}
+
+type ensureManifestsResult int
+
+const (
+	ensureManifestsResultNil   ensureManifestsResult = iota
+	ensureManifestsResultStop  ensureManifestsResult = iota
+	ensureManifestsResultRetry ensureManifestsResult = iota
+)
+
+// Objects of these kinds are applied first, in this order,
+// so that objects of all other kinds can depend on them.
+var manifestKindOrder = map[string]int{
+	"ServiceAccount": 1,
+	"ConfigMap":      2,
+	"Secret":         3,
+}
+
+// Applies the manifests of a Manifests Addon or the rendered chart of a Helm Addon, prunes objects that are no longer part of them
+// and checks the health of all applied objects.
+func (r *AddonReconciler) ensureManifests(
+	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon,
+) (ensureManifestsResult, error) {
+	targetNamespace, _, stop, err := r.parseAddonInstallConfig(ctx, log, addon)
+	if err != nil {
+		return ensureManifestsResultNil, err
+	}
+	if stop {
+		return ensureManifestsResultStop, nil
+	}
+
+	var (
+		objects     []unstructured.Unstructured
+		helmRelease *addonsv1alpha1.AddonHelmReleaseStatus
+		loadResult  ensureManifestsResult
+	)
+	if addon.Spec.Install.Type == addonsv1alpha1.Helm {
+		objects, helmRelease, loadResult, err = r.renderHelmChart(ctx, addon, targetNamespace)
+	} else {
+		objects, loadResult, err = r.loadManifestObjects(ctx, addon)
+	}
+	if err != nil || loadResult != ensureManifestsResultNil {
+		return loadResult, err
+	}
+	if err := manifests.ValidateKinds(objects); err != nil {
+		// the addon-operator is not permitted to manage other kinds
+		message := err.Error()
+		setPhaseCondition(addon, addonsv1alpha1.ManifestsReady, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonConfigError, message)
+		return ensureManifestsResultStop, r.reportConfigurationError(ctx, addon, message)
+	}
+	sortManifestObjects(objects)
+
+	for i := range objects {
+		if err := r.prepareManifestObject(addon, &objects[i], targetNamespace); err != nil {
+			return ensureManifestsResultNil, err
+		}
+	}
+	if err := validateManifestNamespaces(addon, objects, targetNamespace); err != nil {
+		// objects may only be installed into namespaces of the Addon
+		message := err.Error()
+		setPhaseCondition(addon, addonsv1alpha1.ManifestsReady, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonConfigError, message)
+		return ensureManifestsResultStop, r.reportConfigurationError(ctx, addon, message)
+	}
+
+	var (
+		appliedRefs []addonsv1alpha1.AddonObjectReference
+		unready     []string
+	)
+	for i := range objects {
+		obj := &objects[i]
+
+		err := r.applyManifestObject(ctx, addon, obj)
+		var collision *adoptionCollisionError
+		if errors.As(err, &collision) {
+			return ensureManifestsResultRetry,
+				r.reportAdoptionCollision(ctx, addon, addonsv1alpha1.ManifestsReady, collision)
+		}
+		if err != nil {
+			return ensureManifestsResultNil, fmt.Errorf("applying %s: %w", manifestObjectName(obj), err)
+		}
+
+		appliedRefs = append(appliedRefs, manifestObjectReference(obj))
+		if message, err := manifestObjectUnreadyMessage(obj); err != nil {
+			return ensureManifestsResultNil, err
+		} else if message != "" {
+			unready = append(unready, fmt.Sprintf("%s: %s", manifestObjectName(obj), message))
+		}
+	}
+
+	if err := r.pruneManifestObjects(ctx, log, addon, appliedRefs); err != nil {
+		return ensureManifestsResultNil, fmt.Errorf("pruning objects: %w", err)
+	}
+	addon.Status.ManifestObjects = appliedRefs
+	if helmRelease != nil {
+		r.recordHelmRelease(addon, helmRelease)
+	}
+
+	if len(unready) > 0 {
+		setPhaseCondition(addon, addonsv1alpha1.ManifestsReady, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonUnreadyManifests, strings.Join(unready, ", "))
+		return ensureManifestsResultRetry, r.reportPhaseStatus(ctx, addon)
+	}
+
+	setPhaseCondition(addon, addonsv1alpha1.ManifestsReady, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+	return ensureManifestsResultNil, nil
+}
+
+// Loads and parses the manifests of a Manifests Addon.
+// Reports the ManifestsReady condition and returns a non-nil result, if the manifests can't be loaded.
+func (r *AddonReconciler) loadManifestObjects(
+	ctx context.Context, addon *addonsv1alpha1.Addon,
+) ([]unstructured.Unstructured, ensureManifestsResult, error) {
+	files, err := r.loadManifestFiles(ctx, addon.Spec.Install.Manifests)
+	if err != nil {
+		setPhaseCondition(addon, addonsv1alpha1.ManifestsReady, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonUnreadyManifests,
+			fmt.Sprintf("loading manifests: %v", err))
+		return nil, ensureManifestsResultRetry, r.reportPhaseStatus(ctx, addon)
+	}
+	objects, err := manifests.Parse(files)
+	if err != nil {
+		return nil, ensureManifestsResultStop, r.reportConfigurationError(ctx, addon,
+			fmt.Sprintf("parsing manifests: %v", err))
+	}
+	return objects, ensureManifestsResultNil, nil
+}
+
+// Loads the manifest files of the Addon from its ConfigMap or OCI image.
+func (r *AddonReconciler) loadManifestFiles(
+	ctx context.Context, manifestsSpec *addonsv1alpha1.AddonInstallManifests,
+) (map[string][]byte, error) {
+	if manifestsSpec.ConfigMapRef != nil {
+		// ConfigMaps are only cached by metadata.
+		configMap := &corev1.ConfigMap{}
+		if err := r.uncachedReader().Get(ctx, client.ObjectKey{
+			Name:      manifestsSpec.ConfigMapRef.Name,
+			Namespace: manifestsSpec.ConfigMapRef.Namespace,
+		}, configMap); err != nil {
+			return nil, fmt.Errorf("getting ConfigMap: %w", err)
+		}
+
+		files := map[string][]byte{}
+		for key, value := range configMap.Data {
+			files[key] = []byte(value)
+		}
+		for key, value := range configMap.BinaryData {
+			files[key] = value
+		}
+		return files, nil
+	}
+
+	if r.ImagePuller == nil {
+		return nil, errors.New("pulling images is not configured")
+	}
+	return r.ImagePuller.Pull(ctx, manifestsSpec.Image)
+}
+
+// Defaults the namespace of namespaced objects and adds common labels and the controller reference.
+func (r *AddonReconciler) prepareManifestObject(
+	addon *addonsv1alpha1.Addon, obj *unstructured.Unstructured, targetNamespace string) error {
+	gvk := obj.GroupVersionKind()
+	mapping, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return err
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if len(obj.GetNamespace()) == 0 {
+			obj.SetNamespace(targetNamespace)
+		}
+	} else {
+		obj.SetNamespace("")
+	}
+
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	addCommonLabels(labels, addon)
+	obj.SetLabels(labels)
+
+	return controllerutil.SetControllerReference(addon, obj, r.Scheme)
+}
+
+// Returns an error naming all objects outside of the target namespace and the namespaces of the Addon.
+func validateManifestNamespaces(
+	addon *addonsv1alpha1.Addon, objects []unstructured.Unstructured, targetNamespace string) error {
+	allowed := map[string]bool{targetNamespace: true}
+	for _, namespace := range addon.Spec.Namespaces {
+		allowed[namespace.Name] = true
+	}
+
+	var disallowed []string
+	for i := range objects {
+		obj := &objects[i]
+		// cluster-scoped objects have no namespace
+		if len(obj.GetNamespace()) == 0 || allowed[obj.GetNamespace()] {
+			continue
+		}
+		disallowed = append(disallowed, manifestObjectName(obj))
+	}
+	if len(disallowed) > 0 {
+		return fmt.Errorf("objects outside of the Addon namespaces: %s", strings.Join(disallowed, ", "))
+	}
+	return nil
+}
+
+// Applies the given object via server-side apply,
+// obj is updated with the state returned by the kube-apiserver.
+// Existing objects that are not controlled by the Addon are only adopted
+// as allowed by the adoption policy of the Addon, otherwise an *adoptionCollisionError is returned.
+func (r *AddonReconciler) applyManifestObject(
+	ctx context.Context, addon *addonsv1alpha1.Addon, obj *unstructured.Unstructured,
+) error {
+	currentObj := &unstructured.Unstructured{}
+	currentObj.SetGroupVersionKind(obj.GroupVersionKind())
+	err := r.Get(ctx, client.ObjectKeyFromObject(obj), currentObj)
+	switch {
+	case k8sApiErrors.IsNotFound(err):
+	case err != nil:
+		return fmt.Errorf("getting current object: %w", err)
+	default:
+		if err := adoptObject(addon, obj.GroupVersionKind(), currentObj, obj); err != nil {
+			return err
+		}
+	}
+
+	return r.Patch(ctx, obj, client.Apply,
+		client.ForceOwnership, client.FieldOwner(manifestsFieldOwner))
+}
+
+// Deletes objects applied for the Addon before, that are not in appliedRefs anymore.
+// Objects that are no longer controlled by the Addon are left alone.
+func (r *AddonReconciler) pruneManifestObjects(
+	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon,
+	appliedRefs []addonsv1alpha1.AddonObjectReference,
+) error {
+	applied := map[addonsv1alpha1.AddonObjectReference]struct{}{}
+	for _, ref := range appliedRefs {
+		applied[ref] = struct{}{}
+	}
+
+	// delete in reverse order of creation
+	for i := len(addon.Status.ManifestObjects) - 1; i >= 0; i-- {
+		ref := addon.Status.ManifestObjects[i]
+		if _, ok := applied[ref]; ok {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(ref.APIVersion)
+		obj.SetKind(ref.Kind)
+		err := r.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: ref.Namespace}, obj)
+		if k8sApiErrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("getting %s: %w", manifestObjectName(obj), err)
+		}
+		if !metav1.IsControlledBy(obj, addon) {
+			continue
+		}
+
+		log.Info("pruning object", "object", manifestObjectName(obj))
+		if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("deleting %s: %w", manifestObjectName(obj), err)
+		}
+		r.recordEvent(addon, corev1.EventTypeNormal, eventReasonManifestObjectPruned,
+			"Pruned %s", manifestObjectName(obj))
+	}
+	return nil
+}
+
+// Checks the health of the objects applied for the Addon, without changing anything on the cluster.
+func (r *AddonReconciler) observeManifests(
+	ctx context.Context, addon *addonsv1alpha1.Addon) error {
+	var unready []string
+	for _, ref := range addon.Status.ManifestObjects {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(ref.APIVersion)
+		obj.SetKind(ref.Kind)
+		err := r.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: ref.Namespace}, obj)
+		if k8sApiErrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			obj.SetName(ref.Name)
+			obj.SetNamespace(ref.Namespace)
+			unready = append(unready, fmt.Sprintf("%s: not found", manifestObjectName(obj)))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if message, err := manifestObjectUnreadyMessage(obj); err != nil {
+			return err
+		} else if message != "" {
+			unready = append(unready, fmt.Sprintf("%s: %s", manifestObjectName(obj), message))
+		}
+	}
+
+	if len(unready) > 0 {
+		setPhaseCondition(addon, addonsv1alpha1.ManifestsReady, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonUnreadyManifests, strings.Join(unready, ", "))
+		return nil
+	}
+	setPhaseCondition(addon, addonsv1alpha1.ManifestsReady, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+	return nil
+}
+
+// Returns why the given object is not healthy, or an empty string if it is healthy.
+// Deployments, StatefulSets and DaemonSets have to be rolled out and available,
+// Jobs have to be complete. Objects of all other kinds are healthy when they exist.
+func manifestObjectUnreadyMessage(obj *unstructured.Unstructured) (string, error) {
+	switch obj.GroupVersionKind().GroupKind() {
+	case schema.GroupKind{Group: appsv1.GroupName, Kind: "Deployment"}:
+		deployment := &appsv1.Deployment{}
+		if err := fromUnstructured(obj, deployment); err != nil {
+			return "", err
+		}
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		if deployment.Status.ObservedGeneration < deployment.Generation ||
+			deployment.Status.UpdatedReplicas < replicas ||
+			deployment.Status.AvailableReplicas < replicas {
+			return fmt.Sprintf("%d/%d replicas available",
+				deployment.Status.AvailableReplicas, replicas), nil
+		}
+
+	case schema.GroupKind{Group: appsv1.GroupName, Kind: "StatefulSet"}:
+		statefulSet := &appsv1.StatefulSet{}
+		if err := fromUnstructured(obj, statefulSet); err != nil {
+			return "", err
+		}
+		replicas := int32(1)
+		if statefulSet.Spec.Replicas != nil {
+			replicas = *statefulSet.Spec.Replicas
+		}
+		if statefulSet.Status.ObservedGeneration < statefulSet.Generation ||
+			statefulSet.Status.UpdatedReplicas < replicas ||
+			statefulSet.Status.ReadyReplicas < replicas {
+			return fmt.Sprintf("%d/%d replicas ready",
+				statefulSet.Status.ReadyReplicas, replicas), nil
+		}
+
+	case schema.GroupKind{Group: appsv1.GroupName, Kind: "DaemonSet"}:
+		daemonSet := &appsv1.DaemonSet{}
+		if err := fromUnstructured(obj, daemonSet); err != nil {
+			return "", err
+		}
+		if daemonSet.Status.ObservedGeneration < daemonSet.Generation ||
+			daemonSet.Status.UpdatedNumberScheduled < daemonSet.Status.DesiredNumberScheduled ||
+			daemonSet.Status.NumberAvailable < daemonSet.Status.DesiredNumberScheduled {
+			return fmt.Sprintf("%d/%d pods available",
+				daemonSet.Status.NumberAvailable, daemonSet.Status.DesiredNumberScheduled), nil
+		}
+
+	case schema.GroupKind{Group: batchv1.GroupName, Kind: "Job"}:
+		job := &batchv1.Job{}
+		if err := fromUnstructured(obj, job); err != nil {
+			return "", err
+		}
+		for _, cond := range job.Status.Conditions {
+			if cond.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch cond.Type {
+			case batchv1.JobComplete:
+				return "", nil
+			case batchv1.JobFailed:
+				return fmt.Sprintf("failed: %s", cond.Message), nil
+			}
+		}
+		return "not complete", nil
+	}
+	return "", nil
+}
+
+func fromUnstructured(obj *unstructured.Unstructured, into interface{}) error {
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, into); err != nil {
+		return fmt.Errorf("converting %s: %w", manifestObjectName(obj), err)
+	}
+	return nil
+}
+
+// Sorts objects, so that objects other objects depend on are applied first.
+// Keeps the order of the manifests otherwise.
+func sortManifestObjects(objects []unstructured.Unstructured) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		return manifestKindRank(objects[i].GetKind()) < manifestKindRank(objects[j].GetKind())
+	})
+}
+
+func manifestKindRank(kind string) int {
+	if rank, ok := manifestKindOrder[kind]; ok {
+		return rank
+	}
+	return len(manifestKindOrder) + 1
+}
+
+func manifestObjectReference(obj *unstructured.Unstructured) addonsv1alpha1.AddonObjectReference {
+	return addonsv1alpha1.AddonObjectReference{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+	}
+}
+
+// Returns refs followed by all references of additionalRefs not already in refs.
+func mergeObjectReferences(
+	refs, additionalRefs []addonsv1alpha1.AddonObjectReference) []addonsv1alpha1.AddonObjectReference {
+	seen := map[addonsv1alpha1.AddonObjectReference]struct{}{}
+	merged := make([]addonsv1alpha1.AddonObjectReference, 0, len(refs)+len(additionalRefs))
+	for _, ref := range append(refs, additionalRefs...) {
+		if _, ok := seen[ref]; ok {
+			continue
+		}
+		seen[ref] = struct{}{}
+		merged = append(merged, ref)
+	}
+	return merged
+}
+
+// Returns a human readable identifier of the object for messages.
+func manifestObjectName(obj *unstructured.Unstructured) string {
+	if len(obj.GetNamespace()) == 0 {
+		return fmt.Sprintf("%s %s", obj.GetKind(), obj.GetName())
+	}
+	return fmt.Sprintf("%s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+}
+
+// Maps ConfigMaps to the Manifests Addons loading their manifests from them.
+func (r *AddonReconciler) enqueueAddonsForManifestsConfigMap(obj client.Object) []reconcile.Request {
+	addonList := &addonsv1alpha1.AddonList{}
+	if err := r.List(context.Background(), addonList); err != nil {
+		r.Log.Error(err, "listing Addons to enqueue for manifests ConfigMap",
+			"configmap", client.ObjectKeyFromObject(obj).String())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, addon := range addonList.Items {
+		manifestsSpec := addon.Spec.Install.Manifests
+		if manifestsSpec == nil || manifestsSpec.ConfigMapRef == nil ||
+			manifestsSpec.ConfigMapRef.Name != obj.GetName() ||
+			manifestsSpec.ConfigMapRef.Namespace != obj.GetNamespace() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: addon.Name},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,379 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/testutil"
+)
+
+const testManifests = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  replicas: 2
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+`
+
+func newTestManifestsAddon() *addonsv1alpha1.Addon {
+	return &addonsv1alpha1.Addon{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "addon-1",
+			UID:  "addon-uid",
+		},
+		Spec: addonsv1alpha1.AddonSpec{
+			Install: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.Manifests,
+				Manifests: &addonsv1alpha1.AddonInstallManifests{
+					Namespace: "addon-1",
+					ConfigMapRef: &addonsv1alpha1.AddonManifestsConfigMapReference{
+						Name:      "manifests",
+						Namespace: "addon-1",
+					},
+				},
+			},
+		},
+	}
+}
+
+func newTestManifestsRESTMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	return mapper
+}
+
+func newTestManifestsClient() *testutil.Client {
+	c := testutil.NewClient()
+	c.On("RESTMapper").Return(newTestManifestsRESTMapper())
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "manifests", Namespace: "addon-1"},
+		mock.IsType(&corev1.ConfigMap{}),
+	).Run(func(args mock.Arguments) {
+		cm := args.Get(2).(*corev1.ConfigMap)
+		cm.Data = map[string]string{"manifests.yaml": testManifests}
+	}).Return(nil)
+	return c
+}
+
+func TestEnsureManifests(t *testing.T) {
+	c := newTestManifestsClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "test", Namespace: "addon-1"},
+		mock.IsType(&unstructured.Unstructured{}),
+	).Return(newTestErrNotFound())
+
+	var applied []string
+	c.On("Patch",
+		testutil.IsContext,
+		mock.IsType(&unstructured.Unstructured{}),
+		client.Apply,
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		obj := args.Get(1).(*unstructured.Unstructured)
+		applied = append(applied, obj.GetKind())
+		assert.Equal(t, "addon-1", obj.GetNamespace())
+		assert.Equal(t, "addon-1", obj.GetLabels()[commonInstanceLabel])
+		assert.Len(t, obj.GetOwnerReferences(), 1)
+		if obj.GetKind() == "Deployment" {
+			// as returned by the kube-apiserver
+			_ = unstructured.SetNestedField(obj.Object, int64(1), "status", "availableReplicas")
+		}
+	}).Return(nil)
+	c.StatusMock.On("Update", testutil.IsContext, testutil.IsAddonsv1alpha1AddonPtr, mock.Anything).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	addon := newTestManifestsAddon()
+	result, err := r.ensureManifests(context.Background(), r.Log, addon)
+	require.NoError(t, err)
+	c.AssertExpectations(t)
+
+	// ConfigMaps are applied before other kinds
+	assert.Equal(t, []string{"ConfigMap", "Deployment"}, applied)
+	assert.Equal(t, ensureManifestsResultRetry, result)
+	assert.Equal(t, []addonsv1alpha1.AddonObjectReference{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "test", Namespace: "addon-1"},
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "test", Namespace: "addon-1"},
+	}, addon.Status.ManifestObjects)
+
+	cond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.ManifestsReady)
+	if assert.NotNil(t, cond) {
+		assert.Equal(t, metav1.ConditionFalse, cond.Status)
+		assert.Equal(t, addonsv1alpha1.AddonReasonUnreadyManifests, cond.Reason)
+		assert.Equal(t, "Deployment addon-1/test: 1/2 replicas available", cond.Message)
+	}
+}
+
+func TestEnsureManifests_Prune(t *testing.T) {
+	addon := newTestManifestsAddon()
+	addon.Status.ManifestObjects = []addonsv1alpha1.AddonObjectReference{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "test", Namespace: "addon-1"},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "old", Namespace: "addon-1"},
+	}
+
+	c := newTestManifestsClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "test", Namespace: "addon-1"},
+		mock.IsType(&unstructured.Unstructured{}),
+	).Return(newTestErrNotFound())
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "old", Namespace: "addon-1"},
+		mock.IsType(&unstructured.Unstructured{}),
+	).Run(func(args mock.Arguments) {
+		obj := args.Get(2).(*unstructured.Unstructured)
+		obj.SetName("old")
+		obj.SetNamespace("addon-1")
+		obj.SetOwnerReferences([]metav1.OwnerReference{{
+			APIVersion: addonsv1alpha1.GroupVersion.String(),
+			Kind:       "Addon",
+			Name:       addon.Name,
+			UID:        addon.UID,
+			Controller: func(b bool) *bool { return &b }(true),
+		}})
+	}).Return(nil)
+	c.On("Patch",
+		testutil.IsContext,
+		mock.IsType(&unstructured.Unstructured{}),
+		client.Apply,
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		obj := args.Get(1).(*unstructured.Unstructured)
+		if obj.GetKind() == "Deployment" {
+			_ = unstructured.SetNestedField(obj.Object, int64(2), "status", "replicas")
+			_ = unstructured.SetNestedField(obj.Object, int64(2), "status", "updatedReplicas")
+			_ = unstructured.SetNestedField(obj.Object, int64(2), "status", "availableReplicas")
+		}
+	}).Return(nil)
+	var deleted *unstructured.Unstructured
+	c.On("Delete",
+		testutil.IsContext,
+		mock.IsType(&unstructured.Unstructured{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		deleted = args.Get(1).(*unstructured.Unstructured)
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	result, err := r.ensureManifests(context.Background(), r.Log, addon)
+	require.NoError(t, err)
+	c.AssertExpectations(t)
+
+	assert.Equal(t, ensureManifestsResultNil, result)
+	if assert.NotNil(t, deleted) {
+		assert.Equal(t, "old", deleted.GetName())
+	}
+	assert.Len(t, addon.Status.ManifestObjects, 2)
+	assert.True(t, meta.IsStatusConditionTrue(addon.Status.Conditions, addonsv1alpha1.ManifestsReady))
+}
+
+func TestEnsureManifests_Collision(t *testing.T) {
+	c := newTestManifestsClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "test", Namespace: "addon-1"},
+		mock.IsType(&unstructured.Unstructured{}),
+	).Run(func(args mock.Arguments) {
+		obj := args.Get(2).(*unstructured.Unstructured)
+		obj.SetName("test")
+		obj.SetNamespace("addon-1")
+	}).Return(nil)
+	c.StatusMock.On("Update", testutil.IsContext, testutil.IsAddonsv1alpha1AddonPtr, mock.Anything).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	addon := newTestManifestsAddon()
+	result, err := r.ensureManifests(context.Background(), r.Log, addon)
+	require.NoError(t, err)
+	c.AssertExpectations(t)
+	c.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	assert.Equal(t, ensureManifestsResultRetry, result)
+	cond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.ManifestsReady)
+	if assert.NotNil(t, cond) {
+		assert.Equal(t, metav1.ConditionFalse, cond.Status)
+		assert.Equal(t, addonsv1alpha1.AddonReasonAdoptionPrevented, cond.Reason)
+	}
+}
+
+func TestManifestObjectUnreadyMessage(t *testing.T) {
+	testCases := []struct {
+		name     string
+		obj      map[string]interface{}
+		expected string
+	}{
+		{
+			name: "available Deployment",
+			obj: map[string]interface{}{
+				"apiVersion": "apps/v1", "kind": "Deployment",
+				"metadata": map[string]interface{}{"name": "test", "generation": int64(2)},
+				"status": map[string]interface{}{
+					"observedGeneration": int64(2),
+					"updatedReplicas":    int64(1),
+					"availableReplicas":  int64(1),
+				},
+			},
+		},
+		{
+			name: "Deployment rollout in progress",
+			obj: map[string]interface{}{
+				"apiVersion": "apps/v1", "kind": "Deployment",
+				"metadata": map[string]interface{}{"name": "test", "generation": int64(2)},
+				"status": map[string]interface{}{
+					"observedGeneration": int64(1),
+					"updatedReplicas":    int64(1),
+					"availableReplicas":  int64(1),
+				},
+			},
+			expected: "1/1 replicas available",
+		},
+		{
+			name: "failed Job",
+			obj: map[string]interface{}{
+				"apiVersion": "batch/v1", "kind": "Job",
+				"metadata": map[string]interface{}{"name": "test"},
+				"status": map[string]interface{}{
+					"conditions": []interface{}{
+						map[string]interface{}{"type": "Failed", "status": "True", "message": "BackoffLimitExceeded"},
+					},
+				},
+			},
+			expected: "failed: BackoffLimitExceeded",
+		},
+		{
+			name: "ConfigMap",
+			obj: map[string]interface{}{
+				"apiVersion": "v1", "kind": "ConfigMap",
+				"metadata": map[string]interface{}{"name": "test"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			message, err := manifestObjectUnreadyMessage(&unstructured.Unstructured{Object: tc.obj})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, message)
+		})
+	}
+}
+
+func TestEnsureManifests_UnsupportedKind(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "manifests", Namespace: "addon-1"},
+		mock.IsType(&corev1.ConfigMap{}),
+	).Run(func(args mock.Arguments) {
+		cm := args.Get(2).(*corev1.ConfigMap)
+		cm.Data = map[string]string{"manifests.yaml": testManifests + `
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: test
+`}
+	}).Return(nil)
+	c.StatusMock.On("Update", testutil.IsContext, testutil.IsAddonsv1alpha1AddonPtr, mock.Anything).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	addon := newTestManifestsAddon()
+	result, err := r.ensureManifests(context.Background(), r.Log, addon)
+	require.NoError(t, err)
+	assert.Equal(t, ensureManifestsResultStop, result)
+	// nothing is applied
+	c.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	manifestsCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.ManifestsReady)
+	if assert.NotNil(t, manifestsCond) {
+		assert.Equal(t, addonsv1alpha1.AddonReasonConfigError, manifestsCond.Reason)
+		assert.Equal(t, "unsupported kinds: RoleBinding.rbac.authorization.k8s.io test", manifestsCond.Message)
+	}
+}
+
+func TestEnsureManifests_ForeignNamespace(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("RESTMapper").Return(newTestManifestsRESTMapper())
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "manifests", Namespace: "addon-1"},
+		mock.IsType(&corev1.ConfigMap{}),
+	).Run(func(args mock.Arguments) {
+		cm := args.Get(2).(*corev1.ConfigMap)
+		cm.Data = map[string]string{"manifests.yaml": testManifests + `
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: extra
+  namespace: addon-extra
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foreign
+  namespace: kube-system
+`}
+	}).Return(nil)
+	c.StatusMock.On("Update", testutil.IsContext, testutil.IsAddonsv1alpha1AddonPtr, mock.Anything).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	addon := newTestManifestsAddon()
+	addon.Spec.Namespaces = []addonsv1alpha1.AddonNamespace{{Name: "addon-extra"}}
+	result, err := r.ensureManifests(context.Background(), r.Log, addon)
+	require.NoError(t, err)
+	assert.Equal(t, ensureManifestsResultStop, result)
+	// nothing is applied
+	c.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	manifestsCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.ManifestsReady)
+	if assert.NotNil(t, manifestsCond) {
+		assert.Equal(t, addonsv1alpha1.AddonReasonConfigError, manifestsCond.Reason)
+		assert.Equal(t, "objects outside of the Addon namespaces: ConfigMap kube-system/foreign",
+			manifestsCond.Message)
+	}
+}
@@ -2,12 +2,15 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/go-logr/logr"
 	operatorsv1 "github.com/operator-framework/api/pkg/operators/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
-	"k8s.io/apimachinery/pkg/api/errors"
+	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -15,51 +18,212 @@ import (
 	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
 )
 
-// Ensures the presense or absense of an OperatorGroup depending on the Addon install type.
+type ensureOperatorGroupResult int
+
+const (
+	ensureOperatorGroupResultNil   ensureOperatorGroupResult = iota
+	ensureOperatorGroupResultStop  ensureOperatorGroupResult = iota
+	ensureOperatorGroupResultRetry ensureOperatorGroupResult = iota
+)
+
+// Ensures the OperatorGroup of an Addon installed via OLM.
+// OLM supports only one OperatorGroup per Namespace, so other OperatorGroups
+// in the install Namespace are handled according to the foreign OperatorGroup policy of the Addon.
 func (r *AddonReconciler) ensureOperatorGroup(
-	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon) (stop bool, err error) {
+	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon,
+) (ensureOperatorGroupResult, error) {
 	targetNamespace, _, stop, err := r.parseAddonInstallConfig(ctx, log, addon)
 	if err != nil {
-		return false, err
+		return ensureOperatorGroupResultNil, err
 	}
 	if stop {
-		return true, nil
+		return ensureOperatorGroupResultStop, nil
+	}
+
+	ownedOperatorGroups, foreignOperatorGroups, err := r.listOperatorGroups(ctx, addon, targetNamespace)
+	if err != nil {
+		return ensureOperatorGroupResultNil, err
+	}
+	operatorGroupName := addonOperatorGroupName(addon, ownedOperatorGroups)
+	adoptionPolicy := adoptionPolicy(addon, operatorsv1.OperatorGroupKind)
+	if len(foreignOperatorGroups) > 0 {
+		policy := getCommonInstallOptions(addon).ForeignOperatorGroupPolicy
+		switch {
+		case policy == addonsv1alpha1.ForeignOperatorGroupPolicyRemove:
+			if err := r.removeForeignOperatorGroups(ctx, addon, foreignOperatorGroups); err != nil {
+				return ensureOperatorGroupResultNil, err
+			}
+		case policy == addonsv1alpha1.ForeignOperatorGroupPolicyAdopt && len(foreignOperatorGroups) == 1:
+			// explicitly requested, so regardless of the adoption policy of the Addon
+			operatorGroupName = foreignOperatorGroups[0].Name
+			adoptionPolicy = addonsv1alpha1.AdoptionPolicyAdoptAll
+		default:
+			log.Info("requeue", "reason", "foreign OperatorGroups")
+			return ensureOperatorGroupResultRetry,
+				r.reportForeignOperatorGroups(ctx, addon, targetNamespace, policy, foreignOperatorGroups)
+		}
 	}
 
 	desiredOperatorGroup := &operatorsv1.OperatorGroup{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      addon.Name,
+			Name:      operatorGroupName,
 			Namespace: targetNamespace,
 			Labels:    map[string]string{},
 		},
 	}
-	if addon.Spec.Install.Type == addonsv1alpha1.OLMOwnNamespace {
+	switch addon.Spec.Install.Type {
+	case addonsv1alpha1.OLMOwnNamespace:
 		desiredOperatorGroup.Spec.TargetNamespaces = []string{targetNamespace}
+	case addonsv1alpha1.OLMMultiNamespace:
+		desiredOperatorGroup.Spec.TargetNamespaces = append(
+			[]string{}, addon.Spec.Install.OLMMultiNamespace.TargetNamespaces...)
 	}
 
 	addCommonLabels(desiredOperatorGroup.Labels, addon)
 	if err := controllerutil.SetControllerReference(addon, desiredOperatorGroup, r.Scheme); err != nil {
-		return false, fmt.Errorf("setting controller reference: %w", err)
+		return ensureOperatorGroupResultNil, fmt.Errorf("setting controller reference: %w", err)
+	}
+
+	err = r.reconcileOperatorGroup(ctx, addon, desiredOperatorGroup, adoptionPolicy)
+	var collision *adoptionCollisionError
+	if errors.As(err, &collision) {
+		return ensureOperatorGroupResultRetry,
+			r.reportAdoptionCollision(ctx, addon, addonsv1alpha1.OperatorGroupReady, collision)
+	}
+	if err != nil {
+		return ensureOperatorGroupResultNil, err
+	}
+
+	// OperatorGroups replaced by an adopted OperatorGroup
+	for i := range ownedOperatorGroups {
+		operatorGroup := &ownedOperatorGroups[i]
+		if operatorGroup.Name == operatorGroupName {
+			continue
+		}
+		if err := r.deleteOperatorGroup(ctx, addon, operatorGroup); err != nil {
+			return ensureOperatorGroupResultNil, err
+		}
+	}
+
+	setPhaseCondition(addon, addonsv1alpha1.OperatorGroupReady, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+	return ensureOperatorGroupResultNil, nil
+}
+
+// Lists the OperatorGroups in the given Namespace,
+// split into the ones controlled by the Addon and foreign ones.
+// An OperatorGroup named after the Addon is never foreign,
+// it collides with the OperatorGroup of the Addon and is subject to the adoption policy.
+func (r *AddonReconciler) listOperatorGroups(
+	ctx context.Context, addon *addonsv1alpha1.Addon, namespace string,
+) (owned, foreign []operatorsv1.OperatorGroup, err error) {
+	operatorGroupList := &operatorsv1.OperatorGroupList{}
+	if err := r.List(ctx, operatorGroupList, client.InNamespace(namespace)); err != nil {
+		return nil, nil, fmt.Errorf("listing OperatorGroups: %w", err)
 	}
 
-	return false, r.reconcileOperatorGroup(ctx, desiredOperatorGroup)
+	for _, operatorGroup := range operatorGroupList.Items {
+		switch {
+		case metav1.IsControlledBy(&operatorGroup, addon):
+			owned = append(owned, operatorGroup)
+		case operatorGroup.Name != addon.Name:
+			foreign = append(foreign, operatorGroup)
+		}
+	}
+	return owned, foreign, nil
+}
+
+// Returns the name of the OperatorGroup of the Addon.
+// OperatorGroups created by the Addon are named after it,
+// adopted OperatorGroups keep their name.
+func addonOperatorGroupName(
+	addon *addonsv1alpha1.Addon, ownedOperatorGroups []operatorsv1.OperatorGroup) string {
+	for _, operatorGroup := range ownedOperatorGroups {
+		if operatorGroup.Name == addon.Name {
+			return addon.Name
+		}
+	}
+	if len(ownedOperatorGroups) > 0 {
+		return ownedOperatorGroups[0].Name
+	}
+	return addon.Name
+}
+
+// Deletes the given foreign OperatorGroups.
+func (r *AddonReconciler) removeForeignOperatorGroups(
+	ctx context.Context, addon *addonsv1alpha1.Addon, operatorGroups []operatorsv1.OperatorGroup) error {
+	for i := range operatorGroups {
+		if err := r.deleteOperatorGroup(ctx, addon, &operatorGroups[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *AddonReconciler) deleteOperatorGroup(
+	ctx context.Context, addon *addonsv1alpha1.Addon, operatorGroup *operatorsv1.OperatorGroup) error {
+	if err := r.Delete(ctx, operatorGroup); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("deleting OperatorGroup: %w", err)
+	}
+	r.recordEvent(addon, corev1.EventTypeNormal, eventReasonOperatorGroupRemoved,
+		"Removed OperatorGroup %s/%s", operatorGroup.Namespace, operatorGroup.Name)
+	return nil
+}
+
+// Reports the foreign OperatorGroups in the install Namespace as configuration error,
+// OLM will not install the Addon until they are gone.
+func (r *AddonReconciler) reportForeignOperatorGroups(
+	ctx context.Context, addon *addonsv1alpha1.Addon, namespace string,
+	policy addonsv1alpha1.AddonForeignOperatorGroupPolicy,
+	operatorGroups []operatorsv1.OperatorGroup) error {
+	names := make([]string, len(operatorGroups))
+	for i, operatorGroup := range operatorGroups {
+		names[i] = operatorGroup.Name
+	}
+	message := fmt.Sprintf(
+		"Namespace %s contains OperatorGroups not owned by this Addon: %s, "+
+			"OLM supports only one OperatorGroup per Namespace", namespace, strings.Join(names, ", "))
+	if policy == addonsv1alpha1.ForeignOperatorGroupPolicyAdopt {
+		message += ", only a single foreign OperatorGroup can be adopted"
+	} else {
+		message += ", set foreignOperatorGroupPolicy to Adopt or Remove to resolve this automatically"
+	}
+
+	r.recordEvent(addon, corev1.EventTypeWarning, eventReasonForeignOperatorGroups, "%s", message)
+	setPhaseCondition(addon, addonsv1alpha1.OperatorGroupReady, metav1.ConditionFalse,
+		addonsv1alpha1.AddonReasonConfigError, message)
+	return r.reportConfigurationError(ctx, addon, message)
 }
 
 // Reconciles the Spec of the given OperatorGroup if needed by updating or creating the OperatorGroup.
-// The given OperatorGroup is updated to reflect the latest state from the kube-apiserver.
+// Existing OperatorGroups are only adopted as allowed by the given adoption policy.
 func (r *AddonReconciler) reconcileOperatorGroup(
-	ctx context.Context, operatorGroup *operatorsv1.OperatorGroup) error {
+	ctx context.Context, addon *addonsv1alpha1.Addon, operatorGroup *operatorsv1.OperatorGroup,
+	adoptionPolicy addonsv1alpha1.AddonAdoptionPolicy) error {
 	currentOperatorGroup := &operatorsv1.OperatorGroup{}
 
 	err := r.Get(ctx, client.ObjectKeyFromObject(operatorGroup), currentOperatorGroup)
-	if errors.IsNotFound(err) {
+	if k8sApiErrors.IsNotFound(err) {
 		return r.Create(ctx, operatorGroup)
 	}
 	if err != nil {
 		return fmt.Errorf("getting OperatorGroup: %w", err)
 	}
 
-	if !equality.Semantic.DeepEqual(currentOperatorGroup.Spec, operatorGroup.Spec) ||
+	if err := adoptObjectWithPolicy(addon, adoptionPolicy,
+		operatorsv1.GroupVersion.WithKind(operatorsv1.OperatorGroupKind),
+		currentOperatorGroup, operatorGroup); err != nil {
+		return err
+	}
+
+	// labels and annotations are merged, to keep entries added by others
+	changed := mergeStringMap(&currentOperatorGroup.Labels, operatorGroup.Labels)
+	if mergeStringMap(&currentOperatorGroup.Annotations, operatorGroup.Annotations) {
+		changed = true
+	}
+
+	if changed ||
+		!equality.Semantic.DeepEqual(currentOperatorGroup.Spec, operatorGroup.Spec) ||
 		!equality.Semantic.DeepEqual(currentOperatorGroup.OwnerReferences, operatorGroup.OwnerReferences) {
 		currentOperatorGroup.Spec = operatorGroup.Spec
 		currentOperatorGroup.OwnerReferences = operatorGroup.OwnerReferences
@@ -13,6 +13,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
 	"github.com/openshift/addon-operator/internal/testutil"
@@ -54,6 +55,24 @@ func TestEnsureOperatorGroup(t *testing.T) {
 			},
 		}
 
+		addonMultiNamespace := &addonsv1alpha1.Addon{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "addon-1",
+			},
+			Spec: addonsv1alpha1.AddonSpec{
+				Install: addonsv1alpha1.AddonInstallSpec{
+					Type: addonsv1alpha1.OLMMultiNamespace,
+					OLMMultiNamespace: &addonsv1alpha1.AddonInstallOLMMultiNamespace{
+						AddonInstallOLMCommon: addonsv1alpha1.AddonInstallOLMCommon{
+							CatalogSourceImage: "quay.io/osd-addons/test:sha256:04864220677b2ed6244f2e0d421166df908986700647595ffdb6fd9ca4e5098a",
+							Namespace:          "addon-system",
+						},
+						TargetNamespaces: []string{"tenant-1", "tenant-2"},
+					},
+				},
+			},
+		}
+
 		tests := []struct {
 			name                     string
 			addon                    *addonsv1alpha1.Addon
@@ -71,6 +90,12 @@ func TestEnsureOperatorGroup(t *testing.T) {
 				addon:           addonAllNamespaces,
 				targetNamespace: addonAllNamespaces.Spec.Install.OLMAllNamespaces.Namespace,
 			},
+			{
+				name:                     "MultiNamespace",
+				addon:                    addonMultiNamespace,
+				targetNamespace:          addonMultiNamespace.Spec.Install.OLMMultiNamespace.Namespace,
+				expectedTargetNamespaces: []string{"tenant-1", "tenant-2"},
+			},
 		}
 
 		for _, test := range tests {
@@ -84,6 +109,14 @@ func TestEnsureOperatorGroup(t *testing.T) {
 				addon := test.addon
 
 				// Mock Setup
+				c.
+					On(
+						"List",
+						mock.Anything,
+						mock.IsType(&operatorsv1.OperatorGroupList{}),
+						mock.Anything,
+					).
+					Return(nil)
 				c.
 					On(
 						"Get",
@@ -110,9 +143,9 @@ func TestEnsureOperatorGroup(t *testing.T) {
 
 				// Test
 				ctx := context.Background()
-				stop, err := r.ensureOperatorGroup(ctx, log, addon)
+				result, err := r.ensureOperatorGroup(ctx, log, addon)
 				require.NoError(t, err)
-				assert.False(t, stop)
+				assert.Equal(t, ensureOperatorGroupResultNil, result)
 
 				if c.AssertCalled(
 					t, "Create",
@@ -211,9 +244,9 @@ func TestEnsureOperatorGroup(t *testing.T) {
 
 				// Test
 				ctx := context.Background()
-				stop, err := r.ensureOperatorGroup(ctx, log, test.addon)
+				result, err := r.ensureOperatorGroup(ctx, log, test.addon)
 				require.NoError(t, err)
-				assert.True(t, stop)
+				assert.Equal(t, ensureOperatorGroupResultStop, result)
 
 				c.StatusMock.AssertCalled(
 					t, "Update", mock.Anything, test.addon, mock.Anything)
@@ -248,9 +281,9 @@ func TestEnsureOperatorGroup(t *testing.T) {
 
 		// Test
 		ctx := context.Background()
-		stop, err := r.ensureOperatorGroup(ctx, log, addonUnsupported.DeepCopy())
+		result, err := r.ensureOperatorGroup(ctx, log, addonUnsupported.DeepCopy())
 		require.NoError(t, err)
-		assert.True(t, stop)
+		assert.Equal(t, ensureOperatorGroupResultStop, result)
 
 		// indirect sanity check
 		// nothing was called on the client and the method signals to stop
@@ -258,15 +291,20 @@ func TestEnsureOperatorGroup(t *testing.T) {
 }
 
 func TestReconcileOperatorGroup(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
 	operatorGroup := &operatorsv1.OperatorGroup{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "testing",
 			Namespace: "testing-ns",
+			Labels:    map[string]string{},
 		},
 		Spec: operatorsv1.OperatorGroupSpec{
 			TargetNamespaces: []string{"testing-ns"},
 		},
 	}
+	addCommonLabels(operatorGroup.Labels, addon)
+	require.NoError(t, controllerutil.SetControllerReference(
+		addon, operatorGroup, newTestSchemeWithAddonsv1alpha1()))
 
 	t.Run("no-op", func(t *testing.T) {
 		c := testutil.NewClient()
@@ -289,7 +327,8 @@ func TestReconcileOperatorGroup(t *testing.T) {
 			Return(nil)
 
 		ctx := context.Background()
-		err := r.reconcileOperatorGroup(ctx, operatorGroup.DeepCopy())
+		err := r.reconcileOperatorGroup(ctx, addon.DeepCopy(), operatorGroup.DeepCopy(),
+			addonsv1alpha1.AdoptionPolicyPrevent)
 		require.NoError(t, err)
 	})
 
@@ -307,6 +346,11 @@ func TestReconcileOperatorGroup(t *testing.T) {
 				client.ObjectKeyFromObject(operatorGroup),
 				mock.IsType(&operatorsv1.OperatorGroup{}),
 			).
+			Run(func(args mock.Arguments) {
+				og := args.Get(2).(*operatorsv1.OperatorGroup)
+				operatorGroup.DeepCopyInto(og)
+				og.Spec = operatorsv1.OperatorGroupSpec{}
+			}).
 			Return(nil)
 
 		c.
@@ -319,7 +363,8 @@ func TestReconcileOperatorGroup(t *testing.T) {
 			Return(nil)
 
 		ctx := context.Background()
-		err := r.reconcileOperatorGroup(ctx, operatorGroup.DeepCopy())
+		err := r.reconcileOperatorGroup(ctx, addon.DeepCopy(), operatorGroup.DeepCopy(),
+			addonsv1alpha1.AdoptionPolicyPrevent)
 		require.NoError(t, err)
 
 		c.AssertCalled(t,
@@ -329,4 +374,329 @@ func TestReconcileOperatorGroup(t *testing.T) {
 			mock.Anything,
 		)
 	})
+
+	t.Run("merges labels", func(t *testing.T) {
+		c := testutil.NewClient()
+		r := AddonReconciler{
+			Client: c,
+			Scheme: newTestSchemeWithAddonsv1alpha1(),
+		}
+
+		c.
+			On(
+				"Get",
+				mock.Anything,
+				client.ObjectKeyFromObject(operatorGroup),
+				mock.IsType(&operatorsv1.OperatorGroup{}),
+			).
+			Run(func(args mock.Arguments) {
+				og := args.Get(2).(*operatorsv1.OperatorGroup)
+				operatorGroup.DeepCopyInto(og)
+				og.Labels = map[string]string{"team": "sre"}
+			}).
+			Return(nil)
+
+		var updatedOperatorGroup *operatorsv1.OperatorGroup
+		c.
+			On(
+				"Update",
+				mock.Anything,
+				mock.IsType(&operatorsv1.OperatorGroup{}),
+				mock.Anything,
+			).
+			Run(func(args mock.Arguments) {
+				updatedOperatorGroup = args.Get(1).(*operatorsv1.OperatorGroup)
+			}).
+			Return(nil)
+
+		ctx := context.Background()
+		err := r.reconcileOperatorGroup(ctx, addon.DeepCopy(), operatorGroup.DeepCopy(),
+			addonsv1alpha1.AdoptionPolicyPrevent)
+		require.NoError(t, err)
+		c.AssertExpectations(t)
+
+		if assert.NotNil(t, updatedOperatorGroup) {
+			assert.True(t, hasCommonLabels(updatedOperatorGroup.Labels, addon))
+			assert.Equal(t, "sre", updatedOperatorGroup.Labels["team"])
+		}
+	})
+
+	adoptionTests := []struct {
+		name           string
+		policy         addonsv1alpha1.AddonAdoptionPolicy
+		currentLabels  map[string]string
+		expectedReason string
+	}{
+		{
+			name:           "adoption prevented",
+			policy:         addonsv1alpha1.AdoptionPolicyPrevent,
+			currentLabels:  operatorGroup.Labels,
+			expectedReason: addonsv1alpha1.AddonReasonAdoptionPrevented,
+		},
+		{
+			name:           "not labelled for adoption",
+			policy:         addonsv1alpha1.AdoptionPolicyAdoptIfLabelled,
+			expectedReason: addonsv1alpha1.AddonReasonNotLabelledForAdoption,
+		},
+		{
+			name:          "adopt labelled",
+			policy:        addonsv1alpha1.AdoptionPolicyAdoptIfLabelled,
+			currentLabels: operatorGroup.Labels,
+		},
+		{
+			name:   "adopt all",
+			policy: addonsv1alpha1.AdoptionPolicyAdoptAll,
+		},
+	}
+	for _, test := range adoptionTests {
+		t.Run(test.name, func(t *testing.T) {
+			addon := addon.DeepCopy()
+			addon.Spec.Adoption = &addonsv1alpha1.AddonAdoption{
+				Overrides: []addonsv1alpha1.AddonAdoptionOverride{
+					{Kind: operatorsv1.OperatorGroupKind, Policy: test.policy},
+				},
+			}
+
+			c := testutil.NewClient()
+			r := AddonReconciler{
+				Client: c,
+				Scheme: newTestSchemeWithAddonsv1alpha1(),
+			}
+
+			c.
+				On(
+					"Get",
+					mock.Anything,
+					client.ObjectKeyFromObject(operatorGroup),
+					mock.IsType(&operatorsv1.OperatorGroup{}),
+				).
+				Run(func(args mock.Arguments) {
+					og := args.Get(2).(*operatorsv1.OperatorGroup)
+					og.Name = operatorGroup.Name
+					og.Namespace = operatorGroup.Namespace
+					og.Labels = test.currentLabels
+				}).
+				Return(nil)
+			var updatedOperatorGroup *operatorsv1.OperatorGroup
+			c.
+				On(
+					"Update",
+					mock.Anything,
+					mock.IsType(&operatorsv1.OperatorGroup{}),
+					mock.Anything,
+				).
+				Run(func(args mock.Arguments) {
+					updatedOperatorGroup = args.Get(1).(*operatorsv1.OperatorGroup)
+				}).
+				Return(nil)
+
+			ctx := context.Background()
+			err := r.reconcileOperatorGroup(ctx, addon, operatorGroup.DeepCopy(),
+				adoptionPolicy(addon, operatorsv1.OperatorGroupKind))
+
+			if len(test.expectedReason) > 0 {
+				var collision *adoptionCollisionError
+				if assert.ErrorAs(t, err, &collision) {
+					assert.Equal(t, test.expectedReason, collision.reason)
+				}
+				assert.ErrorIs(t, err, errNotOwnedByUs)
+				c.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+				assert.Empty(t, addon.Status.AdoptedObjects)
+				return
+			}
+
+			require.NoError(t, err)
+			if assert.NotNil(t, updatedOperatorGroup) {
+				assert.True(t, metav1.IsControlledBy(updatedOperatorGroup, addon))
+				assert.Equal(t, string(test.policy), updatedOperatorGroup.Annotations[adoptedAnnotation])
+			}
+			assert.Len(t, addon.Status.AdoptedObjects, 1)
+		})
+	}
+}
+
+func TestEnsureOperatorGroup_ForeignOperatorGroups(t *testing.T) {
+	foreignOperatorGroup := operatorsv1.OperatorGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foreign",
+			Namespace: "addon-1",
+		},
+	}
+
+	tests := []struct {
+		name                   string
+		policy                 addonsv1alpha1.AddonForeignOperatorGroupPolicy
+		foreignOperatorGroups  []operatorsv1.OperatorGroup
+		expectedResult         ensureOperatorGroupResult
+		expectedOperatorGroup  string
+		expectedDeletedNames   []string
+		expectedConfigErrorMsg string
+	}{
+		{
+			name:                  "report",
+			foreignOperatorGroups: []operatorsv1.OperatorGroup{foreignOperatorGroup},
+			expectedResult:        ensureOperatorGroupResultRetry,
+			expectedConfigErrorMsg: "Namespace addon-1 contains OperatorGroups not owned by this Addon: foreign, " +
+				"OLM supports only one OperatorGroup per Namespace, " +
+				"set foreignOperatorGroupPolicy to Adopt or Remove to resolve this automatically",
+		},
+		{
+			name:   "adopt more than one",
+			policy: addonsv1alpha1.ForeignOperatorGroupPolicyAdopt,
+			foreignOperatorGroups: []operatorsv1.OperatorGroup{
+				foreignOperatorGroup,
+				{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "addon-1"}},
+			},
+			expectedResult: ensureOperatorGroupResultRetry,
+			expectedConfigErrorMsg: "Namespace addon-1 contains OperatorGroups not owned by this Addon: foreign, other, " +
+				"OLM supports only one OperatorGroup per Namespace, " +
+				"only a single foreign OperatorGroup can be adopted",
+		},
+		{
+			name:                  "adopt",
+			policy:                addonsv1alpha1.ForeignOperatorGroupPolicyAdopt,
+			foreignOperatorGroups: []operatorsv1.OperatorGroup{foreignOperatorGroup},
+			expectedResult:        ensureOperatorGroupResultNil,
+			expectedOperatorGroup: "foreign",
+		},
+		{
+			name:                  "remove",
+			policy:                addonsv1alpha1.ForeignOperatorGroupPolicyRemove,
+			foreignOperatorGroups: []operatorsv1.OperatorGroup{foreignOperatorGroup},
+			expectedResult:        ensureOperatorGroupResultNil,
+			expectedOperatorGroup: "addon-1",
+			expectedDeletedNames:  []string{"foreign"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addon := newTestAddonWithCatalogSourceImage()
+			addon.Spec.Install.OLMOwnNamespace.ForeignOperatorGroupPolicy = test.policy
+
+			log := testutil.NewLogger(t)
+			c := testutil.NewClient()
+			r := AddonReconciler{
+				Client: c,
+				Scheme: newTestSchemeWithAddonsv1alpha1(),
+			}
+
+			c.
+				On(
+					"List",
+					mock.Anything,
+					mock.IsType(&operatorsv1.OperatorGroupList{}),
+					mock.Anything,
+				).
+				Run(func(args mock.Arguments) {
+					list := args.Get(1).(*operatorsv1.OperatorGroupList)
+					list.Items = test.foreignOperatorGroups
+				}).
+				Return(nil)
+			c.
+				On(
+					"Get",
+					mock.Anything,
+					client.ObjectKey{Name: "foreign", Namespace: "addon-1"},
+					mock.IsType(&operatorsv1.OperatorGroup{}),
+				).
+				Run(func(args mock.Arguments) {
+					foreignOperatorGroup.DeepCopyInto(args.Get(2).(*operatorsv1.OperatorGroup))
+				}).
+				Return(nil)
+			c.
+				On(
+					"Get",
+					mock.Anything,
+					client.ObjectKey{Name: "addon-1", Namespace: "addon-1"},
+					mock.IsType(&operatorsv1.OperatorGroup{}),
+				).
+				Return(errors.NewNotFound(schema.GroupResource{}, ""))
+			var createdOperatorGroup *operatorsv1.OperatorGroup
+			c.
+				On(
+					"Create",
+					mock.Anything,
+					mock.IsType(&operatorsv1.OperatorGroup{}),
+					mock.Anything,
+				).
+				Run(func(args mock.Arguments) {
+					createdOperatorGroup = args.Get(1).(*operatorsv1.OperatorGroup)
+				}).
+				Return(nil)
+			var updatedOperatorGroup *operatorsv1.OperatorGroup
+			c.
+				On(
+					"Update",
+					mock.Anything,
+					mock.IsType(&operatorsv1.OperatorGroup{}),
+					mock.Anything,
+				).
+				Run(func(args mock.Arguments) {
+					updatedOperatorGroup = args.Get(1).(*operatorsv1.OperatorGroup)
+				}).
+				Return(nil)
+			var deletedNames []string
+			c.
+				On(
+					"Delete",
+					mock.Anything,
+					mock.IsType(&operatorsv1.OperatorGroup{}),
+					mock.Anything,
+				).
+				Run(func(args mock.Arguments) {
+					deletedNames = append(deletedNames, args.Get(1).(*operatorsv1.OperatorGroup).Name)
+				}).
+				Return(nil)
+			c.StatusMock.
+				On(
+					"Update",
+					mock.Anything,
+					mock.IsType(&addonsv1alpha1.Addon{}),
+					mock.Anything,
+				).
+				Return(nil)
+
+			ctx := context.Background()
+			result, err := r.ensureOperatorGroup(ctx, log, addon)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedResult, result)
+			assert.Equal(t, test.expectedDeletedNames, deletedNames)
+
+			if len(test.expectedConfigErrorMsg) > 0 {
+				c.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+				c.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+
+				availableCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.Available)
+				if assert.NotNil(t, availableCond) {
+					assert.Equal(t, metav1.ConditionFalse, availableCond.Status)
+					assert.Equal(t, addonsv1alpha1.AddonReasonConfigError, availableCond.Reason)
+					assert.Equal(t, test.expectedConfigErrorMsg, availableCond.Message)
+				}
+				return
+			}
+
+			switch test.expectedOperatorGroup {
+			case "foreign":
+				c.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+				if assert.NotNil(t, updatedOperatorGroup) {
+					assert.Equal(t, "foreign", updatedOperatorGroup.Name)
+					assert.True(t, metav1.IsControlledBy(updatedOperatorGroup, addon))
+					assert.Equal(t, []string{"addon-1"}, updatedOperatorGroup.Spec.TargetNamespaces)
+				}
+				assert.Equal(t, []addonsv1alpha1.AddonObjectReference{
+					{
+						APIVersion: operatorsv1.GroupVersion.String(),
+						Kind:       operatorsv1.OperatorGroupKind,
+						Name:       "foreign",
+						Namespace:  "addon-1",
+					},
+				}, addon.Status.AdoptedObjects)
+			default:
+				if assert.NotNil(t, createdOperatorGroup) {
+					assert.Equal(t, test.expectedOperatorGroup, createdOperatorGroup.Name)
+				}
+			}
+		})
+	}
 }
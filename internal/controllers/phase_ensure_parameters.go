@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+)
+
+const (
+	parametersSecretSuffix             = "-parameters"
+	parametersRevisionAnnotation       = "addons.managed.openshift.io/parameters-revision"
+	parametersRevisionHashPrefixLength = 16
+)
+
+type ensureParametersSecretResult int
+
+const (
+	ensureParametersSecretResultNil   ensureParametersSecretResult = iota
+	ensureParametersSecretResultStop  ensureParametersSecretResult = iota
+	ensureParametersSecretResultRetry ensureParametersSecretResult = iota
+)
+
+// Ensures that the parameters of the given Addon are rendered into a Secret in the install namespace.
+// Addons without parameters have no parameters Secret, a Secret left from earlier parameters is deleted.
+func (r *AddonReconciler) ensureParametersSecret(
+	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon,
+) (ensureParametersSecretResult, error) {
+	targetNamespace, _, stop, err := r.parseAddonInstallConfig(ctx, log, addon)
+	if err != nil {
+		return ensureParametersSecretResultNil, err
+	}
+	if stop {
+		return ensureParametersSecretResultStop, nil
+	}
+
+	if len(addon.Spec.Parameters) == 0 {
+		if err := r.deleteParametersSecret(ctx, addon, targetNamespace); err != nil {
+			return ensureParametersSecretResultNil, err
+		}
+		addon.Status.ParametersRevision = ""
+		setPhaseCondition(addon, addonsv1alpha1.ParametersReady, metav1.ConditionTrue,
+			addonsv1alpha1.AddonReasonReady, "")
+		return ensureParametersSecretResultNil, nil
+	}
+
+	data, err := renderAddonParameters(addon.Spec.Parameters)
+	if err != nil {
+		return ensureParametersSecretResultStop, r.reportConfigurationError(ctx, addon,
+			fmt.Sprintf("rendering .spec.parameters: %v", err))
+	}
+	revision := parametersRevision(data)
+
+	desiredSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      parametersSecretName(addon),
+			Namespace: targetNamespace,
+			Labels:    map[string]string{},
+			Annotations: map[string]string{
+				parametersRevisionAnnotation: revision,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+	addCommonLabels(desiredSecret.Labels, addon)
+	if err := controllerutil.SetControllerReference(addon, desiredSecret, r.Scheme); err != nil {
+		return ensureParametersSecretResultNil, fmt.Errorf("setting controller reference: %w", err)
+	}
+
+	err = reconcileSecret(ctx, r.Client, r.uncachedReader(), addon, desiredSecret)
+	var collision *adoptionCollisionError
+	if errors.As(err, &collision) {
+		return ensureParametersSecretResultRetry,
+			r.reportAdoptionCollision(ctx, addon, addonsv1alpha1.ParametersReady, collision)
+	}
+	if err != nil {
+		return ensureParametersSecretResultNil, fmt.Errorf("reconciling parameters Secret: %w", err)
+	}
+
+	addon.Status.ParametersRevision = revision
+	setPhaseCondition(addon, addonsv1alpha1.ParametersReady, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+	return ensureParametersSecretResultNil, nil
+}
+
+// Deletes the parameters Secret of the given Addon, if it exists and is controlled by the Addon.
+func (r *AddonReconciler) deleteParametersSecret(
+	ctx context.Context, addon *addonsv1alpha1.Addon, namespace string) error {
+	secret := &metav1.PartialObjectMetadata{}
+	secret.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+	err := r.Get(ctx, client.ObjectKey{
+		Name:      parametersSecretName(addon),
+		Namespace: namespace,
+	}, secret)
+	if k8sApiErrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("getting parameters Secret: %w", err)
+	}
+	if !metav1.IsControlledBy(secret, addon) {
+		return nil
+	}
+
+	if err := r.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("deleting parameters Secret: %w", err)
+	}
+	return nil
+}
+
+// Name of the Secret holding the parameters of the given Addon.
+func parametersSecretName(addon *addonsv1alpha1.Addon) string {
+	return addon.Name + parametersSecretSuffix
+}
+
+// Renders Addon parameters into Secret data.
+// String values are stored as is, all other values are stored as JSON.
+func renderAddonParameters(
+	parameters map[string]apiextensionsv1.JSON) (map[string][]byte, error) {
+	data := map[string][]byte{}
+	for name, value := range parameters {
+		var str string
+		if err := json.Unmarshal(value.Raw, &str); err == nil {
+			data[name] = []byte(str)
+			continue
+		}
+
+		if !json.Valid(value.Raw) {
+			return nil, fmt.Errorf("parameter %q is not valid JSON", name)
+		}
+		data[name] = value.Raw
+	}
+	return data, nil
+}
+
+// Computes a stable revision identifier from rendered parameter data.
+func parametersRevision(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, key := range keys {
+		// write length prefixes so that key/value boundaries are unambiguous
+		fmt.Fprintf(h, "%d:%s%d:", len(key), key, len(data[key]))
+		h.Write(data[key])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:parametersRevisionHashPrefixLength]
+}
+
+// Reconciles the Data and OwnerReferences of the given Secret
+// by creating or updating the Secret if needed.
+// Labels and Annotations of the given Secret are merged into the existing ones,
+// so entries added by others are kept.
+// Existing Secrets are only adopted as allowed by the adoption policy of the Addon.
+// The current Secret is read from the given reader, as Secrets are only cached by metadata.
+func reconcileSecret(
+	ctx context.Context, c client.Client, reader client.Reader,
+	addon *addonsv1alpha1.Addon, secret *corev1.Secret) error {
+	currentSecret := &corev1.Secret{}
+	err := reader.Get(ctx, client.ObjectKeyFromObject(secret), currentSecret)
+	if k8sApiErrors.IsNotFound(err) {
+		return c.Create(ctx, secret)
+	}
+	if err != nil {
+		return fmt.Errorf("getting Secret: %w", err)
+	}
+
+	if err := adoptObject(addon, corev1.SchemeGroupVersion.WithKind("Secret"),
+		currentSecret, secret); err != nil {
+		return err
+	}
+
+	changed := mergeStringMap(&currentSecret.Labels, secret.Labels)
+	if mergeStringMap(&currentSecret.Annotations, secret.Annotations) {
+		changed = true
+	}
+	if !equality.Semantic.DeepEqual(currentSecret.Data, secret.Data) ||
+		!equality.Semantic.DeepEqual(currentSecret.OwnerReferences, secret.OwnerReferences) {
+		currentSecret.Data = secret.Data
+		currentSecret.OwnerReferences = secret.OwnerReferences
+		changed = true
+	}
+	if changed {
+		return c.Update(ctx, currentSecret)
+	}
+	return nil
+}
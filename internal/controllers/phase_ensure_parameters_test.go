@@ -0,0 +1,286 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/testutil"
+)
+
+func TestRenderAddonParameters(t *testing.T) {
+	data, err := renderAddonParameters(map[string]apiextensionsv1.JSON{
+		"email":    {Raw: []byte(`"sre@example.com"`)},
+		"replicas": {Raw: []byte(`3`)},
+		"enabled":  {Raw: []byte(`true`)},
+		"sizes":    {Raw: []byte(`{"small":1}`)},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]byte{
+		"email":    []byte("sre@example.com"),
+		"replicas": []byte("3"),
+		"enabled":  []byte("true"),
+		"sizes":    []byte(`{"small":1}`),
+	}, data)
+}
+
+func TestRenderAddonParameters_InvalidJSON(t *testing.T) {
+	_, err := renderAddonParameters(map[string]apiextensionsv1.JSON{
+		"broken": {Raw: []byte(`{`)},
+	})
+	assert.Error(t, err)
+}
+
+func TestParametersRevision(t *testing.T) {
+	a := parametersRevision(map[string][]byte{"a": []byte("1"), "b": []byte("2")})
+	b := parametersRevision(map[string][]byte{"b": []byte("2"), "a": []byte("1")})
+	c := parametersRevision(map[string][]byte{"a": []byte("12")})
+
+	assert.Equal(t, a, b, "revision must not depend on map order")
+	assert.NotEqual(t, a, c)
+	assert.Len(t, a, parametersRevisionHashPrefixLength)
+}
+
+func TestEnsureParametersSecret_Create(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	addon.Spec.Parameters = map[string]apiextensionsv1.JSON{
+		"email": {Raw: []byte(`"sre@example.com"`)},
+	}
+
+	c := testutil.NewClient()
+	c.On("Get",
+		mock.Anything,
+		client.ObjectKey{
+			Name:      "addon-1-parameters",
+			Namespace: addon.Spec.Install.OLMOwnNamespace.Namespace,
+		},
+		mock.IsType(&corev1.Secret{}),
+	).Return(newTestErrNotFound())
+	var createdSecret *corev1.Secret
+	c.On("Create",
+		mock.Anything,
+		mock.IsType(&corev1.Secret{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		createdSecret = args.Get(1).(*corev1.Secret)
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	ctx := context.Background()
+	result, err := r.ensureParametersSecret(ctx, testutil.NewLogger(t), addon)
+	require.NoError(t, err)
+	assert.Equal(t, ensureParametersSecretResultNil, result)
+	c.AssertExpectations(t)
+
+	if assert.NotNil(t, createdSecret) {
+		assert.Equal(t, []byte("sre@example.com"), createdSecret.Data["email"])
+		assert.Equal(t, commonManagedByValue, createdSecret.Labels[commonManagedByLabel])
+		assert.Equal(t, addon.Status.ParametersRevision,
+			createdSecret.Annotations[parametersRevisionAnnotation])
+		assert.Len(t, createdSecret.OwnerReferences, 1)
+	}
+	assert.NotEmpty(t, addon.Status.ParametersRevision)
+}
+
+func TestEnsureParametersSecret_Update(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	addon.Spec.Parameters = map[string]apiextensionsv1.JSON{
+		"email": {Raw: []byte(`"new@example.com"`)},
+	}
+
+	c := testutil.NewClient()
+	c.On("Get",
+		mock.Anything,
+		mock.Anything,
+		mock.IsType(&corev1.Secret{}),
+	).Run(func(args mock.Arguments) {
+		secret := args.Get(2).(*corev1.Secret)
+		secret.Data = map[string][]byte{"email": []byte("old@example.com")}
+		require.NoError(t, controllerutil.SetControllerReference(
+			addon, secret, newTestSchemeWithAddonsv1alpha1()))
+	}).Return(nil)
+	var updatedSecret *corev1.Secret
+	c.On("Update",
+		mock.Anything,
+		mock.IsType(&corev1.Secret{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		updatedSecret = args.Get(1).(*corev1.Secret)
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	ctx := context.Background()
+	result, err := r.ensureParametersSecret(ctx, testutil.NewLogger(t), addon)
+	require.NoError(t, err)
+	assert.Equal(t, ensureParametersSecretResultNil, result)
+	c.AssertExpectations(t)
+
+	if assert.NotNil(t, updatedSecret) {
+		assert.Equal(t, []byte("new@example.com"), updatedSecret.Data["email"])
+	}
+}
+
+func TestEnsureParametersSecret_Collision(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	addon.Spec.Parameters = map[string]apiextensionsv1.JSON{
+		"email": {Raw: []byte(`"sre@example.com"`)},
+	}
+
+	c := testutil.NewClient()
+	c.On("Get",
+		mock.Anything,
+		mock.Anything,
+		mock.IsType(&corev1.Secret{}),
+	).Run(func(args mock.Arguments) {
+		secret := args.Get(2).(*corev1.Secret)
+		secret.Name = "addon-1-parameters"
+		secret.Namespace = addon.Spec.Install.OLMOwnNamespace.Namespace
+	}).Return(nil)
+	c.StatusMock.On("Update",
+		mock.Anything,
+		testutil.IsAddonsv1alpha1AddonPtr,
+		mock.Anything,
+	).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	ctx := context.Background()
+	result, err := r.ensureParametersSecret(ctx, testutil.NewLogger(t), addon)
+	require.NoError(t, err)
+	assert.Equal(t, ensureParametersSecretResultRetry, result)
+	c.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+
+	parametersCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.ParametersReady)
+	if assert.NotNil(t, parametersCond) {
+		assert.Equal(t, metav1.ConditionFalse, parametersCond.Status)
+		assert.Equal(t, addonsv1alpha1.AddonReasonAdoptionPrevented, parametersCond.Reason)
+		assert.Equal(t,
+			"Secret addon-1/addon-1-parameters already exists and is not controlled by this Addon, "+
+				"adoption policy Prevent prevents adopting it",
+			parametersCond.Message)
+	}
+	assert.True(t, meta.IsStatusConditionFalse(addon.Status.Conditions, addonsv1alpha1.Available))
+}
+
+func TestEnsureParametersSecret_NoParameters(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	addon.Status.ParametersRevision = "0123456789abcdef"
+
+	c := testutil.NewClient()
+	c.On("Get",
+		mock.Anything,
+		client.ObjectKey{
+			Name:      "addon-1-parameters",
+			Namespace: addon.Spec.Install.OLMOwnNamespace.Namespace,
+		},
+		mock.IsType(&metav1.PartialObjectMetadata{}),
+	).Run(func(args mock.Arguments) {
+		secret := args.Get(2).(*metav1.PartialObjectMetadata)
+		secret.Name = "addon-1-parameters"
+		secret.Namespace = addon.Spec.Install.OLMOwnNamespace.Namespace
+		require.NoError(t, controllerutil.SetControllerReference(
+			addon, secret, newTestSchemeWithAddonsv1alpha1()))
+	}).Return(nil)
+	var deletedSecret *metav1.PartialObjectMetadata
+	c.On("Delete",
+		mock.Anything,
+		mock.IsType(&metav1.PartialObjectMetadata{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		deletedSecret = args.Get(1).(*metav1.PartialObjectMetadata)
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	ctx := context.Background()
+	result, err := r.ensureParametersSecret(ctx, testutil.NewLogger(t), addon)
+	require.NoError(t, err)
+	assert.Equal(t, ensureParametersSecretResultNil, result)
+	c.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+
+	if assert.NotNil(t, deletedSecret) {
+		assert.Equal(t, "addon-1-parameters", deletedSecret.Name)
+	}
+	assert.Empty(t, addon.Status.ParametersRevision)
+	assert.True(t, meta.IsStatusConditionTrue(addon.Status.Conditions, addonsv1alpha1.ParametersReady))
+}
+
+func TestReconcileSecret_KeepsForeignMetadata(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	desiredSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "addon-1-parameters",
+			Namespace:   "addon-1",
+			Labels:      map[string]string{},
+			Annotations: map[string]string{parametersRevisionAnnotation: "new"},
+		},
+		Data: map[string][]byte{"email": []byte("sre@example.com")},
+	}
+	addCommonLabels(desiredSecret.Labels, addon)
+	require.NoError(t, controllerutil.SetControllerReference(
+		addon, desiredSecret, newTestSchemeWithAddonsv1alpha1()))
+
+	c := testutil.NewClient()
+	c.On("Get",
+		mock.Anything,
+		client.ObjectKeyFromObject(desiredSecret),
+		mock.IsType(&corev1.Secret{}),
+	).Run(func(args mock.Arguments) {
+		secret := args.Get(2).(*corev1.Secret)
+		desiredSecret.DeepCopyInto(secret)
+		secret.Labels["backup.example.com/include"] = "true"
+		secret.Annotations = map[string]string{
+			parametersRevisionAnnotation: "old",
+			"reflector.example.com/sync": "true",
+		}
+	}).Return(nil)
+	var updatedSecret *corev1.Secret
+	c.On("Update",
+		mock.Anything,
+		mock.IsType(&corev1.Secret{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		updatedSecret = args.Get(1).(*corev1.Secret)
+	}).Return(nil)
+
+	err := reconcileSecret(context.Background(), c, c, addon, desiredSecret.DeepCopy())
+	require.NoError(t, err)
+
+	if assert.NotNil(t, updatedSecret) {
+		assert.Equal(t, "true", updatedSecret.Labels["backup.example.com/include"])
+		assert.Equal(t, commonManagedByValue, updatedSecret.Labels[commonManagedByLabel])
+		assert.Equal(t, map[string]string{
+			parametersRevisionAnnotation: "new",
+			"reflector.example.com/sync": "true",
+		}, updatedSecret.Annotations)
+	}
+}
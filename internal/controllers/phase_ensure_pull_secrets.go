@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+)
+
+const (
+	pullSecretInfix           = "-pull-"
+	pullSecretLabel           = "addons.managed.openshift.io/pull-secret"
+	defaultServiceAccountName = "default"
+)
+
+type ensurePullSecretsResult int
+
+const (
+	ensurePullSecretsResultNil   ensurePullSecretsResult = iota
+	ensurePullSecretsResultStop  ensurePullSecretsResult = iota
+	ensurePullSecretsResultRetry ensurePullSecretsResult = iota
+)
+
+// Ensures that the pull Secrets of the given Addon are copied from the operator namespace
+// into the install namespace and attached to the default ServiceAccount of the install namespace.
+// Copies of pull Secrets that were removed from the Addon are deleted again.
+func (r *AddonReconciler) ensurePullSecrets(
+	ctx context.Context, log logr.Logger, addon *addonsv1alpha1.Addon,
+) (ensurePullSecretsResult, error) {
+	targetNamespace, _, stop, err := r.parseAddonInstallConfig(ctx, log, addon)
+	if err != nil {
+		return ensurePullSecretsResultNil, err
+	}
+	if stop {
+		return ensurePullSecretsResultStop, nil
+	}
+
+	var wantedSecretNames []string
+	for _, pullSecret := range addon.Spec.PullSecrets {
+		// Secrets are only cached by metadata.
+		sourceSecret := &corev1.Secret{}
+		err := r.uncachedReader().Get(ctx, client.ObjectKey{
+			Name:      pullSecret.Name,
+			Namespace: r.OperatorNamespace,
+		}, sourceSecret)
+		if k8sApiErrors.IsNotFound(err) {
+			// the Addon is requeued when the Secret is created, see enqueueAddonsForPullSecret
+			return ensurePullSecretsResultStop, r.reportConfigurationError(ctx, addon,
+				fmt.Sprintf("pull Secret %s/%s not found", r.OperatorNamespace, pullSecret.Name))
+		}
+		if err != nil {
+			return ensurePullSecretsResultNil, fmt.Errorf("getting pull Secret: %w", err)
+		}
+
+		desiredSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pullSecretName(addon, pullSecret.Name),
+				Namespace: targetNamespace,
+				Labels: map[string]string{
+					pullSecretLabel: "true",
+				},
+			},
+			Type: sourceSecret.Type,
+			Data: sourceSecret.Data,
+		}
+		addCommonLabels(desiredSecret.Labels, addon)
+		if err := controllerutil.SetControllerReference(addon, desiredSecret, r.Scheme); err != nil {
+			return ensurePullSecretsResultNil, fmt.Errorf("setting controller reference: %w", err)
+		}
+
+		err = reconcileSecret(ctx, r.Client, r.uncachedReader(), addon, desiredSecret)
+		var collision *adoptionCollisionError
+		if errors.As(err, &collision) {
+			return ensurePullSecretsResultRetry,
+				r.reportAdoptionCollision(ctx, addon, addonsv1alpha1.PullSecretsReady, collision)
+		}
+		if err != nil {
+			return ensurePullSecretsResultNil, fmt.Errorf("reconciling pull Secret: %w", err)
+		}
+		wantedSecretNames = append(wantedSecretNames, desiredSecret.Name)
+	}
+
+	unwantedSecretNames, err := r.deleteUnwantedPullSecrets(
+		ctx, addon, targetNamespace, wantedSecretNames)
+	if err != nil {
+		return ensurePullSecretsResultNil, err
+	}
+	if len(wantedSecretNames) == 0 && len(unwantedSecretNames) == 0 {
+		setPhaseCondition(addon, addonsv1alpha1.PullSecretsReady, metav1.ConditionTrue,
+			addonsv1alpha1.AddonReasonReady, "")
+		return ensurePullSecretsResultNil, nil
+	}
+
+	// ServiceAccounts are only cached by metadata.
+	serviceAccount := &corev1.ServiceAccount{}
+	err = r.uncachedReader().Get(ctx, client.ObjectKey{
+		Name:      defaultServiceAccountName,
+		Namespace: targetNamespace,
+	}, serviceAccount)
+	if k8sApiErrors.IsNotFound(err) {
+		// created asynchronously by kube-controller-manager for new Namespaces
+		log.Info("requeue", "reason", "default ServiceAccount not yet created")
+		return ensurePullSecretsResultRetry, nil
+	}
+	if err != nil {
+		return ensurePullSecretsResultNil, fmt.Errorf("getting default ServiceAccount: %w", err)
+	}
+
+	if updateImagePullSecrets(serviceAccount, wantedSecretNames, unwantedSecretNames) {
+		if err := r.Update(ctx, serviceAccount); err != nil {
+			return ensurePullSecretsResultNil, fmt.Errorf("updating default ServiceAccount: %w", err)
+		}
+	}
+	setPhaseCondition(addon, addonsv1alpha1.PullSecretsReady, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+	return ensurePullSecretsResultNil, nil
+}
+
+// Deletes copies of pull Secrets in the given namespace that are no longer wanted
+// and returns their names.
+func (r *AddonReconciler) deleteUnwantedPullSecrets(
+	ctx context.Context, addon *addonsv1alpha1.Addon,
+	namespace string, wantedSecretNames []string,
+) (map[string]bool, error) {
+	wanted := map[string]bool{}
+	for _, name := range wantedSecretNames {
+		wanted[name] = true
+	}
+
+	secretList := &metav1.PartialObjectMetadataList{}
+	secretList.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("SecretList"))
+	if err := r.List(ctx, secretList,
+		client.InNamespace(namespace),
+		client.MatchingLabels{
+			commonManagedByLabel: commonManagedByValue,
+			commonInstanceLabel:  addon.Name,
+			pullSecretLabel:      "true",
+		}); err != nil {
+		return nil, fmt.Errorf("listing pull Secrets: %w", err)
+	}
+
+	unwantedSecretNames := map[string]bool{}
+	for i := range secretList.Items {
+		secret := &secretList.Items[i]
+		if wanted[secret.Name] || !metav1.IsControlledBy(secret, addon) {
+			continue
+		}
+		if err := r.Delete(ctx, secret); err != nil && !k8sApiErrors.IsNotFound(err) {
+			return nil, fmt.Errorf("deleting unwanted pull Secret: %w", err)
+		}
+		unwantedSecretNames[secret.Name] = true
+	}
+	return unwantedSecretNames, nil
+}
+
+// Adds the wanted and removes the unwanted Secrets from the image pull Secrets of the given ServiceAccount,
+// leaving all other references untouched.
+// Returns true if the ServiceAccount was changed.
+func updateImagePullSecrets(
+	serviceAccount *corev1.ServiceAccount, wanted []string, unwanted map[string]bool) (changed bool) {
+	present := map[string]bool{}
+	imagePullSecrets := make([]corev1.LocalObjectReference, 0, len(serviceAccount.ImagePullSecrets))
+	for _, ref := range serviceAccount.ImagePullSecrets {
+		if unwanted[ref.Name] {
+			changed = true
+			continue
+		}
+		present[ref.Name] = true
+		imagePullSecrets = append(imagePullSecrets, ref)
+	}
+	for _, name := range wanted {
+		if present[name] {
+			continue
+		}
+		changed = true
+		imagePullSecrets = append(imagePullSecrets, corev1.LocalObjectReference{Name: name})
+	}
+
+	if changed {
+		serviceAccount.ImagePullSecrets = imagePullSecrets
+	}
+	return changed
+}
+
+// Name of the copy of the given pull Secret in the install namespace of the Addon.
+func pullSecretName(addon *addonsv1alpha1.Addon, secretName string) string {
+	return addon.Name + pullSecretInfix + secretName
+}
+
+// Names of the copies of all pull Secrets of the Addon in its install namespace,
+// or nil if the Addon has no pull Secrets.
+func pullSecretNames(addon *addonsv1alpha1.Addon) []string {
+	var names []string
+	for _, pullSecret := range addon.Spec.PullSecrets {
+		names = append(names, pullSecretName(addon, pullSecret.Name))
+	}
+	return names
+}
+
+// Filters events for pull Secrets, which are only read from the operator namespace.
+func (r *AddonReconciler) isInOperatorNamespace(obj client.Object) bool {
+	return obj.GetNamespace() == r.OperatorNamespace
+}
+
+// Maps Secrets in the operator namespace to the Addons using them as pull Secret,
+// so copies are updated when the Secret is rotated.
+// Events for Secrets in other namespaces are filtered by isInOperatorNamespace.
+func (r *AddonReconciler) enqueueAddonsForPullSecret(obj client.Object) []reconcile.Request {
+	addonList := &addonsv1alpha1.AddonList{}
+	if err := r.List(context.Background(), addonList); err != nil {
+		r.Log.Error(err, "listing Addons to enqueue for pull Secret",
+			"secret", client.ObjectKeyFromObject(obj).String())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, addon := range addonList.Items {
+		for _, pullSecret := range addon.Spec.PullSecrets {
+			if pullSecret.Name != obj.GetName() {
+				continue
+			}
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: addon.Name},
+			})
+			break
+		}
+	}
+	return requests
+}
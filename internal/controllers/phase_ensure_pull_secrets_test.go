@@ -0,0 +1,308 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/testutil"
+)
+
+func TestEnsurePullSecrets(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	addon.Spec.PullSecrets = []addonsv1alpha1.AddonPullSecret{
+		{Name: "quay"},
+	}
+
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "quay", Namespace: "addon-operator"},
+		mock.IsType(&corev1.Secret{}),
+	).Run(func(args mock.Arguments) {
+		secret := args.Get(2).(*corev1.Secret)
+		secret.Type = corev1.SecretTypeDockerConfigJson
+		secret.Data = map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(`{"auths":{}}`),
+		}
+	}).Return(nil)
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "addon-1-pull-quay", Namespace: "addon-1"},
+		mock.IsType(&corev1.Secret{}),
+	).Return(newTestErrNotFound())
+	var createdSecret *corev1.Secret
+	c.On("Create",
+		testutil.IsContext,
+		mock.IsType(&corev1.Secret{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		createdSecret = args.Get(1).(*corev1.Secret)
+	}).Return(nil)
+	c.On("List",
+		testutil.IsContext,
+		mock.IsType(&metav1.PartialObjectMetadataList{}),
+		mock.Anything,
+	).Return(nil)
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "default", Namespace: "addon-1"},
+		mock.IsType(&corev1.ServiceAccount{}),
+	).Run(func(args mock.Arguments) {
+		sa := args.Get(2).(*corev1.ServiceAccount)
+		sa.ImagePullSecrets = []corev1.LocalObjectReference{
+			{Name: "default-dockercfg-abc"},
+		}
+	}).Return(nil)
+	var updatedServiceAccount *corev1.ServiceAccount
+	c.On("Update",
+		testutil.IsContext,
+		mock.IsType(&corev1.ServiceAccount{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		updatedServiceAccount = args.Get(1).(*corev1.ServiceAccount)
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client:            c,
+		Log:               testutil.NewLogger(t),
+		Scheme:            newTestSchemeWithAddonsv1alpha1(),
+		OperatorNamespace: "addon-operator",
+	}
+
+	ctx := context.Background()
+	result, err := r.ensurePullSecrets(ctx, testutil.NewLogger(t), addon)
+	require.NoError(t, err)
+	assert.Equal(t, ensurePullSecretsResultNil, result)
+	c.AssertExpectations(t)
+	assert.True(t, meta.IsStatusConditionTrue(addon.Status.Conditions, addonsv1alpha1.PullSecretsReady))
+
+	if assert.NotNil(t, createdSecret) {
+		assert.Equal(t, corev1.SecretTypeDockerConfigJson, createdSecret.Type)
+		assert.Equal(t, []byte(`{"auths":{}}`), createdSecret.Data[corev1.DockerConfigJsonKey])
+		assert.Equal(t, "true", createdSecret.Labels[pullSecretLabel])
+		assert.True(t, metav1.IsControlledBy(createdSecret, addon))
+	}
+	if assert.NotNil(t, updatedServiceAccount) {
+		assert.Equal(t, []corev1.LocalObjectReference{
+			{Name: "default-dockercfg-abc"},
+			{Name: "addon-1-pull-quay"},
+		}, updatedServiceAccount.ImagePullSecrets)
+	}
+}
+
+func TestEnsurePullSecrets_Collision(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	addon.Spec.PullSecrets = []addonsv1alpha1.AddonPullSecret{
+		{Name: "quay"},
+	}
+
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "quay", Namespace: "addon-operator"},
+		mock.IsType(&corev1.Secret{}),
+	).Return(nil)
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "addon-1-pull-quay", Namespace: "addon-1"},
+		mock.IsType(&corev1.Secret{}),
+	).Run(func(args mock.Arguments) {
+		secret := args.Get(2).(*corev1.Secret)
+		secret.Name = "addon-1-pull-quay"
+		secret.Namespace = "addon-1"
+	}).Return(nil)
+	c.StatusMock.On("Update",
+		testutil.IsContext,
+		testutil.IsAddonsv1alpha1AddonPtr,
+		mock.Anything,
+	).Return(nil)
+
+	r := &AddonReconciler{
+		Client:            c,
+		Log:               testutil.NewLogger(t),
+		Scheme:            newTestSchemeWithAddonsv1alpha1(),
+		OperatorNamespace: "addon-operator",
+	}
+
+	ctx := context.Background()
+	result, err := r.ensurePullSecrets(ctx, testutil.NewLogger(t), addon)
+	require.NoError(t, err)
+	assert.Equal(t, ensurePullSecretsResultRetry, result)
+	c.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+
+	pullSecretsCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.PullSecretsReady)
+	if assert.NotNil(t, pullSecretsCond) {
+		assert.Equal(t, metav1.ConditionFalse, pullSecretsCond.Status)
+		assert.Equal(t, addonsv1alpha1.AddonReasonAdoptionPrevented, pullSecretsCond.Reason)
+	}
+	assert.True(t, meta.IsStatusConditionFalse(addon.Status.Conditions, addonsv1alpha1.Available))
+}
+
+func TestEnsurePullSecrets_SourceNotFound(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	addon.Spec.PullSecrets = []addonsv1alpha1.AddonPullSecret{
+		{Name: "quay"},
+	}
+
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		testutil.IsObjectKey,
+		mock.IsType(&corev1.Secret{}),
+	).Return(newTestErrNotFound())
+	c.StatusMock.On("Update",
+		testutil.IsContext,
+		testutil.IsAddonsv1alpha1AddonPtr,
+		mock.Anything,
+	).Return(nil)
+
+	r := &AddonReconciler{
+		Client:            c,
+		Log:               testutil.NewLogger(t),
+		Scheme:            newTestSchemeWithAddonsv1alpha1(),
+		OperatorNamespace: "addon-operator",
+	}
+
+	ctx := context.Background()
+	result, err := r.ensurePullSecrets(ctx, testutil.NewLogger(t), addon)
+	require.NoError(t, err)
+	assert.Equal(t, ensurePullSecretsResultStop, result)
+
+	availableCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.Available)
+	if assert.NotNil(t, availableCond) {
+		assert.Equal(t, addonsv1alpha1.AddonReasonConfigError, availableCond.Reason)
+	}
+}
+
+func TestEnsurePullSecrets_RemovesUnwanted(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+
+	staleSecret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "addon-1-pull-quay",
+			Namespace: "addon-1",
+		},
+	}
+	require.NoError(t, controllerutil.SetControllerReference(
+		addon, &staleSecret, newTestSchemeWithAddonsv1alpha1()))
+
+	c := testutil.NewClient()
+	c.On("List",
+		testutil.IsContext,
+		mock.IsType(&metav1.PartialObjectMetadataList{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		list := args.Get(1).(*metav1.PartialObjectMetadataList)
+		list.Items = []metav1.PartialObjectMetadata{{ObjectMeta: staleSecret.ObjectMeta}}
+	}).Return(nil)
+	c.On("Delete",
+		testutil.IsContext,
+		mock.IsType(&metav1.PartialObjectMetadata{}),
+		mock.Anything,
+	).Return(nil)
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "default", Namespace: "addon-1"},
+		mock.IsType(&corev1.ServiceAccount{}),
+	).Run(func(args mock.Arguments) {
+		sa := args.Get(2).(*corev1.ServiceAccount)
+		sa.ImagePullSecrets = []corev1.LocalObjectReference{
+			{Name: "default-dockercfg-abc"},
+			{Name: "addon-1-pull-quay"},
+		}
+	}).Return(nil)
+	var updatedServiceAccount *corev1.ServiceAccount
+	c.On("Update",
+		testutil.IsContext,
+		mock.IsType(&corev1.ServiceAccount{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		updatedServiceAccount = args.Get(1).(*corev1.ServiceAccount)
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client:            c,
+		Log:               testutil.NewLogger(t),
+		Scheme:            newTestSchemeWithAddonsv1alpha1(),
+		OperatorNamespace: "addon-operator",
+	}
+
+	ctx := context.Background()
+	result, err := r.ensurePullSecrets(ctx, testutil.NewLogger(t), addon)
+	require.NoError(t, err)
+	assert.Equal(t, ensurePullSecretsResultNil, result)
+	c.AssertExpectations(t)
+
+	if assert.NotNil(t, updatedServiceAccount) {
+		assert.Equal(t, []corev1.LocalObjectReference{
+			{Name: "default-dockercfg-abc"},
+		}, updatedServiceAccount.ImagePullSecrets)
+	}
+}
+
+func TestUpdateImagePullSecrets(t *testing.T) {
+	sa := &corev1.ServiceAccount{
+		ImagePullSecrets: []corev1.LocalObjectReference{
+			{Name: "default-dockercfg-abc"},
+			{Name: "addon-1-pull-quay"},
+		},
+	}
+	assert.False(t, updateImagePullSecrets(sa, []string{"addon-1-pull-quay"}, nil))
+	assert.True(t, updateImagePullSecrets(sa, []string{"addon-1-pull-redhat"},
+		map[string]bool{"addon-1-pull-quay": true}))
+	assert.Equal(t, []corev1.LocalObjectReference{
+		{Name: "default-dockercfg-abc"},
+		{Name: "addon-1-pull-redhat"},
+	}, sa.ImagePullSecrets)
+}
+
+func TestEnqueueAddonsForPullSecret(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("List",
+		mock.Anything,
+		mock.IsType(&addonsv1alpha1.AddonList{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		list := args.Get(1).(*addonsv1alpha1.AddonList)
+		list.Items = []addonsv1alpha1.Addon{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "addon-1"},
+				Spec: addonsv1alpha1.AddonSpec{
+					PullSecrets: []addonsv1alpha1.AddonPullSecret{{Name: "quay"}},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "addon-2"},
+			},
+		}
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client:            c,
+		Log:               testutil.NewLogger(t),
+		OperatorNamespace: "addon-operator",
+	}
+
+	secret := &corev1.Secret{}
+	secret.Name = "quay"
+	secret.Namespace = "addon-operator"
+	requests := r.enqueueAddonsForPullSecret(secret)
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, "addon-1", requests[0].Name)
+	}
+
+	assert.True(t, r.isInOperatorNamespace(secret))
+	// Secrets in other namespaces are filtered
+	secret.Namespace = "addon-1"
+	assert.False(t, r.isInOperatorNamespace(secret))
+}
@@ -2,10 +2,13 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -15,88 +18,270 @@ import (
 	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
 )
 
+// Marks Subscriptions whose InstallPlanApproval is set to Manual by the operator,
+// so it can be reset to Automatic when the upgrade policy and maintenance windows are removed.
+const installPlanApprovalManagedAnnotation = "addons.managed.openshift.io/install-plan-approval-managed"
+
+// Ensures a Subscription for every OLM package of the Addon,
+// using the CatalogSource of the package at the same index in catalogSources.
+// Returns the keys of the ClusterServiceVersions to observe, in the same order.
 func (r *AddonReconciler) ensureSubscription(
 	ctx context.Context,
 	log logr.Logger,
 	addon *addonsv1alpha1.Addon,
-	catalogSource *operatorsv1alpha1.CatalogSource,
+	catalogSources []*operatorsv1alpha1.CatalogSource,
 ) (
-	currentCSVKey client.ObjectKey,
+	currentCSVKeys []client.ObjectKey,
 	requeue bool,
 	err error,
 ) {
-	var commonInstallOptions addonsv1alpha1.AddonInstallOLMCommon
-	switch addon.Spec.Install.Type {
-	case addonsv1alpha1.OLMAllNamespaces:
-		commonInstallOptions = addon.Spec.Install.
-			OLMAllNamespaces.AddonInstallOLMCommon
-	case addonsv1alpha1.OLMOwnNamespace:
-		commonInstallOptions = addon.Spec.Install.
-			OLMOwnNamespace.AddonInstallOLMCommon
-	}
-
-	desiredSubscription := &operatorsv1alpha1.Subscription{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      addon.Name,
-			Namespace: commonInstallOptions.Namespace,
-		},
-		Spec: &operatorsv1alpha1.SubscriptionSpec{
-			CatalogSource:          catalogSource.Name,
-			CatalogSourceNamespace: catalogSource.Namespace,
-			Channel:                commonInstallOptions.Channel,
-			Package:                commonInstallOptions.PackageName,
-			// InstallPlanApproval is deliberately unmanaged
-			// API default is `Automatic`
-			// Legacy behavior of existing managed-tenants tooling is:
-			// All addons initially have to be installed with `Automatic`
-			// so that the very first InstallPlan succeedes
-			// but some addons want to take control of upgrades and thus
-			// change the Subscription.Spec.InstallPlanApproval value to `Manual`
-			// ATTENTION: When reconciling the subscription, we need to
-			// make sure to keep the current value of this field
-		},
-	}
-	addCommonLabels(desiredSubscription.Labels, addon)
-	if err := controllerutil.SetControllerReference(addon, desiredSubscription, r.Scheme); err != nil {
-		return client.ObjectKey{}, false, fmt.Errorf("setting controller reference: %w", err)
-	}
-
-	observedSubscription, err := r.reconcileSubscription(
-		ctx, desiredSubscription)
+	commonInstallOptions := getCommonInstallOptions(addon)
+
+	maintenanceWindows, err := r.getMaintenanceWindows(ctx, addon)
 	if err != nil {
-		return client.ObjectKey{}, false, fmt.Errorf("reconciling Subscription: %w", err)
+		return nil, false, fmt.Errorf("getting maintenance windows: %w", err)
+	}
+	if _, err := nextMaintenanceWindow(maintenanceWindows, time.Now().UTC()); err != nil {
+		// Addon maintenance windows are validated by the webhook,
+		// so this is an invalid AddonOperator default.
+		// The Addon is requeued when the AddonOperator changes, see enqueueAddonsForAddonOperator.
+		log.Info("requeue", "reason", "invalid maintenance windows")
+		setPhaseCondition(addon, addonsv1alpha1.SubscriptionReady, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonConfigError,
+			fmt.Sprintf("invalid maintenance windows: %v", err))
+		return nil, true, r.reportPhaseStatus(ctx, addon)
+	}
+	upgradePolicy := commonInstallOptions.UpgradePolicy
+	if upgradePolicy == nil && len(maintenanceWindows) > 0 {
+		// InstallPlans have to be approved by us to hold them until a maintenance window opens.
+		upgradePolicy = &addonsv1alpha1.AddonUpgradePolicy{
+			Type: addonsv1alpha1.UpgradePolicyAutomatic,
+		}
 	}
 
-	if len(observedSubscription.Status.InstalledCSV) == 0 ||
-		len(observedSubscription.Status.CurrentCSV) == 0 {
-		log.Info("requeue", "reason", "csv not linked in subscription")
-		return client.ObjectKey{}, true, nil
+	packages := getOLMPackages(addon)
+	if len(catalogSources) != len(packages) {
+		return nil, false, fmt.Errorf(
+			"expected %d CatalogSources, got %d", len(packages), len(catalogSources))
 	}
+	observedSubscriptions := make([]*operatorsv1alpha1.Subscription, 0, len(packages))
+	for i, pkg := range packages {
+		desiredSubscription := &operatorsv1alpha1.Subscription{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pkg.name,
+				Namespace: commonInstallOptions.Namespace,
+				Labels:    map[string]string{},
+			},
+			Spec: &operatorsv1alpha1.SubscriptionSpec{
+				CatalogSource:          catalogSources[i].Name,
+				CatalogSourceNamespace: catalogSources[i].Namespace,
+				Channel:                pkg.channel,
+				Package:                pkg.packageName,
+				StartingCSV:            pkg.startingCSV,
+				Config:                 newSubscriptionConfig(pkg.config),
+				// InstallPlanApproval is unmanaged, unless an upgrade policy
+				// or maintenance windows are set.
+				// API default is `Automatic`
+				// Legacy behavior of existing managed-tenants tooling is:
+				// All addons initially have to be installed with `Automatic`
+				// so that the very first InstallPlan succeedes
+				// but some addons want to take control of upgrades and thus
+				// change the Subscription.Spec.InstallPlanApproval value to `Manual`
+				// ATTENTION: When reconciling the subscription, we need to
+				// make sure to keep the current value of this field
+			},
+		}
+		if upgradePolicy != nil {
+			// InstallPlans are approved according to the upgrade policy,
+			// see ensureInstallPlanApproval.
+			desiredSubscription.Spec.InstallPlanApproval = operatorsv1alpha1.ApprovalManual
+			desiredSubscription.Annotations = map[string]string{
+				installPlanApprovalManagedAnnotation: "true",
+			}
+		}
+		addCommonLabels(desiredSubscription.Labels, addon)
+		if err := controllerutil.SetControllerReference(addon, desiredSubscription, r.Scheme); err != nil {
+			return nil, false, fmt.Errorf("setting controller reference: %w", err)
+		}
 
-	installedCSVKey := client.ObjectKey{
-		Name:      observedSubscription.Status.InstalledCSV,
-		Namespace: commonInstallOptions.Namespace,
+		observedSubscription, created, err := r.reconcileSubscription(
+			ctx, addon, desiredSubscription)
+		var collision *adoptionCollisionError
+		if errors.As(err, &collision) {
+			return nil, true,
+				r.reportAdoptionCollision(ctx, addon, addonsv1alpha1.SubscriptionReady, collision)
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("reconciling Subscription: %w", err)
+		}
+		if created {
+			r.recordEvent(addon, corev1.EventTypeNormal, eventReasonSubscriptionCreated,
+				"Created Subscription %s/%s for package %q in channel %q",
+				desiredSubscription.Namespace, desiredSubscription.Name,
+				pkg.packageName, pkg.channel)
+		}
+		observedSubscriptions = append(observedSubscriptions, observedSubscription)
 	}
-	currentCSVKey = client.ObjectKey{
-		Name:      observedSubscription.Status.CurrentCSV,
-		Namespace: commonInstallOptions.Namespace,
+
+	upgradeHeld, err := r.ensureInstallPlanApproval(
+		ctx, log, addon, upgradePolicy, maintenanceWindows, observedSubscriptions...)
+	if err != nil {
+		return nil, false, fmt.Errorf("ensuring InstallPlan approval: %w", err)
 	}
 
-	changed := r.csvEventHandler.ReplaceMap(addon, installedCSVKey, currentCSVKey)
+	var csvKeys []client.ObjectKey
+	for _, observedSubscription := range observedSubscriptions {
+		reason, message, err := r.subscriptionFailure(ctx, observedSubscription)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(reason) > 0 {
+			log.Info("requeue", "reason", "subscription failed",
+				"subscription", observedSubscription.Name, "message", message)
+			setPhaseCondition(addon, addonsv1alpha1.SubscriptionReady, metav1.ConditionFalse,
+				reason, message)
+			r.recordEvent(addon, corev1.EventTypeWarning, eventReasonSubscriptionFailed,
+				"Subscription %s/%s failed: %s",
+				observedSubscription.Namespace, observedSubscription.Name, message)
+			return nil, true, r.reportPhaseStatus(ctx, addon)
+		}
+
+		if len(observedSubscription.Status.InstalledCSV) == 0 ||
+			len(observedSubscription.Status.CurrentCSV) == 0 {
+			log.Info("requeue", "reason", "csv not linked in subscription",
+				"subscription", observedSubscription.Name)
+			setPhaseCondition(addon, addonsv1alpha1.SubscriptionReady, metav1.ConditionFalse,
+				addonsv1alpha1.AddonReasonUnreadySubscription,
+				"ClusterServiceVersion is not yet linked in the Subscription")
+			return nil, true, r.reportPhaseStatus(ctx, addon)
+		}
+
+		installedCSVKey := client.ObjectKey{
+			Name:      observedSubscription.Status.InstalledCSV,
+			Namespace: commonInstallOptions.Namespace,
+		}
+		currentCSVKey := client.ObjectKey{
+			Name:      observedSubscription.Status.CurrentCSV,
+			Namespace: commonInstallOptions.Namespace,
+		}
+
+		if upgradeHeld {
+			// The current CSV will not be installed until the upgrade is approved,
+			// keep observing the installed CSV instead.
+			currentCSVKey = installedCSVKey
+		}
+		csvKeys = append(csvKeys, installedCSVKey, currentCSVKey)
+		currentCSVKeys = append(currentCSVKeys, currentCSVKey)
+	}
+
+	setPhaseCondition(addon, addonsv1alpha1.SubscriptionReady, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+
+	changed := r.csvEventHandler.ReplaceMap(addon, csvKeys...)
 	if changed {
 		// Mapping changes need to requeue, because we could have lost events before or during
 		// setting up the mapping, see csvEventHandler implementation for a longer description.
 		log.Info("requeue", "reason", "csv-addon mapping changed")
-		return client.ObjectKey{}, true, nil
+		return nil, true, nil
 	}
 
-	return currentCSVKey, false, nil
+	return currentCSVKeys, false, nil
 }
 
+// Subscription condition set by OLM when dependency resolution fails,
+// not yet part of the vendored OLM API.
+const subscriptionResolutionFailed operatorsv1alpha1.SubscriptionConditionType = "ResolutionFailed"
+
+// Checks the given Subscription and the InstallPlan it references for failures.
+// Returns the Addon condition reason and the failure message reported by OLM,
+// or an empty reason if the Subscription has not failed.
+func (r *AddonReconciler) subscriptionFailure(
+	ctx context.Context, subscription *operatorsv1alpha1.Subscription,
+) (reason, message string, err error) {
+	for _, failure := range []struct {
+		conditionType operatorsv1alpha1.SubscriptionConditionType
+		reason        string
+	}{
+		{subscriptionResolutionFailed, addonsv1alpha1.AddonReasonSubscriptionResolutionFailed},
+		{operatorsv1alpha1.SubscriptionCatalogSourcesUnhealthy, addonsv1alpha1.AddonReasonCatalogSourcesUnhealthy},
+		{operatorsv1alpha1.SubscriptionInstallPlanFailed, addonsv1alpha1.AddonReasonInstallPlanFailed},
+	} {
+		cond := subscription.Status.GetCondition(failure.conditionType)
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		return failure.reason, conditionMessage(string(failure.conditionType), cond.Reason, cond.Message), nil
+	}
+
+	if subscription.Status.InstallPlanRef == nil {
+		return "", "", nil
+	}
+	installPlan := &operatorsv1alpha1.InstallPlan{}
+	err = r.Get(ctx, client.ObjectKey{
+		Name:      subscription.Status.InstallPlanRef.Name,
+		Namespace: subscription.Status.InstallPlanRef.Namespace,
+	}, installPlan)
+	if apierrors.IsNotFound(err) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("getting InstallPlan: %w", err)
+	}
+	if installPlan.Status.Phase != operatorsv1alpha1.InstallPlanPhaseFailed {
+		return "", "", nil
+	}
+
+	message = installPlan.Status.Message
+	for _, cond := range installPlan.Status.Conditions {
+		if cond.Type == operatorsv1alpha1.InstallPlanInstalled &&
+			cond.Status == corev1.ConditionFalse {
+			message = conditionMessage(string(cond.Type), string(cond.Reason), cond.Message)
+		}
+	}
+	if len(message) == 0 {
+		message = "InstallPlan failed"
+	}
+	return addonsv1alpha1.AddonReasonInstallPlanFailed,
+		fmt.Sprintf("InstallPlan %s: %s", installPlan.Name, message), nil
+}
+
+// Formats the message of an OLM condition, falling back to its type and reason.
+func conditionMessage(conditionType, reason, message string) string {
+	if len(message) > 0 {
+		return message
+	}
+	if len(reason) > 0 {
+		return fmt.Sprintf("%s: %s", conditionType, reason)
+	}
+	return conditionType
+}
+
+// Converts the Addon Subscription config into the OLM Subscription config.
+func newSubscriptionConfig(
+	config *addonsv1alpha1.SubscriptionConfig) *operatorsv1alpha1.SubscriptionConfig {
+	if config == nil {
+		return nil
+	}
+
+	return &operatorsv1alpha1.SubscriptionConfig{
+		Env:          config.Env,
+		EnvFrom:      config.EnvFrom,
+		Resources:    config.Resources,
+		NodeSelector: config.NodeSelector,
+		Tolerations:  config.Tolerations,
+		Volumes:      config.Volumes,
+		VolumeMounts: config.VolumeMounts,
+	}
+}
+
+// Reconciles the given Subscription and returns the current object as observed
+// and whether the Subscription was created.
+// Existing Subscriptions are only adopted as allowed by the adoption policy of the Addon.
 func (r *AddonReconciler) reconcileSubscription(
 	ctx context.Context,
+	addon *addonsv1alpha1.Addon,
 	subscription *operatorsv1alpha1.Subscription,
-) (currentSubscription *operatorsv1alpha1.Subscription, err error) {
+) (currentSubscription *operatorsv1alpha1.Subscription, created bool, err error) {
 	currentSubscription = &operatorsv1alpha1.Subscription{}
 	err = r.Get(ctx, client.ObjectKey{
 		Name:      subscription.Name,
@@ -104,14 +289,34 @@ func (r *AddonReconciler) reconcileSubscription(
 	}, currentSubscription)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
-			return subscription, r.Create(ctx, subscription)
+			return subscription, true, r.Create(ctx, subscription)
 		}
-		return nil, err
+		return nil, false, err
 	}
 
-	// keep installPlanApproval value of existing object
-	subscription.Spec.InstallPlanApproval = currentSubscription.Spec.InstallPlanApproval
+	if err := adoptObject(addon, operatorsv1alpha1.SchemeGroupVersion.WithKind(
+		operatorsv1alpha1.SubscriptionKind), currentSubscription, subscription); err != nil {
+		return nil, false, err
+	}
+
+	// labels and annotations are merged, to keep entries added by others
+	changed := mergeStringMap(&currentSubscription.Labels, subscription.Labels)
+	if mergeStringMap(&currentSubscription.Annotations, subscription.Annotations) {
+		changed = true
+	}
 
+	if len(subscription.Spec.InstallPlanApproval) == 0 {
+		if _, managed := currentSubscription.Annotations[installPlanApprovalManagedAnnotation]; managed {
+			// the upgrade policy or maintenance windows were removed,
+			// so InstallPlans are no longer approved by us.
+			subscription.Spec.InstallPlanApproval = operatorsv1alpha1.ApprovalAutomatic
+			delete(currentSubscription.Annotations, installPlanApprovalManagedAnnotation)
+			changed = true
+		} else {
+			// keep installPlanApproval value of existing object, if unmanaged
+			subscription.Spec.InstallPlanApproval = currentSubscription.Spec.InstallPlanApproval
+		}
+	}
 	// only update when spec has changed or owner reference has changed
 	if !equality.Semantic.DeepEqual(
 		subscription.Spec, currentSubscription.Spec) ||
@@ -120,7 +325,10 @@ func (r *AddonReconciler) reconcileSubscription(
 		// copy new spec into existing object and update in the k8s api
 		currentSubscription.Spec = subscription.Spec
 		currentSubscription.OwnerReferences = subscription.OwnerReferences
-		return currentSubscription, r.Update(ctx, currentSubscription)
+		changed = true
+	}
+	if changed {
+		return currentSubscription, false, r.Update(ctx, currentSubscription)
 	}
-	return currentSubscription, nil
+	return currentSubscription, false, nil
 }
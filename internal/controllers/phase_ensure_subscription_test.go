@@ -0,0 +1,379 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilpointer "k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/testutil"
+)
+
+func TestNewSubscriptionConfig(t *testing.T) {
+	assert.Nil(t, newSubscriptionConfig(nil))
+
+	resources := &corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("200Mi"),
+		},
+	}
+	config := newSubscriptionConfig(&addonsv1alpha1.SubscriptionConfig{
+		Env: []corev1.EnvVar{
+			{Name: "LOG_LEVEL", Value: "debug"},
+		},
+		Resources: resources,
+		NodeSelector: map[string]string{
+			"node-role.kubernetes.io/infra": "",
+		},
+		Tolerations: []corev1.Toleration{
+			{Key: "node-role.kubernetes.io/infra", Effect: corev1.TaintEffectNoSchedule},
+		},
+	})
+	if assert.NotNil(t, config) {
+		assert.Equal(t, "LOG_LEVEL", config.Env[0].Name)
+		assert.Equal(t, resources, config.Resources)
+		assert.Contains(t, config.NodeSelector, "node-role.kubernetes.io/infra")
+		assert.Len(t, config.Tolerations, 1)
+	}
+}
+
+func TestReconcileSubscription_UpdatesConfig(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+
+	c := testutil.NewClient()
+	c.On("Get",
+		mock.Anything,
+		mock.Anything,
+		mock.IsType(&operatorsv1alpha1.Subscription{}),
+	).Run(func(args mock.Arguments) {
+		sub := args.Get(2).(*operatorsv1alpha1.Subscription)
+		sub.Spec = &operatorsv1alpha1.SubscriptionSpec{
+			Package:             "test",
+			InstallPlanApproval: operatorsv1alpha1.ApprovalManual,
+		}
+		require.NoError(t, controllerutil.SetControllerReference(
+			addon, sub, newTestSchemeWithAddonsv1alpha1()))
+	}).Return(nil)
+	var updatedSubscription *operatorsv1alpha1.Subscription
+	c.On("Update",
+		mock.Anything,
+		mock.IsType(&operatorsv1alpha1.Subscription{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		updatedSubscription = args.Get(1).(*operatorsv1alpha1.Subscription)
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	ctx := context.Background()
+	_, _, err := r.reconcileSubscription(ctx, addon, &operatorsv1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "addon-1",
+			Namespace: "addon-1",
+		},
+		Spec: &operatorsv1alpha1.SubscriptionSpec{
+			Package: "test",
+			Config: &operatorsv1alpha1.SubscriptionConfig{
+				NodeSelector: map[string]string{
+					"node-role.kubernetes.io/infra": "",
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	c.AssertExpectations(t)
+
+	if assert.NotNil(t, updatedSubscription) {
+		assert.NotNil(t, updatedSubscription.Spec.Config)
+		// existing InstallPlanApproval is kept
+		assert.Equal(t, operatorsv1alpha1.ApprovalManual,
+			updatedSubscription.Spec.InstallPlanApproval)
+	}
+}
+
+func TestReconcileSubscription_ResetsManagedInstallPlanApproval(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	scheme := newTestSchemeWithAddonsv1alpha1()
+
+	desiredSubscription := &operatorsv1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "addon-1",
+			Namespace: "addon-1",
+		},
+		Spec: &operatorsv1alpha1.SubscriptionSpec{
+			Package: "test",
+		},
+	}
+	require.NoError(t, controllerutil.SetControllerReference(addon, desiredSubscription, scheme))
+
+	c := testutil.NewClient()
+	c.On("Get",
+		mock.Anything,
+		mock.Anything,
+		mock.IsType(&operatorsv1alpha1.Subscription{}),
+	).Run(func(args mock.Arguments) {
+		// set to Manual for a since removed upgrade policy
+		sub := args.Get(2).(*operatorsv1alpha1.Subscription)
+		desiredSubscription.DeepCopyInto(sub)
+		sub.Annotations = map[string]string{
+			installPlanApprovalManagedAnnotation: "true",
+		}
+		sub.Spec.InstallPlanApproval = operatorsv1alpha1.ApprovalManual
+	}).Return(nil)
+	var updatedSubscription *operatorsv1alpha1.Subscription
+	c.On("Update",
+		mock.Anything,
+		mock.IsType(&operatorsv1alpha1.Subscription{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		updatedSubscription = args.Get(1).(*operatorsv1alpha1.Subscription)
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: scheme,
+	}
+
+	ctx := context.Background()
+	_, _, err := r.reconcileSubscription(ctx, addon, desiredSubscription.DeepCopy())
+	require.NoError(t, err)
+	c.AssertExpectations(t)
+
+	if assert.NotNil(t, updatedSubscription) {
+		assert.Equal(t, operatorsv1alpha1.ApprovalAutomatic,
+			updatedSubscription.Spec.InstallPlanApproval)
+		assert.NotContains(t, updatedSubscription.Annotations, installPlanApprovalManagedAnnotation)
+	}
+}
+
+func TestReconcileSubscription_ControlledByOther(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	addon.Spec.Adoption = &addonsv1alpha1.AddonAdoption{
+		Policy: addonsv1alpha1.AdoptionPolicyAdoptIfLabelled,
+	}
+
+	c := testutil.NewClient()
+	c.On("Get",
+		mock.Anything,
+		mock.Anything,
+		mock.IsType(&operatorsv1alpha1.Subscription{}),
+	).Run(func(args mock.Arguments) {
+		sub := args.Get(2).(*operatorsv1alpha1.Subscription)
+		sub.Name = "addon-1"
+		sub.Namespace = "addon-1"
+		sub.Labels = map[string]string{}
+		addCommonLabels(sub.Labels, addon)
+		sub.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: "example.com/v1",
+			Kind:       "Operator",
+			Name:       "other",
+			UID:        "other-uid",
+			Controller: utilpointer.BoolPtr(true),
+		}}
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	ctx := context.Background()
+	_, _, err := r.reconcileSubscription(ctx, addon, &operatorsv1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "addon-1",
+			Namespace: "addon-1",
+		},
+		Spec: &operatorsv1alpha1.SubscriptionSpec{
+			Package: "test",
+		},
+	})
+	var collision *adoptionCollisionError
+	if assert.ErrorAs(t, err, &collision) {
+		assert.Equal(t, addonsv1alpha1.AddonReasonControlledByOther, collision.reason)
+		assert.EqualError(t, err,
+			"Subscription addon-1/addon-1 already exists and is controlled by Operator other, "+
+				"adoption policy AdoptIfLabelled does not take it over")
+	}
+	c.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSubscriptionFailure(t *testing.T) {
+	testCases := []struct {
+		name            string
+		conditions      []operatorsv1alpha1.SubscriptionCondition
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name: "healthy",
+			conditions: []operatorsv1alpha1.SubscriptionCondition{
+				{
+					Type:   operatorsv1alpha1.SubscriptionCatalogSourcesUnhealthy,
+					Status: corev1.ConditionFalse,
+				},
+			},
+		},
+		{
+			name: "resolution failed",
+			conditions: []operatorsv1alpha1.SubscriptionCondition{
+				{
+					Type:    subscriptionResolutionFailed,
+					Status:  corev1.ConditionTrue,
+					Reason:  "ConstraintsNotSatisfiable",
+					Message: "no operators found in channel alpha of package reference-addon",
+				},
+			},
+			expectedReason:  addonsv1alpha1.AddonReasonSubscriptionResolutionFailed,
+			expectedMessage: "no operators found in channel alpha of package reference-addon",
+		},
+		{
+			name: "catalog sources unhealthy",
+			conditions: []operatorsv1alpha1.SubscriptionCondition{
+				{
+					Type:   operatorsv1alpha1.SubscriptionCatalogSourcesUnhealthy,
+					Status: corev1.ConditionTrue,
+					Reason: "UnhealthyCatalogSourceFound",
+				},
+			},
+			expectedReason:  addonsv1alpha1.AddonReasonCatalogSourcesUnhealthy,
+			expectedMessage: "CatalogSourcesUnhealthy: UnhealthyCatalogSourceFound",
+		},
+		{
+			name: "install plan failed",
+			conditions: []operatorsv1alpha1.SubscriptionCondition{
+				{
+					Type:    operatorsv1alpha1.SubscriptionInstallPlanFailed,
+					Status:  corev1.ConditionTrue,
+					Message: "install strategy failed",
+				},
+			},
+			expectedReason:  addonsv1alpha1.AddonReasonInstallPlanFailed,
+			expectedMessage: "install strategy failed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &AddonReconciler{
+				Client: testutil.NewClient(),
+				Log:    testutil.NewLogger(t),
+			}
+			subscription := &operatorsv1alpha1.Subscription{}
+			subscription.Status.Conditions = tc.conditions
+
+			reason, message, err := r.subscriptionFailure(context.Background(), subscription)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedReason, reason)
+			assert.Equal(t, tc.expectedMessage, message)
+		})
+	}
+}
+
+func TestSubscriptionFailure_InstallPlan(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "install-abcde", Namespace: "addon-1"},
+		mock.IsType(&operatorsv1alpha1.InstallPlan{}),
+	).Run(func(args mock.Arguments) {
+		ip := args.Get(2).(*operatorsv1alpha1.InstallPlan)
+		ip.Name = "install-abcde"
+		ip.Status.Phase = operatorsv1alpha1.InstallPlanPhaseFailed
+		ip.Status.Conditions = []operatorsv1alpha1.InstallPlanCondition{
+			{
+				Type:    operatorsv1alpha1.InstallPlanInstalled,
+				Status:  corev1.ConditionFalse,
+				Reason:  operatorsv1alpha1.InstallPlanReasonComponentFailed,
+				Message: `error creating csv reference-addon.v0.1.3: admission webhook denied the request`,
+			},
+		}
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+	}
+	subscription := &operatorsv1alpha1.Subscription{}
+	subscription.Status.InstallPlanRef = &corev1.ObjectReference{
+		Name:      "install-abcde",
+		Namespace: "addon-1",
+	}
+
+	reason, message, err := r.subscriptionFailure(context.Background(), subscription)
+	require.NoError(t, err)
+	c.AssertExpectations(t)
+	assert.Equal(t, addonsv1alpha1.AddonReasonInstallPlanFailed, reason)
+	assert.Equal(t,
+		"InstallPlan install-abcde: error creating csv reference-addon.v0.1.3: admission webhook denied the request",
+		message)
+}
+
+func TestReconcileSubscription_MergesLabels(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	scheme := newTestSchemeWithAddonsv1alpha1()
+
+	desiredSubscription := &operatorsv1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "addon-1",
+			Namespace: "addon-1",
+			Labels:    map[string]string{},
+		},
+		Spec: &operatorsv1alpha1.SubscriptionSpec{
+			Package:             "test",
+			InstallPlanApproval: operatorsv1alpha1.ApprovalAutomatic,
+		},
+	}
+	addCommonLabels(desiredSubscription.Labels, addon)
+	require.NoError(t, controllerutil.SetControllerReference(addon, desiredSubscription, scheme))
+
+	c := testutil.NewClient()
+	c.On("Get",
+		mock.Anything,
+		mock.Anything,
+		mock.IsType(&operatorsv1alpha1.Subscription{}),
+	).Run(func(args mock.Arguments) {
+		sub := args.Get(2).(*operatorsv1alpha1.Subscription)
+		desiredSubscription.DeepCopyInto(sub)
+		sub.Labels = map[string]string{"team": "sre"}
+	}).Return(nil)
+	var updatedSubscription *operatorsv1alpha1.Subscription
+	c.On("Update",
+		mock.Anything,
+		mock.IsType(&operatorsv1alpha1.Subscription{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		updatedSubscription = args.Get(1).(*operatorsv1alpha1.Subscription)
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: scheme,
+	}
+
+	ctx := context.Background()
+	_, _, err := r.reconcileSubscription(ctx, addon, desiredSubscription.DeepCopy())
+	require.NoError(t, err)
+	c.AssertExpectations(t)
+
+	if assert.NotNil(t, updatedSubscription) {
+		assert.True(t, hasCommonLabels(updatedSubscription.Labels, addon))
+		assert.Equal(t, "sre", updatedSubscription.Labels["team"])
+	}
+}
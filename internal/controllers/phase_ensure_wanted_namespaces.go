@@ -8,9 +8,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
@@ -22,13 +20,20 @@ import (
 func (r *AddonReconciler) ensureWantedNamespaces(
 	ctx context.Context, addon *addonsv1alpha1.Addon) (stopAndRetry bool, err error) {
 	var unreadyNamespaces []string
-	var collidedNamespaces []string
+	var collisions []string
+	var stuckNamespaces []addonsv1alpha1.AddonStuckNamespace
 
+	wantedNamespaceNames := make(map[string]struct{})
 	for _, namespace := range addon.Spec.Namespaces {
-		ensuredNamespace, err := r.ensureNamespace(ctx, addon, namespace.Name)
+		wantedNamespaceNames[namespace.Name] = struct{}{}
+	}
+
+	for _, namespace := range addon.Spec.Namespaces {
+		ensuredNamespace, err := r.ensureNamespace(ctx, addon, namespace)
 		if err != nil {
-			if errors.Is(err, errNotOwnedByUs) {
-				collidedNamespaces = append(collidedNamespaces, namespace.Name)
+			var collision *adoptionCollisionError
+			if errors.As(err, &collision) {
+				collisions = append(collisions, collision.Error())
 				continue
 			}
 
@@ -38,21 +43,25 @@ func (r *AddonReconciler) ensureWantedNamespaces(
 		if ensuredNamespace.Status.Phase != corev1.NamespaceActive {
 			unreadyNamespaces = append(unreadyNamespaces, ensuredNamespace.Name)
 		}
+		if stuck := stuckNamespaceDiagnostics(ensuredNamespace); stuck != nil {
+			stuckNamespaces = append(stuckNamespaces, *stuck)
+		}
 	}
-
-	if len(collidedNamespaces) > 0 {
-		meta.SetStatusCondition(&addon.Status.Conditions, metav1.Condition{
-			Type:   addonsv1alpha1.Available,
-			Status: metav1.ConditionFalse,
-			Reason: addonsv1alpha1.AddonReasonCollidedNamespaces,
-			Message: fmt.Sprintf(
+	r.reportStuckNamespaces(addon, func(name string) bool {
+		_, isWanted := wantedNamespaceNames[name]
+		return isWanted
+	}, stuckNamespaces)
+
+	if len(collisions) > 0 {
+		r.recordEvent(addon, corev1.EventTypeWarning, eventReasonNamespaceCollision,
+			"Namespaces already exist and can not be adopted: %s",
+			strings.Join(collisions, "; "))
+		setPhaseCondition(addon, addonsv1alpha1.NamespacesReady, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonCollidedNamespaces,
+			fmt.Sprintf(
 				"Namespaces with collisions: %s",
-				strings.Join(collidedNamespaces, ", ")),
-			ObservedGeneration: addon.Generation,
-		})
-		addon.Status.ObservedGeneration = addon.Generation
-		addon.Status.Phase = addonsv1alpha1.PhasePending
-		err := r.Status().Update(ctx, addon)
+				strings.Join(collisions, "; ")))
+		err := r.reportPhaseStatus(ctx, addon)
 		if err != nil {
 			return false, err
 		}
@@ -60,68 +69,138 @@ func (r *AddonReconciler) ensureWantedNamespaces(
 		return true, nil
 	}
 
+	if len(stuckNamespaces) > 0 {
+		setPhaseCondition(addon, addonsv1alpha1.NamespacesReady, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonStuckNamespaces, stuckNamespacesMessage(stuckNamespaces))
+		return false, r.reportPhaseStatus(ctx, addon)
+	}
+
 	if len(unreadyNamespaces) > 0 {
-		meta.SetStatusCondition(&addon.Status.Conditions, metav1.Condition{
-			Type:   addonsv1alpha1.Available,
-			Status: metav1.ConditionFalse,
-			Reason: addonsv1alpha1.AddonReasonUnreadyNamespaces,
-			Message: fmt.Sprintf(
+		setPhaseCondition(addon, addonsv1alpha1.NamespacesReady, metav1.ConditionFalse,
+			addonsv1alpha1.AddonReasonUnreadyNamespaces,
+			fmt.Sprintf(
 				"Namespaces not yet in Active phase: %s",
-				strings.Join(unreadyNamespaces, ", ")),
-			ObservedGeneration: addon.Generation,
-		})
-		addon.Status.ObservedGeneration = addon.Generation
-		addon.Status.Phase = addonsv1alpha1.PhasePending
-		return false, r.Status().Update(ctx, addon)
+				strings.Join(unreadyNamespaces, ", ")))
+		return false, r.reportPhaseStatus(ctx, addon)
 	}
 
+	setPhaseCondition(addon, addonsv1alpha1.NamespacesReady, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
 	return false, nil
 }
 
-// Ensure a single Namespace for the given Addon resource
-func (r *AddonReconciler) ensureNamespace(ctx context.Context, addon *addonsv1alpha1.Addon, name string) (*corev1.Namespace, error) {
+// Ensure a single Namespace for the given Addon resource.
+// The deletion policy of the Namespace is recorded in an annotation.
+// Only Namespaces deleted with the Addon are controlled by it,
+// an owner reference would let the garbage collector delete retained and orphaned Namespaces
+// on foreground deletion of the Addon.
+func (r *AddonReconciler) ensureNamespace(
+	ctx context.Context, addon *addonsv1alpha1.Addon, addonNamespace addonsv1alpha1.AddonNamespace,
+) (*corev1.Namespace, error) {
+	policy := specNamespaceDeletionPolicy(addon, addonNamespace)
 	namespace := &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   name,
+			Name:   addonNamespace.Name,
 			Labels: map[string]string{},
+			Annotations: map[string]string{
+				namespaceDeletionPolicyAnnotation: string(policy),
+			},
 		},
 	}
 	addCommonLabels(namespace.Labels, addon)
-	err := controllerutil.SetControllerReference(addon, namespace, r.Scheme)
+	if policy == addonsv1alpha1.NamespaceDeletionPolicyDelete {
+		err := controllerutil.SetControllerReference(addon, namespace, r.Scheme)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	currentNamespace, created, err := reconcileNamespace(ctx, r.Client, addon, namespace)
 	if err != nil {
 		return nil, err
 	}
-	return reconcileNamespace(ctx, r.Client, r.Scheme, namespace, addon.Spec.ResourceAdoptionStrategy)
+	if created {
+		r.recordEvent(addon, corev1.EventTypeNormal, eventReasonNamespaceCreated,
+			"Created Namespace %q", addonNamespace.Name)
+	}
+	return currentNamespace, nil
 }
 
-// reconciles a Namespace and returns the current object as observed.
-// prevents adoption of Namespaces (unowned or owned by something else)
-// reconciling a Namespace means: creating it when it is not present
-// and erroring if our controller is not the owner of said Namespace
-func reconcileNamespace(ctx context.Context, c client.Client, scheme *runtime.Scheme,
-	namespace *corev1.Namespace, strategy addonsv1alpha1.ResourceAdoptionStrategyType) (*corev1.Namespace, error) {
+// reconciles a Namespace and returns the current object as observed
+// and whether the Namespace was created.
+// reconciling a Namespace means: creating it when it is not present,
+// adopting it as allowed by the adoption policy of the Addon
+// and keeping its owner references and annotations up to date.
+// Namespaces retained by the Addon before are taken back regardless of the adoption policy.
+// Namespaces without owner reference are recognized by the common labels and the deletion policy annotation.
+func reconcileNamespace(
+	ctx context.Context, c client.Client, addon *addonsv1alpha1.Addon, namespace *corev1.Namespace) (
+	currentNamespace *corev1.Namespace, created bool, err error) {
 
-	currentNamespace := &corev1.Namespace{}
+	currentNamespace = &corev1.Namespace{}
 
-	err := c.Get(ctx, client.ObjectKey{
+	err = c.Get(ctx, client.ObjectKey{
 		Name: namespace.Name,
 	}, currentNamespace)
 
 	if k8sApiErrors.IsNotFound(err) {
-		return namespace, c.Create(ctx, namespace)
+		return namespace, true, c.Create(ctx, namespace)
 	}
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
-	if len(currentNamespace.OwnerReferences) == 0 ||
-		!HasEqualControllerReference(currentNamespace, namespace) {
+	var changed bool
+	hasEqualController := len(currentNamespace.OwnerReferences) > 0 &&
+		HasEqualControllerReference(currentNamespace, namespace)
+	if !hasEqualController && !isNamespaceOfAddon(addon, currentNamespace) {
+		// retained Namespaces are taken back when added back to the Addon
+		if !isRetainedNamespace(addon, namespace.Name) {
+			if err := adoptObject(addon, corev1.SchemeGroupVersion.WithKind("Namespace"),
+				currentNamespace, namespace); err != nil {
+				return nil, false, err
+			}
+		}
+		currentNamespace.OwnerReferences = namespace.OwnerReferences
+		if currentNamespace.Labels == nil {
+			currentNamespace.Labels = map[string]string{}
+		}
+		for key, value := range namespace.Labels {
+			currentNamespace.Labels[key] = value
+		}
+		changed = true
+	} else if !hasEqualController {
+		// the deletion policy changed, e.g. from Delete to Retain
+		ownerRefs, removed := withoutOwnerReferencesOf(currentNamespace.OwnerReferences, addon)
+		currentNamespace.OwnerReferences = append(ownerRefs, namespace.OwnerReferences...)
+		changed = removed || len(namespace.OwnerReferences) > 0
+	}
 
-		// TODO: remove this condition once resoureceAdoptionStrategy is discontinued
-		if strategy == addonsv1alpha1.ResourceAdoptionAdoptAll {
-			return namespace, c.Update(ctx, namespace)
+	if currentNamespace.Annotations == nil {
+		currentNamespace.Annotations = map[string]string{}
+	}
+	for key, value := range namespace.Annotations {
+		if currentNamespace.Annotations[key] != value {
+			currentNamespace.Annotations[key] = value
+			changed = true
 		}
-		return nil, errNotOwnedByUs
 	}
-	return currentNamespace, nil
+
+	if changed {
+		return currentNamespace, false, c.Update(ctx, currentNamespace)
+	}
+	return currentNamespace, false, nil
+}
+
+// Checks whether the given existing Namespace already belongs to the Addon,
+// either controlled by it or labelled by ensureNamespace without owner reference.
+func isNamespaceOfAddon(addon *addonsv1alpha1.Addon, namespace *corev1.Namespace) bool {
+	if metav1.IsControlledBy(namespace, addon) {
+		return true
+	}
+	if metav1.GetControllerOf(namespace) != nil {
+		return false
+	}
+	_, ok := namespace.Annotations[namespaceDeletionPolicyAnnotation]
+	return ok && hasCommonLabels(namespace.Labels, addon)
 }
@@ -9,7 +9,11 @@ import (
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
 	"github.com/openshift/addon-operator/internal/testutil"
@@ -46,12 +50,20 @@ func TestEnsureWantedNamespaces_AddonWithSingleNamespace_Collision(t *testing.T)
 	}
 
 	ctx := context.Background()
-	stop, err := r.ensureWantedNamespaces(ctx, newTestAddonWithSingleNamespace())
+	addon := newTestAddonWithSingleNamespace()
+	stop, err := r.ensureWantedNamespaces(ctx, addon)
 	require.NoError(t, err)
 	require.True(t, stop)
 	c.AssertExpectations(t)
 	c.AssertCalled(t, "Get", testutil.IsContext, testutil.IsObjectKey, testutil.IsCoreV1NamespacePtr)
 	c.StatusMock.AssertCalled(t, "Update", testutil.IsContext, testutil.IsAddonsv1alpha1AddonPtr, mock.Anything)
+
+	namespacesReadyCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.NamespacesReady)
+	if assert.NotNil(t, namespacesReadyCond) {
+		assert.Equal(t, metav1.ConditionFalse, namespacesReadyCond.Status)
+		assert.Equal(t, addonsv1alpha1.AddonReasonCollidedNamespaces, namespacesReadyCond.Reason)
+	}
+	assert.False(t, meta.IsStatusConditionTrue(addon.Status.Conditions, addonsv1alpha1.Available))
 }
 
 func TestEnsureWantedNamespaces_AddonWithSingleNamespace_NoCollision(t *testing.T) {
@@ -170,6 +182,80 @@ func TestEnsureWantedNamespaces_AddonWithMultipleNamespaces_MultipleCollisions(t
 	c.StatusMock.AssertCalled(t, "Update", testutil.IsContext, testutil.IsAddonsv1alpha1AddonPtr, mock.Anything)
 }
 
+func TestEnsureWantedNamespaces_StuckNamespace(t *testing.T) {
+	addon := newTestAddonWithSingleNamespace()
+
+	now := metav1.Now()
+	stuckNamespace := newTestExistingNamespaceWithoutOwner()
+	stuckNamespace.DeletionTimestamp = &now
+	stuckNamespace.Annotations = map[string]string{
+		namespaceDeletionPolicyAnnotation: string(addonsv1alpha1.NamespaceDeletionPolicyDelete),
+	}
+	stuckNamespace.Status = corev1.NamespaceStatus{
+		Phase: corev1.NamespaceTerminating,
+		Conditions: []corev1.NamespaceCondition{
+			{
+				Type:    corev1.NamespaceContentRemaining,
+				Status:  corev1.ConditionTrue,
+				Message: "Some resources are remaining: foos.example.com has 2 resource instances",
+			},
+			{
+				Type:    corev1.NamespaceFinalizersRemaining,
+				Status:  corev1.ConditionTrue,
+				Message: "Some content in the namespace has finalizers remaining: example.com/cleanup in 2 resource instances",
+			},
+		},
+	}
+	require.NoError(t, controllerutil.SetControllerReference(
+		addon, stuckNamespace, newTestSchemeWithAddonsv1alpha1()))
+
+	c := testutil.NewClient()
+	c.On("Get", testutil.IsContext, testutil.IsObjectKey, testutil.IsCoreV1NamespacePtr).
+		Run(func(args mock.Arguments) {
+			stuckNamespace.DeepCopyInto(args.Get(2).(*corev1.Namespace))
+		}).
+		Return(nil)
+	c.StatusMock.On("Update", testutil.IsContext, testutil.IsAddonsv1alpha1AddonPtr, mock.Anything).
+		Return(nil)
+
+	recorder := record.NewFakeRecorder(1)
+	r := &AddonReconciler{
+		Client:   c,
+		Log:      testutil.NewLogger(t),
+		Scheme:   newTestSchemeWithAddonsv1alpha1(),
+		Recorder: recorder,
+	}
+
+	ctx := context.Background()
+	stop, err := r.ensureWantedNamespaces(ctx, addon)
+	require.NoError(t, err)
+	require.False(t, stop)
+	c.AssertExpectations(t)
+
+	assert.Equal(t, []addonsv1alpha1.AddonStuckNamespace{
+		{
+			Name:                "namespace-1",
+			RemainingResources:  []string{"foos.example.com has 2 resource instances"},
+			RemainingFinalizers: []string{"example.com/cleanup in 2 resource instances"},
+		},
+	}, addon.Status.StuckNamespaces)
+
+	expectedMessage := "Namespace namespace-1 is stuck terminating, " +
+		"remaining resources: foos.example.com has 2 resource instances; " +
+		"remaining finalizers: example.com/cleanup in 2 resource instances"
+	namespacesReadyCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.NamespacesReady)
+	if assert.NotNil(t, namespacesReadyCond) {
+		assert.Equal(t, addonsv1alpha1.AddonReasonStuckNamespaces, namespacesReadyCond.Reason)
+		assert.Equal(t, expectedMessage, namespacesReadyCond.Message)
+	}
+	if assert.Len(t, recorder.Events, 1) {
+		event := <-recorder.Events
+		assert.Contains(t, event, corev1.EventTypeWarning)
+		assert.Contains(t, event, eventReasonNamespaceStuck)
+		assert.Contains(t, event, expectedMessage)
+	}
+}
+
 func TestEnsureNamespace_Create(t *testing.T) {
 	addon := newTestAddonWithSingleNamespace()
 
@@ -177,6 +263,41 @@ func TestEnsureNamespace_Create(t *testing.T) {
 	c.On("Get", testutil.IsContext, testutil.IsObjectKey, testutil.IsCoreV1NamespacePtr).Return(newTestErrNotFound())
 	c.On("Create", testutil.IsContext, testutil.IsCoreV1NamespacePtr, mock.Anything).Return(nil)
 
+	recorder := record.NewFakeRecorder(1)
+	r := &AddonReconciler{
+		Client:   c,
+		Log:      testutil.NewLogger(t),
+		Scheme:   newTestSchemeWithAddonsv1alpha1(),
+		Recorder: recorder,
+	}
+
+	ctx := context.Background()
+	ensuredNamespace, err := r.ensureNamespace(ctx, addon, addon.Spec.Namespaces[0])
+	c.AssertExpectations(t)
+	require.NoError(t, err)
+	require.NotNil(t, ensuredNamespace)
+	if assert.Len(t, recorder.Events, 1) {
+		assert.Contains(t, <-recorder.Events, eventReasonNamespaceCreated)
+	}
+}
+
+func TestEnsureNamespace_AdoptsRetained(t *testing.T) {
+	addon := newTestAddonWithSingleNamespace()
+	addon.Status.RetainedNamespaces = []string{addon.Spec.Namespaces[0].Name}
+
+	c := testutil.NewClient()
+	c.On("Get", testutil.IsContext, testutil.IsObjectKey, testutil.IsCoreV1NamespacePtr).
+		Run(func(args mock.Arguments) {
+			newTestExistingNamespaceWithoutOwner().DeepCopyInto(args.Get(2).(*corev1.Namespace))
+		}).
+		Return(nil)
+	var updatedNamespace *corev1.Namespace
+	c.On("Update", testutil.IsContext, testutil.IsCoreV1NamespacePtr, mock.Anything).
+		Run(func(args mock.Arguments) {
+			updatedNamespace = args.Get(1).(*corev1.Namespace)
+		}).
+		Return(nil)
+
 	r := &AddonReconciler{
 		Client: c,
 		Log:    testutil.NewLogger(t),
@@ -184,10 +305,89 @@ func TestEnsureNamespace_Create(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	ensuredNamespace, err := r.ensureNamespace(ctx, addon, addon.Spec.Namespaces[0].Name)
+	_, err := r.ensureNamespace(ctx, addon, addon.Spec.Namespaces[0])
+	require.NoError(t, err)
 	c.AssertExpectations(t)
+	if assert.NotNil(t, updatedNamespace) {
+		assert.True(t, metav1.IsControlledBy(updatedNamespace, addon))
+		assert.Equal(t, string(addonsv1alpha1.NamespaceDeletionPolicyDelete),
+			updatedNamespace.Annotations[namespaceDeletionPolicyAnnotation])
+	}
+}
+
+func TestEnsureNamespace_RetainWithoutOwnerReference(t *testing.T) {
+	addon := newTestAddonWithSingleNamespace()
+	addon.Spec.Namespaces[0].DeletionPolicy = addonsv1alpha1.NamespaceDeletionPolicyRetain
+
+	c := testutil.NewClient()
+	c.On("Get", testutil.IsContext, testutil.IsObjectKey, testutil.IsCoreV1NamespacePtr).Return(newTestErrNotFound())
+	var createdNamespace *corev1.Namespace
+	c.On("Create", testutil.IsContext, testutil.IsCoreV1NamespacePtr, mock.Anything).
+		Run(func(args mock.Arguments) {
+			createdNamespace = args.Get(1).(*corev1.Namespace)
+		}).
+		Return(nil)
+
+	r := &AddonReconciler{
+		Client:   c,
+		Log:      testutil.NewLogger(t),
+		Scheme:   newTestSchemeWithAddonsv1alpha1(),
+		Recorder: record.NewFakeRecorder(1),
+	}
+
+	ctx := context.Background()
+	_, err := r.ensureNamespace(ctx, addon, addon.Spec.Namespaces[0])
 	require.NoError(t, err)
-	require.NotNil(t, ensuredNamespace)
+	c.AssertExpectations(t)
+	if assert.NotNil(t, createdNamespace) {
+		assert.Empty(t, createdNamespace.OwnerReferences)
+		assert.True(t, hasCommonLabels(createdNamespace.Labels, addon))
+		assert.Equal(t, string(addonsv1alpha1.NamespaceDeletionPolicyRetain),
+			createdNamespace.Annotations[namespaceDeletionPolicyAnnotation])
+	}
+}
+
+func TestEnsureNamespace_RetainReleasesOwnerReference(t *testing.T) {
+	addon := newTestAddonWithSingleNamespace()
+	addon.UID = "addon-uid"
+	addon.Spec.Namespaces[0].DeletionPolicy = addonsv1alpha1.NamespaceDeletionPolicyRetain
+
+	c := testutil.NewClient()
+	c.On("Get", testutil.IsContext, testutil.IsObjectKey, testutil.IsCoreV1NamespacePtr).
+		Run(func(args mock.Arguments) {
+			namespace := args.Get(2).(*corev1.Namespace)
+			newTestExistingNamespaceWithoutOwner().DeepCopyInto(namespace)
+			namespace.Labels = map[string]string{}
+			addCommonLabels(namespace.Labels, addon)
+			namespace.Annotations = map[string]string{
+				namespaceDeletionPolicyAnnotation: string(addonsv1alpha1.NamespaceDeletionPolicyDelete),
+			}
+			require.NoError(t, controllerutil.SetControllerReference(
+				addon, namespace, newTestSchemeWithAddonsv1alpha1()))
+		}).
+		Return(nil)
+	var updatedNamespace *corev1.Namespace
+	c.On("Update", testutil.IsContext, testutil.IsCoreV1NamespacePtr, mock.Anything).
+		Run(func(args mock.Arguments) {
+			updatedNamespace = args.Get(1).(*corev1.Namespace)
+		}).
+		Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	ctx := context.Background()
+	_, err := r.ensureNamespace(ctx, addon, addon.Spec.Namespaces[0])
+	require.NoError(t, err)
+	c.AssertExpectations(t)
+	if assert.NotNil(t, updatedNamespace) {
+		assert.Empty(t, updatedNamespace.OwnerReferences)
+		assert.Equal(t, string(addonsv1alpha1.NamespaceDeletionPolicyRetain),
+			updatedNamespace.Annotations[namespaceDeletionPolicyAnnotation])
+	}
 }
 
 func TestReconcileNamespace_Create(t *testing.T) {
@@ -196,9 +396,9 @@ func TestReconcileNamespace_Create(t *testing.T) {
 	c.On("Create", testutil.IsContext, testutil.IsCoreV1NamespacePtr, mock.Anything).Return(nil, newTestNamespace())
 
 	ctx := context.Background()
-	reconciledNamespace, err := reconcileNamespace(ctx, c, newTestSchemeWithAddonsv1alpha1(), newTestNamespace(),
-		addonsv1alpha1.ResourceAdoptionPrevent)
+	reconciledNamespace, created, err := reconcileNamespace(ctx, c, newTestAddonWithSingleNamespace(), newTestNamespace())
 	require.NoError(t, err)
+	assert.True(t, created)
 	assert.NotNil(t, reconciledNamespace)
 	assert.Equal(t, newTestNamespace(), reconciledNamespace)
 	c.AssertExpectations(t)
@@ -216,9 +416,8 @@ func TestReconcileNamespace_CreateWithCollisionWithoutOwner(t *testing.T) {
 	}).Return(nil)
 
 	ctx := context.Background()
-	_, err := reconcileNamespace(ctx, c, newTestSchemeWithAddonsv1alpha1(), newTestNamespace(),
-		addonsv1alpha1.ResourceAdoptionPrevent)
-	require.EqualError(t, err, errNotOwnedByUs.Error())
+	_, _, err := reconcileNamespace(ctx, c, newTestAddonWithSingleNamespace(), newTestNamespace())
+	require.ErrorIs(t, err, errNotOwnedByUs)
 	c.AssertExpectations(t)
 	c.AssertCalled(t, "Get", testutil.IsContext, client.ObjectKey{
 		Name: "namespace-1",
@@ -229,13 +428,12 @@ func TestReconcileNamespace_CreateWithCollisionWithOtherOwner(t *testing.T) {
 	c := testutil.NewClient()
 	c.On("Get", testutil.IsContext, testutil.IsObjectKey, testutil.IsCoreV1NamespacePtr).Run(func(args mock.Arguments) {
 		arg := args.Get(2).(*corev1.Namespace)
-		newTestExistingNamespaceWithoutOwner().DeepCopyInto(arg)
+		newTestExistingNamespaceWithOwner().DeepCopyInto(arg)
 	}).Return(nil)
 
 	ctx := context.Background()
-	_, err := reconcileNamespace(ctx, c, newTestSchemeWithAddonsv1alpha1(), newTestNamespace(),
-		addonsv1alpha1.ResourceAdoptionPrevent)
-	require.EqualError(t, err, errNotOwnedByUs.Error())
+	_, _, err := reconcileNamespace(ctx, c, newTestAddonWithSingleNamespace(), newTestNamespace())
+	require.ErrorIs(t, err, errNotOwnedByUs)
 	c.AssertExpectations(t)
 	c.AssertCalled(t, "Get", testutil.IsContext, client.ObjectKey{
 		Name: "namespace-1",
@@ -250,8 +448,7 @@ func TestReconcileNamespace_CreateWithClientError(t *testing.T) {
 		Return(timeoutErr)
 
 	ctx := context.Background()
-	_, err := reconcileNamespace(ctx, c, newTestSchemeWithAddonsv1alpha1(), newTestNamespace(),
-		addonsv1alpha1.ResourceAdoptionPrevent)
+	_, _, err := reconcileNamespace(ctx, c, newTestAddonWithSingleNamespace(), newTestNamespace())
 	require.Error(t, err)
 	require.EqualError(t, err, timeoutErr.Error())
 	c.AssertExpectations(t)
@@ -3,49 +3,184 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
-	"k8s.io/apimachinery/pkg/api/meta"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
 )
 
+const crashLoopBackOffReason = "CrashLoopBackOff"
+
+// Observes the ClusterServiceVersions of all OLM packages of the Addon,
+// the first one is the ClusterServiceVersion of the main package.
+// Succeeded ClusterServiceVersions are additionally checked for unhealthy Deployments.
 func (r *AddonReconciler) observeCurrentCSV(
 	ctx context.Context,
 	addon *addonsv1alpha1.Addon,
-	csvKey client.ObjectKey,
+	csvKeys []client.ObjectKey,
 ) (requeue bool, err error) {
-	csv := &operatorsv1alpha1.ClusterServiceVersion{}
-	if err := r.Get(ctx, csvKey, csv); err != nil {
-		return false, fmt.Errorf("getting installed CSV: %w", err)
-	}
-
-	var message string
-	switch csv.Status.Phase {
-	case operatorsv1alpha1.CSVPhaseSucceeded:
-		// do nothing here
-	case operatorsv1alpha1.CSVPhaseFailed:
-		message = "failed"
-	default:
-		message = "unkown/pending"
-	}
-
-	if message != "" {
-		meta.SetStatusCondition(&addon.Status.Conditions, metav1.Condition{
-			Type:   addonsv1alpha1.Available,
-			Status: metav1.ConditionFalse,
-			Reason: addonsv1alpha1.AddonReasonUnreadyCSV,
-			Message: fmt.Sprintf(
-				"ClusterServiceVersion is not ready: %s",
-				message),
-			ObservedGeneration: addon.Generation,
-		})
-		addon.Status.ObservedGeneration = addon.Generation
-		addon.Status.Phase = addonsv1alpha1.PhasePending
-		return true, r.Status().Update(ctx, addon)
+	var (
+		installedBundle *addonsv1alpha1.AddonInstalledBundle
+		csvs            []*operatorsv1alpha1.ClusterServiceVersion
+	)
+	for _, csvKey := range csvKeys {
+		csv := &operatorsv1alpha1.ClusterServiceVersion{}
+		if err := r.Get(ctx, csvKey, csv); err != nil {
+			return false, fmt.Errorf("getting installed CSV: %w", err)
+		}
+
+		eventType := corev1.EventTypeNormal
+		if csv.Status.Phase == operatorsv1alpha1.CSVPhaseFailed {
+			eventType = corev1.EventTypeWarning
+		}
+		r.recordEvent(addon, eventType, eventReasonCSVPhaseChanged,
+			"ClusterServiceVersion %s/%s is in phase %q",
+			csv.Namespace, csv.Name, csv.Status.Phase)
+
+		if message := csvUnreadyMessage(csv); message != "" {
+			setPhaseCondition(addon, addonsv1alpha1.CSVSucceeded, metav1.ConditionFalse,
+				addonsv1alpha1.AddonReasonUnreadyCSV, message)
+			return true, r.reportPhaseStatus(ctx, addon)
+		}
+
+		if installedBundle == nil {
+			installedBundle = &addonsv1alpha1.AddonInstalledBundle{
+				Name:    csv.Name,
+				Version: csv.Spec.Version.String(),
+			}
+		}
+		csvs = append(csvs, csv)
 	}
 
+	addon.Status.InstalledBundle = installedBundle
+	setPhaseCondition(addon, addonsv1alpha1.CSVSucceeded, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
+
+	for _, csv := range csvs {
+		message, err := r.csvDeploymentsUnhealthyMessage(ctx, csv)
+		if err != nil {
+			return false, err
+		}
+		if message != "" {
+			setPhaseCondition(addon, addonsv1alpha1.DeploymentsAvailable, metav1.ConditionFalse,
+				addonsv1alpha1.AddonReasonUnhealthyDeployments, message)
+			r.recordEvent(addon, corev1.EventTypeWarning, eventReasonDeploymentsUnhealthy,
+				"%s", message)
+			return true, r.reportPhaseStatus(ctx, addon)
+		}
+	}
+	setPhaseCondition(addon, addonsv1alpha1.DeploymentsAvailable, metav1.ConditionTrue,
+		addonsv1alpha1.AddonReasonReady, "")
 	return false, nil
 }
+
+// Returns why the given ClusterServiceVersion is not ready, or an empty string if it succeeded.
+// The message names the ClusterServiceVersion and its version
+// and carries the reason and message reported by OLM.
+func csvUnreadyMessage(csv *operatorsv1alpha1.ClusterServiceVersion) string {
+	if csv.Status.Phase == operatorsv1alpha1.CSVPhaseSucceeded {
+		return ""
+	}
+
+	phase := string(csv.Status.Phase)
+	if len(phase) == 0 {
+		phase = "Unknown"
+	}
+	message := fmt.Sprintf("ClusterServiceVersion %s (version %s) is not ready: phase %s",
+		csv.Name, csv.Spec.Version.String(), phase)
+	if len(csv.Status.Reason) > 0 {
+		message += fmt.Sprintf(", reason %s", csv.Status.Reason)
+	}
+	if len(csv.Status.Message) > 0 {
+		message += ": " + csv.Status.Message
+	}
+	return message
+}
+
+// Checks the Deployments of the install strategy of the given ClusterServiceVersion.
+// Returns why a Deployment is unhealthy, or an empty string if all of them are available
+// and none of their Pods are crash-looping.
+func (r *AddonReconciler) csvDeploymentsUnhealthyMessage(
+	ctx context.Context, csv *operatorsv1alpha1.ClusterServiceVersion) (string, error) {
+	for _, deploymentSpec := range csv.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		deployment := &appsv1.Deployment{}
+		err := r.uncachedReader().Get(ctx, client.ObjectKey{
+			Name:      deploymentSpec.Name,
+			Namespace: csv.Namespace,
+		}, deployment)
+		if k8sApiErrors.IsNotFound(err) {
+			return fmt.Sprintf("Deployment %s/%s of ClusterServiceVersion %s not found",
+				csv.Namespace, deploymentSpec.Name, csv.Name), nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("getting Deployment: %w", err)
+		}
+
+		message, err := r.crashLoopingPodsMessage(ctx, deployment)
+		if err != nil {
+			return "", err
+		}
+		if message != "" {
+			return message, nil
+		}
+
+		wantedReplicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			wantedReplicas = *deployment.Spec.Replicas
+		}
+		if deployment.Status.AvailableReplicas < wantedReplicas {
+			return fmt.Sprintf("Deployment %s/%s has %d/%d available replicas",
+				deployment.Namespace, deployment.Name,
+				deployment.Status.AvailableReplicas, wantedReplicas), nil
+		}
+	}
+	return "", nil
+}
+
+// Returns a message describing the first crash-looping container in the Pods of the given Deployment,
+// or an empty string if no container is crash-looping.
+func (r *AddonReconciler) crashLoopingPodsMessage(
+	ctx context.Context, deployment *appsv1.Deployment) (string, error) {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return "", fmt.Errorf("parsing Deployment selector: %w", err)
+	}
+	podList := &corev1.PodList{}
+	if err := r.uncachedReader().List(ctx, podList,
+		client.InNamespace(deployment.Namespace),
+		client.MatchingLabelsSelector{Selector: selector},
+	); err != nil {
+		return "", fmt.Errorf("listing Deployment Pods: %w", err)
+	}
+
+	for _, pod := range podList.Items {
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			if containerStatus.State.Waiting == nil ||
+				containerStatus.State.Waiting.Reason != crashLoopBackOffReason {
+				continue
+			}
+
+			message := fmt.Sprintf(
+				"container %s of Pod %s/%s (Deployment %s) is crash-looping, restarted %d times",
+				containerStatus.Name, pod.Namespace, pod.Name, deployment.Name,
+				containerStatus.RestartCount)
+			if terminated := containerStatus.LastTerminationState.Terminated; terminated != nil {
+				message += fmt.Sprintf(", last exited with code %d", terminated.ExitCode)
+				if len(terminated.Reason) > 0 {
+					message += fmt.Sprintf(" (%s)", terminated.Reason)
+				}
+				if len(terminated.Message) > 0 {
+					message += ": " + strings.TrimSpace(terminated.Message)
+				}
+			}
+			return message, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/operator-framework/api/pkg/lib/version"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/testutil"
+)
+
+func TestCSVUnreadyMessage(t *testing.T) {
+	csv := newTestCSV()
+	assert.Equal(t, "", csvUnreadyMessage(csv))
+
+	csv.Status.Phase = operatorsv1alpha1.CSVPhaseFailed
+	csv.Status.Reason = operatorsv1alpha1.CSVReasonComponentFailed
+	csv.Status.Message = "install strategy failed: deployment addon-1-operator not ready before timeout"
+	assert.Equal(t,
+		"ClusterServiceVersion addon-1.v1.0.0 (version 1.0.0) is not ready: phase Failed, "+
+			"reason InstallComponentFailed: install strategy failed: deployment addon-1-operator not ready before timeout",
+		csvUnreadyMessage(csv))
+
+	csv.Status = operatorsv1alpha1.ClusterServiceVersionStatus{}
+	assert.Equal(t,
+		"ClusterServiceVersion addon-1.v1.0.0 (version 1.0.0) is not ready: phase Unknown",
+		csvUnreadyMessage(csv))
+}
+
+func TestObserveCurrentCSV_CrashLoopingDeployment(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "addon-1.v1.0.0", Namespace: "addon-1"},
+		mock.IsType(&operatorsv1alpha1.ClusterServiceVersion{}),
+	).Run(func(args mock.Arguments) {
+		newTestCSV().DeepCopyInto(args.Get(2).(*operatorsv1alpha1.ClusterServiceVersion))
+	}).Return(nil)
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "addon-1-operator", Namespace: "addon-1"},
+		mock.IsType(&appsv1.Deployment{}),
+	).Run(func(args mock.Arguments) {
+		deployment := args.Get(2).(*appsv1.Deployment)
+		deployment.Name = "addon-1-operator"
+		deployment.Namespace = "addon-1"
+		deployment.Spec.Selector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{"app": "addon-1-operator"},
+		}
+		deployment.Status.AvailableReplicas = 1
+	}).Return(nil)
+	c.On("List",
+		testutil.IsContext,
+		mock.IsType(&corev1.PodList{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		pod := corev1.Pod{}
+		pod.Name = "addon-1-operator-abcde"
+		pod.Namespace = "addon-1"
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name:         "manager",
+			RestartCount: 5,
+			State: corev1.ContainerState{
+				Waiting: &corev1.ContainerStateWaiting{Reason: crashLoopBackOffReason},
+			},
+			LastTerminationState: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{
+					ExitCode: 1,
+					Reason:   "Error",
+					Message:  "invalid configuration\n",
+				},
+			},
+		}}
+		args.Get(1).(*corev1.PodList).Items = []corev1.Pod{pod}
+	}).Return(nil)
+	c.StatusMock.On("Update",
+		testutil.IsContext,
+		testutil.IsAddonsv1alpha1AddonPtr,
+		mock.Anything,
+	).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	addon := newTestAddonWithCatalogSourceImage()
+	requeue, err := r.observeCurrentCSV(context.Background(), addon, []client.ObjectKey{
+		{Name: "addon-1.v1.0.0", Namespace: "addon-1"},
+	})
+	require.NoError(t, err)
+	assert.True(t, requeue)
+	c.AssertExpectations(t)
+
+	assert.True(t, meta.IsStatusConditionTrue(
+		addon.Status.Conditions, addonsv1alpha1.CSVSucceeded))
+	deploymentsCond := meta.FindStatusCondition(
+		addon.Status.Conditions, addonsv1alpha1.DeploymentsAvailable)
+	if assert.NotNil(t, deploymentsCond) {
+		assert.Equal(t, metav1.ConditionFalse, deploymentsCond.Status)
+		assert.Equal(t, addonsv1alpha1.AddonReasonUnhealthyDeployments, deploymentsCond.Reason)
+		assert.Equal(t,
+			"container manager of Pod addon-1/addon-1-operator-abcde (Deployment addon-1-operator) is crash-looping, "+
+				"restarted 5 times, last exited with code 1 (Error): invalid configuration",
+			deploymentsCond.Message)
+	}
+	availableCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.Available)
+	if assert.NotNil(t, availableCond) {
+		assert.Equal(t, metav1.ConditionFalse, availableCond.Status)
+	}
+}
+
+func TestCSVDeploymentsUnhealthyMessage_UnavailableReplicas(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "addon-1-operator", Namespace: "addon-1"},
+		mock.IsType(&appsv1.Deployment{}),
+	).Run(func(args mock.Arguments) {
+		deployment := args.Get(2).(*appsv1.Deployment)
+		deployment.Name = "addon-1-operator"
+		deployment.Namespace = "addon-1"
+		replicas := int32(2)
+		deployment.Spec.Replicas = &replicas
+		deployment.Spec.Selector = &metav1.LabelSelector{}
+		deployment.Status.AvailableReplicas = 1
+	}).Return(nil)
+	c.On("List",
+		testutil.IsContext,
+		mock.IsType(&corev1.PodList{}),
+		mock.Anything,
+	).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+	}
+
+	message, err := r.csvDeploymentsUnhealthyMessage(context.Background(), newTestCSV())
+	require.NoError(t, err)
+	assert.Equal(t, "Deployment addon-1/addon-1-operator has 1/2 available replicas", message)
+}
+
+func newTestCSV() *operatorsv1alpha1.ClusterServiceVersion {
+	csv := &operatorsv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "addon-1.v1.0.0",
+			Namespace: "addon-1",
+		},
+	}
+	csv.Spec.Version = version.OperatorVersion{Version: semver.MustParse("1.0.0")}
+	csv.Spec.InstallStrategy.StrategySpec.DeploymentSpecs = []operatorsv1alpha1.StrategyDeploymentSpec{
+		{Name: "addon-1-operator"},
+	}
+	csv.Status.Phase = operatorsv1alpha1.CSVPhaseSucceeded
+	return csv
+}
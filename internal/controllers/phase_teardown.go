@@ -0,0 +1,351 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	operatorsv1 "github.com/operator-framework/api/pkg/operators/v1"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+)
+
+// Object deleted in a teardown stage.
+type teardownObject struct {
+	obj client.Object
+	ref addonsv1alpha1.AddonObjectReference
+	// Only delete the object if it is controlled by the Addon.
+	controlledOnly bool
+}
+
+// Tears down the OLM installation of the Addon in stages,
+// each stage waits for its objects to be gone before the next one starts:
+// 1. the Subscriptions, so OLM stops installing and upgrading the operator,
+// 2. the installed and current ClusterServiceVersions of the Subscriptions,
+// 3. the CatalogSources and the OperatorGroup, including an adopted foreign OperatorGroup.
+// Returns false while a stage is still waiting, the stage is reported in the Addon status.
+func (r *AddonReconciler) teardownOLM(
+	ctx context.Context, addon *addonsv1alpha1.Addon) (done bool, err error) {
+	namespace := getCommonInstallOptions(addon).Namespace
+	if len(namespace) == 0 {
+		// misconfigured, nothing was installed
+		return true, nil
+	}
+	packages := getOLMPackages(addon)
+
+	var (
+		subscriptions []teardownObject
+		csvsRecorded  bool
+	)
+	for _, pkg := range packages {
+		subscription := &operatorsv1alpha1.Subscription{}
+		err := r.Get(ctx, client.ObjectKey{Name: pkg.name, Namespace: namespace}, subscription)
+		if k8sApiErrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("getting Subscription: %w", err)
+		}
+		// CSV names are only known from the Subscription status,
+		// so they are recorded before the Subscription is gone.
+		// CSVs of foreign Subscriptions are not ours to delete.
+		if metav1.IsControlledBy(subscription, addon) && recordTeardownCSVs(addon, subscription) {
+			csvsRecorded = true
+		}
+		subscriptions = append(subscriptions, teardownObject{
+			obj:            subscription,
+			ref:            olmObjectReference(operatorsv1alpha1.SubscriptionKind, subscription.Name, namespace),
+			controlledOnly: true,
+		})
+	}
+	if csvsRecorded {
+		// persisted before the Subscriptions are deleted,
+		// as the CSV names would be lost if the status update fails later.
+		if err := r.Status().Update(ctx, addon); err != nil {
+			return false, fmt.Errorf("recording ClusterServiceVersions for teardown: %w", err)
+		}
+	}
+	if done, err := r.teardownStage(
+		ctx, addon, addonsv1alpha1.AddonTeardownStageSubscriptions, subscriptions); err != nil || !done {
+		return false, err
+	}
+
+	var csvs []teardownObject
+	if addon.Status.Teardown != nil {
+		for _, ref := range addon.Status.Teardown.ClusterServiceVersions {
+			csv := &operatorsv1alpha1.ClusterServiceVersion{}
+			csv.Name = ref.Name
+			csv.Namespace = ref.Namespace
+			csvs = append(csvs, teardownObject{obj: csv, ref: ref})
+		}
+	}
+	if done, err := r.teardownStage(
+		ctx, addon, addonsv1alpha1.AddonTeardownStageClusterServiceVersions, csvs); err != nil || !done {
+		return false, err
+	}
+
+	var catalogSourcesAndOperatorGroup []teardownObject
+	for _, pkg := range packages {
+		catalogSource := &operatorsv1alpha1.CatalogSource{}
+		catalogSource.Name = pkg.name
+		catalogSource.Namespace = namespace
+		catalogSourcesAndOperatorGroup = append(catalogSourcesAndOperatorGroup, teardownObject{
+			obj:            catalogSource,
+			ref:            olmObjectReference(operatorsv1alpha1.CatalogSourceKind, pkg.name, namespace),
+			controlledOnly: true,
+		})
+	}
+	operatorGroupNames := []string{addon.Name}
+	// adopted foreign OperatorGroups keep their name,
+	// stale references are harmless, as only controlled OperatorGroups are deleted
+	for _, ref := range addon.Status.AdoptedObjects {
+		if ref.Kind == operatorsv1.OperatorGroupKind &&
+			ref.Namespace == namespace && ref.Name != addon.Name {
+			operatorGroupNames = append(operatorGroupNames, ref.Name)
+		}
+	}
+	for _, name := range operatorGroupNames {
+		operatorGroup := &operatorsv1.OperatorGroup{}
+		operatorGroup.Name = name
+		operatorGroup.Namespace = namespace
+		catalogSourcesAndOperatorGroup = append(catalogSourcesAndOperatorGroup, teardownObject{
+			obj: operatorGroup,
+			ref: addonsv1alpha1.AddonObjectReference{
+				APIVersion: operatorsv1.GroupVersion.String(),
+				Kind:       operatorsv1.OperatorGroupKind,
+				Name:       name,
+				Namespace:  namespace,
+			},
+			controlledOnly: true,
+		})
+	}
+	return r.teardownStage(
+		ctx, addon, addonsv1alpha1.AddonTeardownStageCatalogSources, catalogSourcesAndOperatorGroup)
+}
+
+// Tears down the OLM v1 installation of the Addon in stages,
+// each stage waits for its object to be gone before the next one starts:
+// 1. the ClusterExtension, so OLM v1 uninstalls the bundle while its ServiceAccount still exists,
+// 2. the ClusterCatalog.
+// Returns false while a stage is still waiting, the stage is reported in the Addon status.
+func (r *AddonReconciler) teardownClusterExtension(
+	ctx context.Context, addon *addonsv1alpha1.Addon) (done bool, err error) {
+	for _, stage := range []struct {
+		name addonsv1alpha1.AddonTeardownStage
+		gvk  schema.GroupVersionKind
+	}{
+		{name: addonsv1alpha1.AddonTeardownStageClusterExtension, gvk: clusterExtensionGVK},
+		{name: addonsv1alpha1.AddonTeardownStageClusterCatalog, gvk: clusterCatalogGVK},
+	} {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(stage.gvk)
+		obj.SetName(addon.Name)
+		if done, err := r.teardownStage(ctx, addon, stage.name, []teardownObject{{
+			obj: obj,
+			ref: addonsv1alpha1.AddonObjectReference{
+				APIVersion: stage.gvk.GroupVersion().String(),
+				Kind:       stage.gvk.Kind,
+				Name:       addon.Name,
+			},
+			controlledOnly: true,
+		}}); err != nil || !done {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Deletes the objects applied for a Manifests or Helm Addon in reverse order,
+// instead of leaving them to the garbage collector.
+// Returns false while any of them still exists, they are reported in the Addon status.
+func (r *AddonReconciler) teardownManifestObjects(
+	ctx context.Context, addon *addonsv1alpha1.Addon) (done bool, err error) {
+	objects := make([]teardownObject, 0, len(addon.Status.ManifestObjects))
+	for i := len(addon.Status.ManifestObjects) - 1; i >= 0; i-- {
+		ref := addon.Status.ManifestObjects[i]
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(ref.APIVersion)
+		obj.SetKind(ref.Kind)
+		obj.SetName(ref.Name)
+		obj.SetNamespace(ref.Namespace)
+		objects = append(objects, teardownObject{obj: obj, ref: ref, controlledOnly: true})
+	}
+	return r.teardownStage(ctx, addon, addonsv1alpha1.AddonTeardownStageManifestObjects, objects)
+}
+
+// Deletes the Namespaces owned by the Addon as last teardown stage
+// and returns false while any of them is still terminating.
+// Namespaces with the Orphan or Retain deletion policy are released instead,
+// retained Namespaces are listed in the Addon status, as are Namespaces stuck terminating.
+func (r *AddonReconciler) teardownNamespaces(
+	ctx context.Context, addon *addonsv1alpha1.Addon) (done bool, err error) {
+	ownedNamespaces, err := getOwnedNamespacesViaCommonLabels(ctx, r.Client, addon)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		namespaces         []teardownObject
+		retainedNamespaces []string
+		stuckNamespaces    []addonsv1alpha1.AddonStuckNamespace
+	)
+	for i := range ownedNamespaces {
+		namespace := &ownedNamespaces[i]
+		policy := namespaceDeletionPolicy(addon, namespace)
+		if policy != addonsv1alpha1.NamespaceDeletionPolicyDelete {
+			if err := r.releaseNamespace(ctx, addon, namespace, policy); err != nil {
+				return false, err
+			}
+			if policy == addonsv1alpha1.NamespaceDeletionPolicyRetain {
+				retainedNamespaces = append(retainedNamespaces, namespace.Name)
+			}
+			continue
+		}
+
+		if stuck := stuckNamespaceDiagnostics(namespace); stuck != nil {
+			stuckNamespaces = append(stuckNamespaces, *stuck)
+		}
+		namespaces = append(namespaces, teardownObject{
+			obj: namespace,
+			ref: addonsv1alpha1.AddonObjectReference{
+				APIVersion: corev1.SchemeGroupVersion.String(),
+				Kind:       "Namespace",
+				Name:       namespace.Name,
+			},
+			controlledOnly: true,
+		})
+	}
+	addon.Status.RetainedNamespaces = retainedNamespaces
+	r.reportStuckNamespaces(addon, func(string) bool { return true }, stuckNamespaces)
+	return r.teardownStage(ctx, addon, addonsv1alpha1.AddonTeardownStageNamespaces, namespaces)
+}
+
+// Requests the deletion of the given objects and returns true when all of them are gone.
+// Otherwise the stage and the objects that still exist are set on the Addon status.
+func (r *AddonReconciler) teardownStage(
+	ctx context.Context, addon *addonsv1alpha1.Addon,
+	stage addonsv1alpha1.AddonTeardownStage, objects []teardownObject,
+) (done bool, err error) {
+	var pendingObjects []addonsv1alpha1.AddonObjectReference
+	for _, object := range objects {
+		exists, err := r.deleteTeardownObject(ctx, addon, object)
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			pendingObjects = append(pendingObjects, object.ref)
+		}
+	}
+	if len(pendingObjects) == 0 {
+		return true, nil
+	}
+
+	setTeardownStage(addon, stage, pendingObjects)
+	return false, nil
+}
+
+// Requests the deletion of the given object, unless it is already gone or terminating.
+// Returns true while the object still exists.
+func (r *AddonReconciler) deleteTeardownObject(
+	ctx context.Context, addon *addonsv1alpha1.Addon, object teardownObject,
+) (exists bool, err error) {
+	err = r.Get(ctx, client.ObjectKeyFromObject(object.obj), object.obj)
+	if k8sApiErrors.IsNotFound(err) || isNoMatchError(err) {
+		// gone, or its API is not served, like OLM v1 APIs when OLM v1 is not installed
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("getting %s: %w", object.ref.Kind, err)
+	}
+	if object.controlledOnly && !metav1.IsControlledBy(object.obj, addon) {
+		// not ours to delete
+		return false, nil
+	}
+
+	if object.obj.GetDeletionTimestamp().IsZero() {
+		err := r.Delete(ctx, object.obj, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !k8sApiErrors.IsNotFound(err) {
+			return false, fmt.Errorf("deleting %s: %w", object.ref.Kind, err)
+		}
+	}
+	return true, nil
+}
+
+// Records the installed and current ClusterServiceVersion of the given Subscription
+// to be deleted in the ClusterServiceVersions teardown stage.
+// Returns true if a ClusterServiceVersion was not recorded before.
+func recordTeardownCSVs(
+	addon *addonsv1alpha1.Addon, subscription *operatorsv1alpha1.Subscription) (changed bool) {
+	var refs []addonsv1alpha1.AddonObjectReference
+	for _, csvName := range []string{
+		subscription.Status.InstalledCSV,
+		subscription.Status.CurrentCSV,
+	} {
+		if len(csvName) == 0 {
+			continue
+		}
+		refs = append(refs, olmObjectReference(
+			operatorsv1alpha1.ClusterServiceVersionKind, csvName, subscription.Namespace))
+	}
+	if len(refs) == 0 {
+		return false
+	}
+
+	if addon.Status.Teardown == nil {
+		addon.Status.Teardown = &addonsv1alpha1.AddonTeardownStatus{}
+	}
+	recorded := len(addon.Status.Teardown.ClusterServiceVersions)
+	addon.Status.Teardown.ClusterServiceVersions = mergeObjectReferences(
+		addon.Status.Teardown.ClusterServiceVersions, refs)
+	return len(addon.Status.Teardown.ClusterServiceVersions) > recorded
+}
+
+// Sets the teardown stage in progress and the objects it is waiting for.
+func setTeardownStage(
+	addon *addonsv1alpha1.Addon, stage addonsv1alpha1.AddonTeardownStage,
+	pendingObjects []addonsv1alpha1.AddonObjectReference) {
+	if addon.Status.Teardown == nil {
+		addon.Status.Teardown = &addonsv1alpha1.AddonTeardownStatus{}
+	}
+	addon.Status.Teardown.Stage = stage
+	addon.Status.Teardown.PendingObjects = pendingObjects
+}
+
+// Describes the teardown stage in progress for the Available condition,
+// or returns an empty string if the teardown has not started yet.
+func teardownMessage(addon *addonsv1alpha1.Addon) string {
+	teardown := addon.Status.Teardown
+	if teardown == nil || len(teardown.Stage) == 0 {
+		return ""
+	}
+	if len(teardown.PendingObjects) == 0 {
+		return fmt.Sprintf("teardown stage %s", teardown.Stage)
+	}
+
+	pendingObjects := make([]string, len(teardown.PendingObjects))
+	for i, ref := range teardown.PendingObjects {
+		if len(ref.Namespace) == 0 {
+			pendingObjects[i] = fmt.Sprintf("%s %s", ref.Kind, ref.Name)
+			continue
+		}
+		pendingObjects[i] = fmt.Sprintf("%s %s/%s", ref.Kind, ref.Namespace, ref.Name)
+	}
+	return fmt.Sprintf("teardown stage %s: waiting for deletion of %s",
+		teardown.Stage, strings.Join(pendingObjects, ", "))
+}
+
+func olmObjectReference(kind, name, namespace string) addonsv1alpha1.AddonObjectReference {
+	return addonsv1alpha1.AddonObjectReference{
+		APIVersion: operatorsv1alpha1.SchemeGroupVersion.String(),
+		Kind:       kind,
+		Name:       name,
+		Namespace:  namespace,
+	}
+}
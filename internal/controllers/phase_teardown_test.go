@@ -0,0 +1,383 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	operatorsv1 "github.com/operator-framework/api/pkg/operators/v1"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/testutil"
+)
+
+func TestTeardownOLM_Subscriptions(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+
+	subscription := &operatorsv1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "addon-1",
+			Namespace: "addon-1",
+		},
+	}
+	subscription.Status.InstalledCSV = "addon-1.v1.0.0"
+	subscription.Status.CurrentCSV = "addon-1.v1.1.0"
+	require.NoError(t, controllerutil.SetControllerReference(
+		addon, subscription, newTestSchemeWithAddonsv1alpha1()))
+
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "addon-1", Namespace: "addon-1"},
+		mock.IsType(&operatorsv1alpha1.Subscription{}),
+	).Run(func(args mock.Arguments) {
+		subscription.DeepCopyInto(args.Get(2).(*operatorsv1alpha1.Subscription))
+	}).Return(nil)
+	var statusUpdated bool
+	c.StatusMock.On("Update",
+		testutil.IsContext,
+		testutil.IsAddonsv1alpha1AddonPtr,
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		statusUpdated = true
+	}).Return(nil)
+	c.On("Delete",
+		testutil.IsContext,
+		mock.IsType(&operatorsv1alpha1.Subscription{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		assert.True(t, statusUpdated, "CSVs have to be recorded before the Subscription is deleted")
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	done, err := r.teardownOLM(context.Background(), addon)
+	require.NoError(t, err)
+	assert.False(t, done)
+	c.AssertExpectations(t)
+	c.StatusMock.AssertExpectations(t)
+
+	if assert.NotNil(t, addon.Status.Teardown) {
+		assert.Equal(t, addonsv1alpha1.AddonTeardownStageSubscriptions, addon.Status.Teardown.Stage)
+		assert.Equal(t, []addonsv1alpha1.AddonObjectReference{
+			olmObjectReference(operatorsv1alpha1.SubscriptionKind, "addon-1", "addon-1"),
+		}, addon.Status.Teardown.PendingObjects)
+		assert.Equal(t, []addonsv1alpha1.AddonObjectReference{
+			olmObjectReference(operatorsv1alpha1.ClusterServiceVersionKind, "addon-1.v1.0.0", "addon-1"),
+			olmObjectReference(operatorsv1alpha1.ClusterServiceVersionKind, "addon-1.v1.1.0", "addon-1"),
+		}, addon.Status.Teardown.ClusterServiceVersions)
+	}
+}
+
+func TestTeardownOLM_ForeignSubscription(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "addon-1", Namespace: "addon-1"},
+		mock.IsType(&operatorsv1alpha1.Subscription{}),
+	).Run(func(args mock.Arguments) {
+		subscription := args.Get(2).(*operatorsv1alpha1.Subscription)
+		subscription.Name = "addon-1"
+		subscription.Namespace = "addon-1"
+		subscription.Status.InstalledCSV = "addon-1.v1.0.0"
+	}).Return(nil)
+	c.On("Get",
+		testutil.IsContext,
+		testutil.IsObjectKey,
+		mock.IsType(&operatorsv1alpha1.CatalogSource{}),
+	).Return(newTestErrNotFound())
+	c.On("Get",
+		testutil.IsContext,
+		testutil.IsObjectKey,
+		mock.IsType(&operatorsv1.OperatorGroup{}),
+	).Return(newTestErrNotFound())
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	done, err := r.teardownOLM(context.Background(), addon)
+	require.NoError(t, err)
+	assert.True(t, done)
+	// neither the Subscription nor its CSVs are deleted
+	c.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	c.StatusMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	assert.Nil(t, addon.Status.Teardown)
+}
+
+func TestTeardownOLM_ClusterServiceVersions(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+	addon.Status.Teardown = &addonsv1alpha1.AddonTeardownStatus{
+		Stage: addonsv1alpha1.AddonTeardownStageSubscriptions,
+		ClusterServiceVersions: []addonsv1alpha1.AddonObjectReference{
+			olmObjectReference(operatorsv1alpha1.ClusterServiceVersionKind, "addon-1.v1.0.0", "addon-1"),
+		},
+	}
+
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		testutil.IsObjectKey,
+		mock.IsType(&operatorsv1alpha1.Subscription{}),
+	).Return(newTestErrNotFound())
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "addon-1.v1.0.0", Namespace: "addon-1"},
+		mock.IsType(&operatorsv1alpha1.ClusterServiceVersion{}),
+	).Run(func(args mock.Arguments) {
+		newTestCSV().DeepCopyInto(args.Get(2).(*operatorsv1alpha1.ClusterServiceVersion))
+	}).Return(nil)
+	var deletedCSV *operatorsv1alpha1.ClusterServiceVersion
+	c.On("Delete",
+		testutil.IsContext,
+		mock.IsType(&operatorsv1alpha1.ClusterServiceVersion{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		deletedCSV = args.Get(1).(*operatorsv1alpha1.ClusterServiceVersion)
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	done, err := r.teardownOLM(context.Background(), addon)
+	require.NoError(t, err)
+	assert.False(t, done)
+	c.AssertExpectations(t)
+
+	if assert.NotNil(t, deletedCSV) {
+		assert.Equal(t, "addon-1.v1.0.0", deletedCSV.Name)
+	}
+	assert.Equal(t, addonsv1alpha1.AddonTeardownStageClusterServiceVersions, addon.Status.Teardown.Stage)
+}
+
+func TestTeardownOLM_Done(t *testing.T) {
+	addon := newTestAddonWithCatalogSourceImage()
+
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		testutil.IsObjectKey,
+		mock.IsType(&operatorsv1alpha1.Subscription{}),
+	).Return(newTestErrNotFound())
+	c.On("Get",
+		testutil.IsContext,
+		testutil.IsObjectKey,
+		mock.IsType(&operatorsv1alpha1.CatalogSource{}),
+	).Return(newTestErrNotFound())
+	c.On("Get",
+		testutil.IsContext,
+		testutil.IsObjectKey,
+		mock.IsType(&operatorsv1.OperatorGroup{}),
+	).Return(newTestErrNotFound())
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	done, err := r.teardownOLM(context.Background(), addon)
+	require.NoError(t, err)
+	assert.True(t, done)
+	c.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTeardownClusterExtension(t *testing.T) {
+	addon := newTestClusterExtensionAddon()
+	scheme := newTestSchemeWithAddonsv1alpha1()
+
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: addon.Name},
+		mock.IsType(&unstructured.Unstructured{}),
+	).Run(func(args mock.Arguments) {
+		obj := args.Get(2).(*unstructured.Unstructured)
+		require.NoError(t, controllerutil.SetControllerReference(addon, obj, scheme))
+	}).Return(nil)
+	var deleted []string
+	c.On("Delete",
+		testutil.IsContext,
+		mock.IsType(&unstructured.Unstructured{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		deleted = append(deleted, args.Get(1).(*unstructured.Unstructured).GetKind())
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: scheme,
+	}
+
+	done, err := r.teardownClusterExtension(context.Background(), addon)
+	require.NoError(t, err)
+	assert.False(t, done)
+
+	// the ClusterCatalog is only deleted after the ClusterExtension is gone
+	assert.Equal(t, []string{clusterExtensionGVK.Kind}, deleted)
+	if assert.NotNil(t, addon.Status.Teardown) {
+		assert.Equal(t, addonsv1alpha1.AddonTeardownStageClusterExtension, addon.Status.Teardown.Stage)
+		assert.Equal(t, []addonsv1alpha1.AddonObjectReference{{
+			APIVersion: clusterExtensionGVK.GroupVersion().String(),
+			Kind:       clusterExtensionGVK.Kind,
+			Name:       addon.Name,
+		}}, addon.Status.Teardown.PendingObjects)
+	}
+}
+
+func TestTeardownClusterExtension_NotInstalled(t *testing.T) {
+	addon := newTestClusterExtensionAddon()
+
+	c := testutil.NewClient()
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: addon.Name},
+		mock.IsType(&unstructured.Unstructured{}),
+	).Return(&meta.NoKindMatchError{GroupKind: clusterExtensionGVK.GroupKind()})
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	// OLM v1 APIs are not served, so there is nothing to delete
+	done, err := r.teardownClusterExtension(context.Background(), addon)
+	require.NoError(t, err)
+	assert.True(t, done)
+	c.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleAddonDeletion_WaitsForNamespaces(t *testing.T) {
+	addon := newTestAddonWithoutNamespace()
+	addon.Finalizers = []string{cacheFinalizer}
+
+	now := metav1.Now()
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "addon-1",
+			DeletionTimestamp: &now,
+		},
+	}
+	require.NoError(t, controllerutil.SetControllerReference(
+		addon, &namespace, newTestSchemeWithAddonsv1alpha1()))
+
+	c := testutil.NewClient()
+	c.On("List",
+		testutil.IsContext,
+		mock.IsType(&corev1.NamespaceList{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		args.Get(1).(*corev1.NamespaceList).Items = []corev1.Namespace{namespace}
+	}).Return(nil)
+	c.On("Get",
+		testutil.IsContext,
+		client.ObjectKey{Name: "addon-1"},
+		mock.IsType(&corev1.Namespace{}),
+	).Run(func(args mock.Arguments) {
+		namespace.DeepCopyInto(args.Get(2).(*corev1.Namespace))
+	}).Return(nil)
+	c.StatusMock.On("Update",
+		testutil.IsContext,
+		testutil.IsAddonsv1alpha1AddonPtr,
+		mock.Anything,
+	).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	requeue, err := r.handleAddonDeletion(context.Background(), addon)
+	require.NoError(t, err)
+	assert.True(t, requeue)
+
+	// already terminating, so no second delete request
+	c.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	// finalizer is kept until the Namespace is gone
+	c.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, []string{cacheFinalizer}, addon.Finalizers)
+
+	assert.Equal(t, addonsv1alpha1.PhaseTerminating, addon.Status.Phase)
+	availableCond := meta.FindStatusCondition(addon.Status.Conditions, addonsv1alpha1.Available)
+	if assert.NotNil(t, availableCond) {
+		assert.Equal(t, addonsv1alpha1.AddonReasonTerminating, availableCond.Reason)
+		assert.Equal(t,
+			"teardown stage Namespaces: waiting for deletion of Namespace addon-1",
+			availableCond.Message)
+	}
+}
+
+func TestTeardownNamespaces_Retain(t *testing.T) {
+	addon := newTestAddonWithoutNamespace()
+	addon.UID = "addon-uid"
+	addon.Spec.Namespaces = []addonsv1alpha1.AddonNamespace{
+		{Name: "addon-1", DeletionPolicy: addonsv1alpha1.NamespaceDeletionPolicyRetain},
+	}
+
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "addon-1",
+			Labels: map[string]string{},
+		},
+	}
+	addCommonLabels(namespace.Labels, addon)
+	require.NoError(t, controllerutil.SetControllerReference(
+		addon, &namespace, newTestSchemeWithAddonsv1alpha1()))
+
+	c := testutil.NewClient()
+	c.On("List",
+		testutil.IsContext,
+		mock.IsType(&corev1.NamespaceList{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		args.Get(1).(*corev1.NamespaceList).Items = []corev1.Namespace{namespace}
+	}).Return(nil)
+	var updatedNamespace *corev1.Namespace
+	c.On("Update",
+		testutil.IsContext,
+		mock.IsType(&corev1.Namespace{}),
+		mock.Anything,
+	).Run(func(args mock.Arguments) {
+		updatedNamespace = args.Get(1).(*corev1.Namespace)
+	}).Return(nil)
+
+	r := &AddonReconciler{
+		Client: c,
+		Log:    testutil.NewLogger(t),
+		Scheme: newTestSchemeWithAddonsv1alpha1(),
+	}
+
+	done, err := r.teardownNamespaces(context.Background(), addon)
+	require.NoError(t, err)
+	assert.True(t, done)
+	c.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+
+	if assert.NotNil(t, updatedNamespace) {
+		assert.Empty(t, updatedNamespace.OwnerReferences)
+		assert.Equal(t, "addon-1", updatedNamespace.Labels[commonInstanceLabel])
+	}
+	assert.Equal(t, []string{"addon-1"}, addon.Status.RetainedNamespaces)
+}
@@ -0,0 +1,78 @@
+package helm
+
+import (
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/addon-operator/internal/manifests"
+)
+
+// Annotation marking chart hooks.
+const hookAnnotation = "helm.sh/hook"
+
+// Release describes the Helm release a chart is rendered for.
+type Release struct {
+	Name      string
+	Namespace string
+	Revision  int
+}
+
+// Renders the given chart with values into objects, like `helm template` would.
+// Charts with CustomResourceDefinitions are refused,
+// as the addon-operator is not permitted to manage them.
+// Hooks are not supported and are left out.
+func Render(
+	chrt *chart.Chart, values map[string]interface{}, release Release,
+) ([]unstructured.Unstructured, error) {
+	if crds := chrt.CRDObjects(); len(crds) > 0 {
+		names := make([]string, 0, len(crds))
+		for _, crd := range crds {
+			names = append(names, crd.Filename)
+		}
+		return nil, fmt.Errorf("CustomResourceDefinitions are not supported: %s",
+			strings.Join(names, ", "))
+	}
+
+	if err := chartutil.ProcessDependencies(chrt, values); err != nil {
+		return nil, fmt.Errorf("processing dependencies: %w", err)
+	}
+
+	renderValues, err := chartutil.ToRenderValues(chrt, values, chartutil.ReleaseOptions{
+		Name:      release.Name,
+		Namespace: release.Namespace,
+		Revision:  release.Revision,
+		IsInstall: release.Revision <= 1,
+		IsUpgrade: release.Revision > 1,
+	}, chartutil.DefaultCapabilities)
+	if err != nil {
+		return nil, fmt.Errorf("composing values: %w", err)
+	}
+
+	rendered, err := engine.Render(chrt, renderValues)
+	if err != nil {
+		return nil, fmt.Errorf("rendering templates: %w", err)
+	}
+
+	templateFiles := make(map[string][]byte, len(rendered))
+	for name, content := range rendered {
+		templateFiles[name] = []byte(content)
+	}
+	templateObjects, err := manifests.Parse(templateFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []unstructured.Unstructured
+	for _, obj := range templateObjects {
+		if _, isHook := obj.GetAnnotations()[hookAnnotation]; isHook {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
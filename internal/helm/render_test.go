@@ -0,0 +1,102 @@
+package helm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func newTestChart() *chart.Chart {
+	return &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion: chart.APIVersionV2,
+			Name:       "test",
+			Version:    "1.0.0",
+		},
+		Values: map[string]interface{}{
+			"replicas": 1,
+		},
+		Templates: []*chart.File{
+			{
+				Name: "templates/deployment.yaml",
+				Data: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+  namespace: {{ .Release.Namespace }}
+  annotations:
+    revision: "{{ .Release.Revision }}"
+spec:
+  replicas: {{ .Values.replicas }}
+`),
+			},
+			{
+				Name: "templates/hook.yaml",
+				Data: []byte(`apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{ .Release.Name }}-hook
+  annotations:
+    helm.sh/hook: pre-install
+`),
+			},
+			{
+				Name: "templates/NOTES.txt",
+				Data: []byte("Thanks for installing {{ .Chart.Name }}"),
+			},
+			{
+				Name: "templates/_helpers.tpl",
+				Data: []byte(`{{- define "test.name" -}}test{{- end -}}`),
+			},
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	objects, err := Render(newTestChart(), map[string]interface{}{"replicas": 3}, Release{
+		Name:      "addon-1",
+		Namespace: "addon-ns",
+		Revision:  2,
+	})
+	require.NoError(t, err)
+
+	// hooks and non-manifest files are left out
+	if assert.Len(t, objects, 1) {
+		deployment := objects[0]
+		assert.Equal(t, "Deployment", deployment.GetKind())
+		assert.Equal(t, "addon-1", deployment.GetName())
+		assert.Equal(t, "addon-ns", deployment.GetNamespace())
+		assert.Equal(t, "2", deployment.GetAnnotations()["revision"])
+		assert.EqualValues(t, 3, deployment.Object["spec"].(map[string]interface{})["replicas"])
+	}
+}
+
+func TestRender_Invalid(t *testing.T) {
+	chrt := newTestChart()
+	chrt.Templates = append(chrt.Templates, &chart.File{
+		Name: "templates/invalid.yaml",
+		Data: []byte(`{{ .Values.missing.field }}`),
+	})
+
+	_, err := Render(chrt, nil, Release{Name: "addon-1", Revision: 1})
+	assert.Error(t, err)
+}
+
+func TestRender_CRDs(t *testing.T) {
+	chrt := newTestChart()
+	chrt.Files = append(chrt.Files, &chart.File{
+		Name: "crds/crd.yaml",
+		Data: []byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: tests.example.com
+`),
+	})
+
+	_, err := Render(chrt, nil, Release{Name: "addon-1", Revision: 1})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "crds/crd.yaml")
+	}
+}
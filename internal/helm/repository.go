@@ -0,0 +1,202 @@
+package helm
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// Upper bound for the size of repository indexes.
+	maxIndexSize = 8 << 20
+	// Upper bound for the size of chart archives.
+	maxArchiveSize = 4 << 20
+	// Upper bound for the total size of cached chart archives.
+	maxCacheSize = 2 * maxArchiveSize
+)
+
+// ChartLoader loads charts from Helm chart repositories.
+// Chart archives are cached by repository, name and version,
+// as published chart versions are not expected to change.
+// The least recently used archives are evicted,
+// when the cache grows beyond its size limit.
+type ChartLoader struct {
+	client *http.Client
+
+	mux          sync.Mutex
+	cache        map[string]*list.Element
+	cacheLRU     *list.List // front is most recently used
+	cacheSize    int
+	maxCacheSize int
+}
+
+type cachedArchive struct {
+	key     string
+	archive []byte
+}
+
+// Creates a new ChartLoader using the given HTTP client.
+// If client is nil, http.DefaultClient is used.
+func NewChartLoader(client *http.Client) *ChartLoader {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &ChartLoader{
+		client:       client,
+		cache:        map[string]*list.Element{},
+		cacheLRU:     list.New(),
+		maxCacheSize: maxCacheSize,
+	}
+}
+
+// Subset of the Helm repository index.
+type repositoryIndex struct {
+	Entries map[string][]repositoryIndexEntry `json:"entries"`
+}
+
+type repositoryIndexEntry struct {
+	Version string   `json:"version"`
+	URLs    []string `json:"urls"`
+	Digest  string   `json:"digest"`
+}
+
+// Loads the chart with the given name and version from the repository.
+// Every call returns a new chart, as rendering modifies the chart.
+func (l *ChartLoader) Load(
+	ctx context.Context, repositoryURL, name, version string) (*chart.Chart, error) {
+	cacheKey := repositoryURL + "/" + name + ":" + version
+	archive, ok := l.getCached(cacheKey)
+	if !ok {
+		var err error
+		archive, err = l.download(ctx, repositoryURL, name, version)
+		if err != nil {
+			return nil, err
+		}
+		l.addCached(cacheKey, archive)
+	}
+
+	chrt, err := loader.LoadArchive(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("loading chart archive: %w", err)
+	}
+	return chrt, nil
+}
+
+// Returns the cached archive for the given key and marks it as recently used.
+func (l *ChartLoader) getCached(key string) ([]byte, bool) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	elem, ok := l.cache[key]
+	if !ok {
+		return nil, false
+	}
+	l.cacheLRU.MoveToFront(elem)
+	return elem.Value.(*cachedArchive).archive, true
+}
+
+// Adds the archive to the cache and evicts the least recently used archives,
+// until the cache fits into its size limit again.
+func (l *ChartLoader) addCached(key string, archive []byte) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if elem, ok := l.cache[key]; ok {
+		// added concurrently
+		l.cacheLRU.MoveToFront(elem)
+		return
+	}
+	l.cache[key] = l.cacheLRU.PushFront(&cachedArchive{key: key, archive: archive})
+	l.cacheSize += len(archive)
+
+	for l.cacheSize > l.maxCacheSize && l.cacheLRU.Len() > 1 {
+		oldest := l.cacheLRU.Remove(l.cacheLRU.Back()).(*cachedArchive)
+		delete(l.cache, oldest.key)
+		l.cacheSize -= len(oldest.archive)
+	}
+}
+
+// Downloads the archive of the chart with the given name and version from the repository.
+func (l *ChartLoader) download(
+	ctx context.Context, repositoryURL, name, version string) ([]byte, error) {
+	repoURL, err := url.Parse(strings.TrimSuffix(repositoryURL, "/") + "/")
+	if err != nil {
+		return nil, fmt.Errorf("parsing repository URL: %w", err)
+	}
+
+	indexData, err := l.get(ctx,
+		repoURL.ResolveReference(&url.URL{Path: "index.yaml"}).String(), maxIndexSize)
+	if err != nil {
+		return nil, fmt.Errorf("getting repository index: %w", err)
+	}
+	index := &repositoryIndex{}
+	if err := yaml.Unmarshal(indexData, index); err != nil {
+		return nil, fmt.Errorf("parsing repository index: %w", err)
+	}
+
+	var entry *repositoryIndexEntry
+	for i := range index.Entries[name] {
+		if strings.TrimPrefix(index.Entries[name][i].Version, "v") == strings.TrimPrefix(version, "v") {
+			entry = &index.Entries[name][i]
+			break
+		}
+	}
+	if entry == nil || len(entry.URLs) == 0 {
+		return nil, fmt.Errorf("chart %s version %s not found in repository %s", name, version, repositoryURL)
+	}
+
+	chartURL, err := url.Parse(entry.URLs[0])
+	if err != nil {
+		return nil, fmt.Errorf("parsing chart URL: %w", err)
+	}
+	archive, err := l.get(ctx, repoURL.ResolveReference(chartURL).String(), maxArchiveSize)
+	if err != nil {
+		return nil, fmt.Errorf("getting chart archive: %w", err)
+	}
+	if len(entry.Digest) > 0 {
+		sum := sha256.Sum256(archive)
+		if hex.EncodeToString(sum[:]) != strings.TrimPrefix(entry.Digest, "sha256:") {
+			return nil, fmt.Errorf("chart archive does not match digest %s", entry.Digest)
+		}
+	}
+
+	return archive, nil
+}
+
+// GETs the given URL, failing if the response is larger than maxSize bytes.
+func (l *ChartLoader) get(ctx context.Context, u string, maxSize int) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: unexpected status %s", u, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxSize {
+		return nil, fmt.Errorf("GET %s: response exceeds %d bytes", u, maxSize)
+	}
+	return body, nil
+}
@@ -0,0 +1,128 @@
+package helm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+func newTestChartArchive(t *testing.T) []byte {
+	t.Helper()
+
+	dir := t.TempDir()
+	path, err := chartutil.Save(newTestChart(), dir)
+	require.NoError(t, err)
+	archive, err := ioutil.ReadFile(filepath.Clean(path))
+	require.NoError(t, err)
+	return archive
+}
+
+func newTestRepository(t *testing.T, archive []byte, digest string) (*httptest.Server, *int) {
+	t.Helper()
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/charts/index.yaml":
+			_, _ = w.Write([]byte(`apiVersion: v1
+entries:
+  test:
+  - version: 0.9.0
+    urls: [test-0.9.0.tgz]
+  - version: 1.0.0
+    urls: [archives/test-1.0.0.tgz]
+    digest: ` + digest + `
+`))
+		case "/charts/archives/test-1.0.0.tgz":
+			_, _ = w.Write(archive)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestChartLoader_Load(t *testing.T) {
+	archive := newTestChartArchive(t)
+	sum := sha256.Sum256(archive)
+	server, requests := newTestRepository(t, archive, hex.EncodeToString(sum[:]))
+
+	loader := NewChartLoader(server.Client())
+	chrt, err := loader.Load(context.Background(), server.URL+"/charts", "test", "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, "test", chrt.Name())
+	assert.Equal(t, "1.0.0", chrt.Metadata.Version)
+
+	// charts are cached
+	requestsBefore := *requests
+	_, err = loader.Load(context.Background(), server.URL+"/charts", "test", "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, requestsBefore, *requests)
+}
+
+func TestChartLoader_Load_NotFound(t *testing.T) {
+	server, _ := newTestRepository(t, nil, "")
+
+	_, err := NewChartLoader(server.Client()).
+		Load(context.Background(), server.URL+"/charts", "test", "2.0.0")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "not found")
+	}
+}
+
+func TestChartLoader_Load_DigestMismatch(t *testing.T) {
+	server, _ := newTestRepository(t, newTestChartArchive(t), "0123")
+
+	_, err := NewChartLoader(server.Client()).
+		Load(context.Background(), server.URL+"/charts", "test", "1.0.0")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "does not match digest")
+	}
+}
+
+func TestChartLoader_Load_TooLarge(t *testing.T) {
+	server, _ := newTestRepository(t, make([]byte, maxArchiveSize+1), "")
+
+	_, err := NewChartLoader(server.Client()).
+		Load(context.Background(), server.URL+"/charts", "test", "1.0.0")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "response exceeds")
+	}
+}
+
+func TestChartLoader_CacheEviction(t *testing.T) {
+	loader := NewChartLoader(nil)
+	loader.maxCacheSize = 10
+
+	loader.addCached("a", []byte("aaaa"))
+	loader.addCached("b", []byte("bbbb"))
+	// mark "a" as recently used
+	_, ok := loader.getCached("a")
+	require.True(t, ok)
+	loader.addCached("c", []byte("cccc"))
+
+	_, ok = loader.getCached("b")
+	assert.False(t, ok, "least recently used archive should be evicted")
+	_, ok = loader.getCached("a")
+	assert.True(t, ok)
+	_, ok = loader.getCached("c")
+	assert.True(t, ok)
+	assert.Equal(t, 8, loader.cacheSize)
+
+	// archives larger than the limit are still kept until the next one is added
+	loader.addCached("d", []byte("dddddddddddd"))
+	_, ok = loader.getCached("d")
+	assert.True(t, ok)
+	assert.Equal(t, 1, loader.cacheLRU.Len())
+}
@@ -0,0 +1,455 @@
+package manifests
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Upper bound for the size of a single manifest or layer blob.
+	maxBlobSize = 32 << 20
+	// Upper bound for the total size of cached manifest files.
+	maxCacheSize = 8 << 20
+	// Images referenced by tag are pulled again after this duration,
+	// instead of on every reconcile.
+	tagCacheDuration = 5 * time.Minute
+
+	dockerHubRegistry = "registry-1.docker.io"
+)
+
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+}
+
+// ImagePuller pulls manifest files from OCI images,
+// using the OCI distribution API with anonymous bearer token authentication.
+// Images referenced by digest are cached, as their content never changes.
+// Images referenced by tag are cached for a short duration,
+// so tags are not resolved again on every pull.
+// The least recently used images are evicted,
+// when the cache grows beyond its size limit.
+type ImagePuller struct {
+	client *http.Client
+
+	mux              sync.Mutex
+	cache            map[string]*list.Element
+	cacheLRU         *list.List // front is most recently used
+	cacheSize        int
+	maxCacheSize     int
+	tagCacheDuration time.Duration
+}
+
+type cachedImage struct {
+	image string
+	files map[string][]byte
+	size  int
+	// zero for images referenced by digest
+	expires time.Time
+}
+
+// Creates a new ImagePuller using the given HTTP client.
+// If client is nil, http.DefaultClient is used.
+func NewImagePuller(client *http.Client) *ImagePuller {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &ImagePuller{
+		client:           client,
+		cache:            map[string]*list.Element{},
+		cacheLRU:         list.New(),
+		maxCacheSize:     maxCacheSize,
+		tagCacheDuration: tagCacheDuration,
+	}
+}
+
+// Pulls all manifest files from the given image.
+// Layers are applied in order, so files of later layers replace files of earlier layers.
+// Returns file contents by path within the image, which must not be modified.
+func (p *ImagePuller) Pull(ctx context.Context, image string) (map[string][]byte, error) {
+	ref, err := parseImageReference(image)
+	if err != nil {
+		return nil, err
+	}
+
+	if files, ok := p.getCached(image); ok {
+		return files, nil
+	}
+
+	rc := &registryClient{client: p.client, ref: ref}
+	files, err := rc.pullFiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("pulling image %s: %w", image, err)
+	}
+
+	var expires time.Time
+	if !ref.isDigest() {
+		expires = time.Now().Add(p.tagCacheDuration)
+	}
+	p.addCached(image, files, expires)
+	return files, nil
+}
+
+// Returns the cached files of the given image and marks them as recently used.
+func (p *ImagePuller) getCached(image string) (map[string][]byte, bool) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	elem, ok := p.cache[image]
+	if !ok {
+		return nil, false
+	}
+	cached := elem.Value.(*cachedImage)
+	if !cached.expires.IsZero() && time.Now().After(cached.expires) {
+		p.removeCached(elem)
+		return nil, false
+	}
+	p.cacheLRU.MoveToFront(elem)
+	return cached.files, true
+}
+
+// Adds the files of the image to the cache and evicts the least recently used images,
+// until the cache fits into its size limit again.
+// Images larger than the size limit are not cached.
+func (p *ImagePuller) addCached(image string, files map[string][]byte, expires time.Time) {
+	var size int
+	for _, content := range files {
+		size += len(content)
+	}
+	if size > p.maxCacheSize {
+		return
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if elem, ok := p.cache[image]; ok {
+		// added concurrently
+		p.removeCached(elem)
+	}
+	p.cache[image] = p.cacheLRU.PushFront(&cachedImage{
+		image: image, files: files, size: size, expires: expires,
+	})
+	p.cacheSize += size
+
+	for p.cacheSize > p.maxCacheSize {
+		p.removeCached(p.cacheLRU.Back())
+	}
+}
+
+// Removes the given element from the cache, the caller has to hold the lock.
+func (p *ImagePuller) removeCached(elem *list.Element) {
+	cached := p.cacheLRU.Remove(elem).(*cachedImage)
+	delete(p.cache, cached.image)
+	p.cacheSize -= cached.size
+}
+
+type imageReference struct {
+	registry   string
+	repository string
+	// tag or digest
+	reference string
+}
+
+func (r imageReference) isDigest() bool {
+	return strings.Contains(r.reference, ":")
+}
+
+// Parses image references in the form of [registry/]repository[:tag|@digest].
+func parseImageReference(image string) (imageReference, error) {
+	ref := imageReference{}
+	name := image
+	if i := strings.Index(name, "@"); i != -1 {
+		name, ref.reference = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.reference = name[:i], name[i+1:]
+	} else {
+		ref.reference = "latest"
+	}
+
+	if i := strings.Index(name, "/"); i != -1 &&
+		(strings.ContainsAny(name[:i], ".:") || name[:i] == "localhost") {
+		ref.registry, ref.repository = name[:i], name[i+1:]
+	} else {
+		ref.registry, ref.repository = "docker.io", name
+	}
+	if ref.registry == "docker.io" {
+		ref.registry = dockerHubRegistry
+		if !strings.Contains(ref.repository, "/") {
+			ref.repository = "library/" + ref.repository
+		}
+	}
+
+	if len(ref.repository) == 0 || len(ref.reference) == 0 {
+		return imageReference{}, fmt.Errorf("invalid image reference %q", image)
+	}
+	return ref, nil
+}
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+// Image manifest or image index.
+type ociManifest struct {
+	Layers    []ociDescriptor `json:"layers"`
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+// Talks to a single repository of an OCI registry.
+type registryClient struct {
+	client *http.Client
+	ref    imageReference
+	token  string
+}
+
+func (c *registryClient) pullFiles(ctx context.Context) (map[string][]byte, error) {
+	manifest, err := c.getManifest(ctx, c.ref.reference)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Manifests) > 0 {
+		// Manifests are platform independent, any image of an index will do.
+		manifest, err = c.getManifest(ctx, manifest.Manifests[0].Digest)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	files := map[string][]byte{}
+	for _, layer := range manifest.Layers {
+		blob, err := c.get(ctx, "blobs/"+layer.Digest, "")
+		if err != nil {
+			return nil, fmt.Errorf("getting layer %s: %w", layer.Digest, err)
+		}
+		if err := verifyDigest(blob, layer.Digest); err != nil {
+			return nil, err
+		}
+		if err := extractManifestFiles(blob, files); err != nil {
+			return nil, fmt.Errorf("extracting layer %s: %w", layer.Digest, err)
+		}
+	}
+	return files, nil
+}
+
+func (c *registryClient) getManifest(ctx context.Context, reference string) (*ociManifest, error) {
+	body, err := c.get(ctx, "manifests/"+reference, strings.Join(manifestMediaTypes, ", "))
+	if err != nil {
+		return nil, fmt.Errorf("getting manifest %s: %w", reference, err)
+	}
+	if strings.Contains(reference, ":") {
+		if err := verifyDigest(body, reference); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest := &ociManifest{}
+	if err := json.Unmarshal(body, manifest); err != nil {
+		return nil, fmt.Errorf("parsing manifest %s: %w", reference, err)
+	}
+	return manifest, nil
+}
+
+// GETs the given path relative to the repository,
+// authenticating with a bearer token if the registry asks for one.
+func (c *registryClient) get(ctx context.Context, subPath, accept string) ([]byte, error) {
+	u := url.URL{
+		Scheme: "https",
+		Host:   c.ref.registry,
+		Path:   path.Join("/v2", c.ref.repository, subPath),
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(accept) > 0 {
+			req.Header.Set("Accept", accept)
+		}
+		if len(c.token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := readLimited(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			if err := c.authenticate(ctx, resp.Header.Get("WWW-Authenticate")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("GET %s: unexpected status %s", u.String(), resp.Status)
+		}
+		return body, nil
+	}
+}
+
+// Fetches an anonymous pull token as described by the given WWW-Authenticate challenge.
+func (c *registryClient) authenticate(ctx context.Context, challenge string) error {
+	params, ok := parseBearerChallenge(challenge)
+	if !ok || len(params["realm"]) == 0 {
+		return fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+
+	u, err := url.Parse(params["realm"])
+	if err != nil {
+		return fmt.Errorf("parsing token realm: %w", err)
+	}
+	query := u.Query()
+	if service := params["service"]; len(service) > 0 {
+		query.Set("service", service)
+	}
+	query.Set("scope", "repository:"+c.ref.repository+":pull")
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("getting token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("getting token: unexpected status %s", resp.Status)
+	}
+
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return fmt.Errorf("parsing token response: %w", err)
+	}
+	c.token = tokenResponse.Token
+	if len(c.token) == 0 {
+		c.token = tokenResponse.AccessToken
+	}
+	return nil
+}
+
+// Parses a challenge in the form of: Bearer realm="...",service="...",scope="..."
+func parseBearerChallenge(challenge string) (map[string]string, bool) {
+	const prefix = "bearer "
+	if len(challenge) < len(prefix) ||
+		!strings.EqualFold(challenge[:len(prefix)], prefix) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for _, param := range strings.Split(challenge[len(prefix):], ",") {
+		kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		params[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+	}
+	return params, true
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(r, maxBlobSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxBlobSize {
+		return nil, fmt.Errorf("blob exceeds %d bytes", maxBlobSize)
+	}
+	return body, nil
+}
+
+func verifyDigest(content []byte, digest string) error {
+	const prefix = "sha256:"
+	if !strings.HasPrefix(digest, prefix) {
+		return fmt.Errorf("unsupported digest algorithm in %q", digest)
+	}
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != digest[len(prefix):] {
+		return fmt.Errorf("content does not match digest %s", digest)
+	}
+	return nil
+}
+
+// Extracts manifest files from a, possibly gzip compressed, tar layer into files.
+// Honors whiteout files, which delete files of earlier layers.
+func extractManifestFiles(layer []byte, files map[string][]byte) error {
+	var r io.Reader = bytes.NewReader(layer)
+	if bytes.HasPrefix(layer, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		dir, base := path.Split(name)
+		switch {
+		case base == ".wh..wh..opq":
+			for file := range files {
+				if strings.HasPrefix(file, dir) {
+					delete(files, file)
+				}
+			}
+			continue
+		case strings.HasPrefix(base, ".wh."):
+			deleted := dir + strings.TrimPrefix(base, ".wh.")
+			for file := range files {
+				if file == deleted || strings.HasPrefix(file, deleted+"/") {
+					delete(files, file)
+				}
+			}
+			continue
+		}
+
+		if hdr.Typeflag != tar.TypeReg || !IsManifestFile(name) {
+			continue
+		}
+		content, err := readLimited(tr)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", name, err)
+		}
+		files[name] = content
+	}
+}
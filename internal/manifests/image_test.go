@@ -0,0 +1,220 @@
+package manifests
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImageReference(t *testing.T) {
+	testCases := []struct {
+		image    string
+		expected imageReference
+	}{
+		{
+			image: "quay.io/osd-addons/manifests@sha256:abc",
+			expected: imageReference{
+				registry: "quay.io", repository: "osd-addons/manifests", reference: "sha256:abc",
+			},
+		},
+		{
+			image: "localhost:5000/manifests:v1",
+			expected: imageReference{
+				registry: "localhost:5000", repository: "manifests", reference: "v1",
+			},
+		},
+		{
+			image: "busybox",
+			expected: imageReference{
+				registry: dockerHubRegistry, repository: "library/busybox", reference: "latest",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.image, func(t *testing.T) {
+			ref, err := parseImageReference(tc.image)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ref)
+		})
+	}
+}
+
+func TestImagePuller_Pull(t *testing.T) {
+	layer1 := newTestLayer(t, true, map[string]string{
+		"manifests/a.yaml":  "a",
+		"manifests/b.yaml":  "b",
+		"manifests/README":  "not a manifest",
+		"manifests/c.json":  "c",
+		"other/ignored.txt": "ignored",
+	})
+	layer2 := newTestLayer(t, false, map[string]string{
+		"manifests/.wh.b.yaml": "",
+		"manifests/c.json":     "c2",
+	})
+	manifest := newTestBlob(t, map[string]interface{}{
+		"mediaType": "application/vnd.oci.image.manifest.v1+json",
+		"layers": []map[string]string{
+			{"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": digest(layer1)},
+			{"mediaType": "application/vnd.oci.image.layer.v1.tar", "digest": digest(layer2)},
+		},
+	})
+	index := newTestBlob(t, map[string]interface{}{
+		"mediaType": "application/vnd.oci.image.index.v1+json",
+		"manifests": []map[string]string{
+			{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": digest(manifest)},
+		},
+	})
+	blobs := map[string][]byte{
+		"manifests/" + digest(index):    index,
+		"manifests/" + digest(manifest): manifest,
+		"blobs/" + digest(layer1):       layer1,
+		"blobs/" + digest(layer2):       layer2,
+	}
+
+	var requests int
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/token" {
+			assert.Equal(t, "repository:addons/test:pull", r.URL.Query().Get("scope"))
+			_, _ = w.Write([]byte(`{"token": "secret"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate",
+				`Bearer realm="`+server.URL+`/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		blob, ok := blobs[strings.TrimPrefix(r.URL.Path, "/v2/addons/test/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(blob)
+	}))
+	defer server.Close()
+
+	image := strings.TrimPrefix(server.URL, "https://") + "/addons/test@" + digest(index)
+	puller := NewImagePuller(server.Client())
+	files, err := puller.Pull(context.Background(), image)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"manifests/a.yaml": []byte("a"),
+		"manifests/c.json": []byte("c2"),
+	}, files)
+
+	// images referenced by digest are cached
+	requestsBefore := requests
+	_, err = puller.Pull(context.Background(), image)
+	require.NoError(t, err)
+	assert.Equal(t, requestsBefore, requests)
+
+	// images referenced by tag are cached until they expire
+	blobs["manifests/v1"] = index
+	tagImage := strings.TrimPrefix(server.URL, "https://") + "/addons/test:v1"
+	_, err = puller.Pull(context.Background(), tagImage)
+	require.NoError(t, err)
+	requestsBefore = requests
+	_, err = puller.Pull(context.Background(), tagImage)
+	require.NoError(t, err)
+	assert.Equal(t, requestsBefore, requests)
+
+	puller.cache[tagImage].Value.(*cachedImage).expires = time.Now().Add(-time.Second)
+	_, err = puller.Pull(context.Background(), tagImage)
+	require.NoError(t, err)
+	assert.Greater(t, requests, requestsBefore)
+}
+
+func TestImagePuller_CacheEviction(t *testing.T) {
+	puller := NewImagePuller(nil)
+	puller.maxCacheSize = 10
+
+	puller.addCached("a", map[string][]byte{"a.yaml": []byte("aaaa")}, time.Time{})
+	puller.addCached("b", map[string][]byte{"b.yaml": []byte("bbbb")}, time.Time{})
+	// mark "a" as recently used
+	_, ok := puller.getCached("a")
+	require.True(t, ok)
+	puller.addCached("c", map[string][]byte{"c.yaml": []byte("cccc")}, time.Time{})
+
+	_, ok = puller.getCached("b")
+	assert.False(t, ok, "least recently used image should be evicted")
+	_, ok = puller.getCached("a")
+	assert.True(t, ok)
+	_, ok = puller.getCached("c")
+	assert.True(t, ok)
+	assert.Equal(t, 8, puller.cacheSize)
+
+	// images larger than the limit are not cached
+	puller.addCached("d", map[string][]byte{"d.yaml": []byte("dddddddddddd")}, time.Time{})
+	_, ok = puller.getCached("d")
+	assert.False(t, ok)
+	assert.Equal(t, 2, puller.cacheLRU.Len())
+}
+
+func TestImagePuller_Pull_DigestMismatch(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"layers": []}`))
+	}))
+	defer server.Close()
+
+	image := strings.TrimPrefix(server.URL, "https://") + "/addons/test@" + digest([]byte("other"))
+	_, err := NewImagePuller(server.Client()).Pull(context.Background(), image)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "does not match digest")
+	}
+}
+
+func newTestLayer(t *testing.T, compress bool, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	if !compress {
+		return buf.Bytes()
+	}
+
+	var gzBuf bytes.Buffer
+	gz := gzip.NewWriter(&gzBuf)
+	_, err := gz.Write(buf.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return gzBuf.Bytes()
+}
+
+func newTestBlob(t *testing.T, obj interface{}) []byte {
+	t.Helper()
+
+	blob, err := json.Marshal(obj)
+	require.NoError(t, err)
+	return blob
+}
+
+func digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,130 @@
+package manifests
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Kinds of objects that can be installed from manifests.
+// The addon-operator is only granted permissions for these kinds,
+// see the ClusterRole in config/deploy/rbac.yaml.
+var SupportedKinds = map[schema.GroupKind]bool{
+	{Kind: "ConfigMap"}:                  true,
+	{Kind: "Secret"}:                     true,
+	{Kind: "Service"}:                    true,
+	{Kind: "ServiceAccount"}:             true,
+	{Group: "apps", Kind: "Deployment"}:  true,
+	{Group: "apps", Kind: "StatefulSet"}: true,
+	{Group: "apps", Kind: "DaemonSet"}:   true,
+	{Group: "batch", Kind: "Job"}:        true,
+}
+
+// Returns an error naming all objects of kinds that are not in SupportedKinds.
+func ValidateKinds(objects []unstructured.Unstructured) error {
+	var unsupported []string
+	for _, obj := range objects {
+		gk := obj.GroupVersionKind().GroupKind()
+		if SupportedKinds[gk] {
+			continue
+		}
+		unsupported = append(unsupported, fmt.Sprintf("%s %s", gk, obj.GetName()))
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("unsupported kinds: %s", strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
+// Checks if the given file name has an extension of a manifest file.
+func IsManifestFile(name string) bool {
+	switch path.Ext(name) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// Parses the manifest files into objects.
+// Files without a manifest file extension are ignored,
+// all other files are parsed in lexical order of their names.
+// Files may contain multiple YAML documents and Lists, empty documents are skipped.
+func Parse(files map[string][]byte) ([]unstructured.Unstructured, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if IsManifestFile(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var objects []unstructured.Unstructured
+	for _, name := range names {
+		fileObjects, err := parseFile(files[name])
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", name, err)
+		}
+		objects = append(objects, fileObjects...)
+	}
+	return objects, nil
+}
+
+func parseFile(content []byte) ([]unstructured.Unstructured, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 4096)
+
+	var objects []unstructured.Unstructured
+	for i := 0; ; i++ {
+		obj := unstructured.Unstructured{}
+		err := decoder.Decode(&obj.Object)
+		if errors.Is(err, io.EOF) {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+
+		if obj.IsList() {
+			err := obj.EachListItem(func(item runtime.Object) error {
+				itemObj := item.(*unstructured.Unstructured)
+				if err := validateObject(itemObj); err != nil {
+					return err
+				}
+				objects = append(objects, *itemObj)
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("document %d: %w", i, err)
+			}
+			continue
+		}
+
+		if err := validateObject(&obj); err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		objects = append(objects, obj)
+	}
+}
+
+func validateObject(obj *unstructured.Unstructured) error {
+	switch {
+	case len(obj.GetAPIVersion()) == 0:
+		return errors.New("apiVersion is required")
+	case len(obj.GetKind()) == 0:
+		return errors.New("kind is required")
+	case len(obj.GetName()) == 0:
+		return fmt.Errorf("%s: metadata.name is required", obj.GetKind())
+	}
+	return nil
+}
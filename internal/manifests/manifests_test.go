@@ -0,0 +1,102 @@
+package manifests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	files := map[string][]byte{
+		"b.yaml": []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: second
+---
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: third
+`),
+		"a.json": []byte(`{"apiVersion": "v1", "kind": "ServiceAccount", "metadata": {"name": "first"}}`),
+		"c.yml": []byte(`
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: fourth
+`),
+		"README.md": []byte("# not a manifest"),
+	}
+
+	objects, err := Parse(files)
+	require.NoError(t, err)
+
+	var names []string
+	for _, obj := range objects {
+		names = append(names, obj.GetKind()+"/"+obj.GetName())
+	}
+	assert.Equal(t, []string{
+		"ServiceAccount/first",
+		"ConfigMap/second",
+		"Deployment/third",
+		"Service/fourth",
+	}, names)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "missing name",
+			content: "apiVersion: v1\nkind: ConfigMap\n",
+		},
+		{
+			name:    "missing kind",
+			content: "apiVersion: v1\nmetadata:\n  name: test\n",
+		},
+		{
+			name:    "invalid yaml",
+			content: "apiVersion: [v1\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(map[string][]byte{"test.yaml": []byte(tc.content)})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), "test.yaml")
+			}
+		})
+	}
+}
+
+func TestValidateKinds(t *testing.T) {
+	objects, err := Parse(map[string][]byte{
+		"manifests.yaml": []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: test
+`),
+	})
+	require.NoError(t, err)
+
+	err = ValidateKinds(objects)
+	if assert.Error(t, err) {
+		assert.Equal(t, "unsupported kinds: ClusterRole.rbac.authorization.k8s.io test", err.Error())
+	}
+	assert.NoError(t, ValidateKinds(objects[:1]))
+}
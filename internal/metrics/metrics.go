@@ -0,0 +1,237 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+)
+
+const namespace = "addon_operator"
+
+// Recorder records addon-operator metrics.
+// All methods are safe to call on a nil Recorder, which records nothing.
+type Recorder struct {
+	addons          *prometheus.GaugeVec
+	addonInfo       *prometheus.GaugeVec
+	paused          prometheus.Gauge
+	addonPaused     *prometheus.GaugeVec
+	phaseDuration   *prometheus.HistogramVec
+	timeToAvailable prometheus.Histogram
+	addonStates     map[string]addonState
+	addonInfoLabels map[string]prometheus.Labels
+	mux             sync.Mutex
+	now             func() time.Time
+}
+
+// Last observed state of an Addon.
+type addonState struct {
+	phase            addonsv1alpha1.AddonPhase
+	reason           string
+	available        bool
+	unavailableSince time.Time
+}
+
+// Creates a new Recorder.
+// When register is true, metrics are registered with the controller-runtime metrics registry.
+func NewRecorder(register bool) *Recorder {
+	r := &Recorder{
+		addons: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "addons",
+			Help:      "Number of Addons by phase and reason of the Available condition.",
+		}, []string{"phase", "reason"}),
+		addonInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "addon_info",
+			Help:      "Information about an Addon, always 1.",
+		}, []string{"name", "package", "channel", "installed_csv"}),
+		paused: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "paused",
+			Help:      "Whether reconciliation of all Addons is paused (1) or not (0).",
+		}),
+		addonPaused: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "addon_paused",
+			Help:      "Whether reconciliation of an Addon is paused (1) or not (0).",
+		}, []string{"name"}),
+		phaseDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "addon_reconcile_phase_duration_seconds",
+			Help:      "Duration of the phases of Addon reconciliation.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+		}, []string{"phase"}),
+		timeToAvailable: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "addon_time_to_available_seconds",
+			Help:      "Time it took an Addon to become Available, since it was created or became unavailable.",
+			// 10s to ~11h
+			Buckets: prometheus.ExponentialBuckets(10, 2, 13),
+		}),
+		addonStates:     map[string]addonState{},
+		addonInfoLabels: map[string]prometheus.Labels{},
+		now:             time.Now,
+	}
+
+	if register {
+		ctrlmetrics.Registry.MustRegister(r.collectors()...)
+	}
+	return r
+}
+
+func (r *Recorder) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		r.addons,
+		r.addonInfo,
+		r.paused,
+		r.addonPaused,
+		r.phaseDuration,
+		r.timeToAvailable,
+	}
+}
+
+// Records the status of the given Addon.
+func (r *Recorder) RecordAddon(addon *addonsv1alpha1.Addon) {
+	if r == nil {
+		return
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	now := r.now()
+	state := addonState{
+		phase:            addon.Status.Phase,
+		unavailableSince: addon.CreationTimestamp.Time,
+	}
+	if cond := meta.FindStatusCondition(
+		addon.Status.Conditions, addonsv1alpha1.Available); cond != nil {
+		state.reason = cond.Reason
+		state.available = cond.Status == metav1.ConditionTrue
+		if !state.available && !cond.LastTransitionTime.IsZero() {
+			state.unavailableSince = cond.LastTransitionTime.Time
+		}
+	}
+
+	if previous, ok := r.addonStates[addon.Name]; ok {
+		if !previous.available && state.available {
+			r.timeToAvailable.Observe(now.Sub(previous.unavailableSince).Seconds())
+		}
+		if !previous.available && !state.available {
+			// keep tracking from when the Addon first became unavailable
+			state.unavailableSince = previous.unavailableSince
+		}
+	}
+	r.addonStates[addon.Name] = state
+	r.updateAddonsGauge()
+
+	var paused float64
+	if addon.Spec.Paused {
+		paused = 1
+	}
+	r.addonPaused.WithLabelValues(addon.Name).Set(paused)
+}
+
+// Records package, channel and installed ClusterServiceVersion of the given Addon.
+func (r *Recorder) RecordAddonInfo(
+	addon *addonsv1alpha1.Addon, packageName, channel, installedCSV string) {
+	if r == nil {
+		return
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	labels := prometheus.Labels{
+		"name":          addon.Name,
+		"package":       packageName,
+		"channel":       channel,
+		"installed_csv": installedCSV,
+	}
+	if previous, ok := r.addonInfoLabels[addon.Name]; ok {
+		r.addonInfo.Delete(previous)
+	}
+	r.addonInfoLabels[addon.Name] = labels
+	r.addonInfo.With(labels).Set(1)
+}
+
+// Removes all metrics of the Addon with the given name.
+func (r *Recorder) ForgetAddon(name string) {
+	if r == nil {
+		return
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	delete(r.addonStates, name)
+	r.updateAddonsGauge()
+	if labels, ok := r.addonInfoLabels[name]; ok {
+		r.addonInfo.Delete(labels)
+		delete(r.addonInfoLabels, name)
+	}
+	r.addonPaused.DeleteLabelValues(name)
+}
+
+// Records whether reconciliation of all Addons is paused.
+func (r *Recorder) SetGlobalPause(paused bool) {
+	if r == nil {
+		return
+	}
+	if paused {
+		r.paused.Set(1)
+		return
+	}
+	r.paused.Set(0)
+}
+
+// Starts timing the phases of a single Addon reconciliation.
+func (r *Recorder) NewPhaseTimer() *PhaseTimer {
+	return &PhaseTimer{recorder: r}
+}
+
+// Recomputes the addons gauge from the last observed Addon states.
+// Must be called with r.mux held.
+func (r *Recorder) updateAddonsGauge() {
+	type key struct {
+		phase  addonsv1alpha1.AddonPhase
+		reason string
+	}
+	counts := map[key]float64{}
+	for _, state := range r.addonStates {
+		counts[key{phase: state.phase, reason: state.reason}]++
+	}
+
+	r.addons.Reset()
+	for k, count := range counts {
+		r.addons.WithLabelValues(string(k.phase), k.reason).Set(count)
+	}
+}
+
+// PhaseTimer records the duration of consecutive reconcile phases.
+type PhaseTimer struct {
+	recorder *Recorder
+	phase    string
+	start    time.Time
+}
+
+// Ends the running phase and starts the given one.
+func (t *PhaseTimer) Phase(phase string) {
+	t.Done()
+	t.phase = phase
+	t.start = time.Now()
+}
+
+// Ends the running phase.
+func (t *PhaseTimer) Done() {
+	if t.recorder == nil || len(t.phase) == 0 {
+		return
+	}
+	t.recorder.phaseDuration.
+		WithLabelValues(t.phase).
+		Observe(time.Since(t.start).Seconds())
+	t.phase = ""
+}
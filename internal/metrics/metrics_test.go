@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+)
+
+func newTestAddon(name string, phase addonsv1alpha1.AddonPhase,
+	status metav1.ConditionStatus, reason string) *addonsv1alpha1.Addon {
+	addon := &addonsv1alpha1.Addon{}
+	addon.Name = name
+	addon.Status.Phase = phase
+	meta.SetStatusCondition(&addon.Status.Conditions, metav1.Condition{
+		Type:   addonsv1alpha1.Available,
+		Status: status,
+		Reason: reason,
+	})
+	return addon
+}
+
+// Gathers all samples of the metric with the given name as label string -> value.
+func gather(t *testing.T, r *Recorder, name string) map[string]float64 {
+	t.Helper()
+
+	registry := prometheus.NewPedanticRegistry()
+	for _, c := range r.collectors() {
+		require.NoError(t, registry.Register(c))
+	}
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	samples := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			var labels string
+			for _, label := range metric.GetLabel() {
+				labels += label.GetName() + "=" + label.GetValue() + ","
+			}
+			switch {
+			case metric.GetGauge() != nil:
+				samples[labels] = metric.GetGauge().GetValue()
+			case metric.GetHistogram() != nil:
+				samples[labels] = float64(metric.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+	return samples
+}
+
+func TestRecorder_RecordAddon(t *testing.T) {
+	r := NewRecorder(false)
+	r.RecordAddon(newTestAddon("a", addonsv1alpha1.PhaseReady,
+		metav1.ConditionTrue, addonsv1alpha1.AddonReasonFullyReconciled))
+	r.RecordAddon(newTestAddon("b", addonsv1alpha1.PhaseReady,
+		metav1.ConditionTrue, addonsv1alpha1.AddonReasonFullyReconciled))
+	r.RecordAddon(newTestAddon("c", addonsv1alpha1.PhasePending,
+		metav1.ConditionFalse, addonsv1alpha1.AddonReasonUnreadyCSV))
+
+	assert.Equal(t, map[string]float64{
+		"phase=Pending,reason=UnreadyCSV,":    1,
+		"phase=Ready,reason=FullyReconciled,": 2,
+	}, gather(t, r, "addon_operator_addons"))
+
+	r.ForgetAddon("c")
+	assert.Equal(t, map[string]float64{
+		"phase=Ready,reason=FullyReconciled,": 2,
+	}, gather(t, r, "addon_operator_addons"))
+}
+
+func TestRecorder_TimeToAvailable(t *testing.T) {
+	r := NewRecorder(false)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	addon := newTestAddon("a", addonsv1alpha1.PhasePending,
+		metav1.ConditionFalse, addonsv1alpha1.AddonReasonUnreadyCSV)
+	addon.Status.Conditions[0].LastTransitionTime = metav1.NewTime(now.Add(-time.Minute))
+	r.RecordAddon(addon)
+	r.RecordAddon(addon)
+	assert.Empty(t, gather(t, r, "addon_operator_addon_time_to_available_seconds")[""])
+
+	r.RecordAddon(newTestAddon("a", addonsv1alpha1.PhaseReady,
+		metav1.ConditionTrue, addonsv1alpha1.AddonReasonFullyReconciled))
+	assert.Equal(t, float64(1),
+		gather(t, r, "addon_operator_addon_time_to_available_seconds")[""])
+
+	// staying Available is not observed again
+	r.RecordAddon(newTestAddon("a", addonsv1alpha1.PhaseReady,
+		metav1.ConditionTrue, addonsv1alpha1.AddonReasonFullyReconciled))
+	assert.Equal(t, float64(1),
+		gather(t, r, "addon_operator_addon_time_to_available_seconds")[""])
+}
+
+func TestRecorder_RecordAddonInfo(t *testing.T) {
+	r := NewRecorder(false)
+	addon := newTestAddon("a", addonsv1alpha1.PhaseReady,
+		metav1.ConditionTrue, addonsv1alpha1.AddonReasonFullyReconciled)
+
+	r.RecordAddonInfo(addon, "reference-addon", "alpha", "reference-addon.v0.1.0")
+	r.RecordAddonInfo(addon, "reference-addon", "alpha", "reference-addon.v0.1.1")
+	assert.Equal(t, map[string]float64{
+		"channel=alpha,installed_csv=reference-addon.v0.1.1,name=a,package=reference-addon,": 1,
+	}, gather(t, r, "addon_operator_addon_info"))
+
+	r.ForgetAddon("a")
+	assert.Empty(t, gather(t, r, "addon_operator_addon_info"))
+}
+
+func TestRecorder_Nil(t *testing.T) {
+	var r *Recorder
+	assert.NotPanics(t, func() {
+		r.RecordAddon(&addonsv1alpha1.Addon{})
+		r.RecordAddonInfo(&addonsv1alpha1.Addon{}, "", "", "")
+		r.ForgetAddon("a")
+		r.SetGlobalPause(true)
+
+		timer := r.NewPhaseTimer()
+		timer.Phase("test")
+		timer.Done()
+	})
+}
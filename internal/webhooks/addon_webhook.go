@@ -2,10 +2,17 @@ package webhooks
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
 	v1 "k8s.io/api/admission/v1"
 	adminv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
 
@@ -19,10 +26,20 @@ type AddonWebhookHandler struct {
 	decoder *admission.Decoder
 	Log     logr.Logger
 	Client  client.Client
+	// Reads objects from the API server, to not cache every ConfigMap and Namespace of the cluster.
+	APIReader client.Reader
 }
 
 var _ admission.Handler = (*AddonWebhookHandler)(nil)
 
+// Returns the reader for objects that are not cached.
+func (r *AddonWebhookHandler) uncachedReader() client.Reader {
+	if r.APIReader == nil {
+		return r.Client
+	}
+	return r.APIReader
+}
+
 func (r *AddonWebhookHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
 	obj, err := r.decodeAddon(req)
 	if err != nil {
@@ -31,13 +48,13 @@ func (r *AddonWebhookHandler) Handle(ctx context.Context, req admission.Request)
 
 	switch req.Operation {
 	case v1.Operation(adminv1beta1.Create):
-		return r.validateCreate(&obj)
+		return r.validateCreate(ctx, &obj)
 	case v1.Operation(adminv1beta1.Update):
 		oldObj := addonsv1alpha1.Addon{}
 		if err := r.decoder.DecodeRaw(req.OldObject, &oldObj); err != nil {
 			return admission.Errored(http.StatusBadRequest, err)
 		}
-		return r.validateUpdate(&obj, &oldObj)
+		return r.validateUpdate(ctx, &obj, &oldObj)
 	default:
 		return admission.Allowed("operation allowed")
 	}
@@ -59,15 +76,55 @@ func (r *AddonWebhookHandler) InjectDecoder(d *admission.Decoder) error {
 	return nil
 }
 
-func (r *AddonWebhookHandler) validateCreate(addon *addonsv1alpha1.Addon) admission.Response {
-	if err := validateAddon(addon); err != nil {
+func (r *AddonWebhookHandler) validateCreate(
+	ctx context.Context, addon *addonsv1alpha1.Addon) admission.Response {
+	parametersSchema, err := r.getParametersSchema(ctx, addon)
+	if err != nil {
+		return parametersSchemaErrorResponse(err)
+	}
+
+	existingNamespaces, err := r.getExistingTargetNamespaces(ctx, addon, nil)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if err := validateAddon(addon, parametersSchema, existingNamespaces); err != nil {
+		return admission.Denied(err.Error())
+	}
+	if err := r.validateDependencies(ctx, addon); err != nil {
 		return admission.Denied(err.Error())
 	}
 	return admission.Allowed("operation allowed")
 }
 
-func (r *AddonWebhookHandler) validateUpdate(addon, oldAddon *addonsv1alpha1.Addon) admission.Response {
-	if err := validateAddon(addon); err != nil {
+func (r *AddonWebhookHandler) validateUpdate(
+	ctx context.Context, addon, oldAddon *addonsv1alpha1.Addon) admission.Response {
+	if !addon.DeletionTimestamp.IsZero() {
+		// The finalizer has to be removable,
+		// even if the Addon would no longer pass validation.
+		return admission.Allowed("Addon is being deleted")
+	}
+
+	// Parameters are only validated against the schema when they or the schema changed,
+	// so a deleted schema ConfigMap or a tightened schema does not block unrelated updates.
+	var parametersSchema *apiextensionsv1.JSONSchemaProps
+	if parametersChanged(addon, oldAddon) {
+		var err error
+		parametersSchema, err = r.getParametersSchema(ctx, addon)
+		if err != nil {
+			return parametersSchemaErrorResponse(err)
+		}
+	}
+
+	existingNamespaces, err := r.getExistingTargetNamespaces(ctx, addon, oldAddon)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if err := validateAddon(addon, parametersSchema, existingNamespaces); err != nil {
+		return admission.Denied(err.Error())
+	}
+	if err := r.validateDependencies(ctx, addon); err != nil {
 		return admission.Denied(err.Error())
 	}
 
@@ -76,3 +133,121 @@ func (r *AddonWebhookHandler) validateUpdate(addon, oldAddon *addonsv1alpha1.Add
 	}
 	return admission.Allowed("operation allowed")
 }
+
+// Rejects the given Addon if it would introduce a dependency cycle.
+func (r *AddonWebhookHandler) validateDependencies(
+	ctx context.Context, addon *addonsv1alpha1.Addon) error {
+	if len(addon.Spec.Dependencies) == 0 {
+		// Addons without dependencies can't be part of a cycle.
+		return nil
+	}
+
+	addonList := &addonsv1alpha1.AddonList{}
+	if err := r.Client.List(ctx, addonList); err != nil {
+		return fmt.Errorf("listing Addons: %w", err)
+	}
+	return validateDependencies(addon, addonList.Items)
+}
+
+// Checks which of the target namespaces of the given Addon already exist.
+// Namespaces managed by the Addon itself are not looked up.
+// On updates, oldAddon is the previous version of the Addon and only newly added
+// target namespaces are looked up, so deleting a target namespace later on
+// does not block unrelated updates or the removal of the finalizer.
+func (r *AddonWebhookHandler) getExistingTargetNamespaces(
+	ctx context.Context, addon, oldAddon *addonsv1alpha1.Addon) (map[string]bool, error) {
+	multiNamespace := addon.Spec.Install.OLMMultiNamespace
+	if multiNamespace == nil {
+		return nil, nil
+	}
+
+	existingNamespaces := map[string]bool{}
+	if oldAddon != nil && oldAddon.Spec.Install.OLMMultiNamespace != nil {
+		for _, name := range oldAddon.Spec.Install.OLMMultiNamespace.TargetNamespaces {
+			// already validated when it was added
+			existingNamespaces[name] = true
+		}
+	}
+
+	managedNamespaces := map[string]bool{}
+	for _, namespace := range addon.Spec.Namespaces {
+		managedNamespaces[namespace.Name] = true
+	}
+
+	for _, name := range multiNamespace.TargetNamespaces {
+		if managedNamespaces[name] || existingNamespaces[name] ||
+			len(validation.IsDNS1123Label(name)) > 0 {
+			continue
+		}
+
+		err := r.uncachedReader().Get(ctx, client.ObjectKey{Name: name}, &corev1.Namespace{})
+		if k8sApiErrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting Namespace %s: %w", name, err)
+		}
+		existingNamespaces[name] = true
+	}
+	return existingNamespaces, nil
+}
+
+// Returns true if the parameters or the parameters schema differ between the given Addons.
+func parametersChanged(addon, oldAddon *addonsv1alpha1.Addon) bool {
+	return !equality.Semantic.DeepEqual(addon.Spec.Parameters, oldAddon.Spec.Parameters) ||
+		!equality.Semantic.DeepEqual(addon.Spec.ParametersSchema, oldAddon.Spec.ParametersSchema)
+}
+
+// Denies Addons with a missing or invalid parameters schema.
+// Failures to look up the schema ConfigMap, other than NotFound, are returned as server errors.
+func parametersSchemaErrorResponse(err error) admission.Response {
+	var status k8sApiErrors.APIStatus
+	if errors.As(err, &status) && !k8sApiErrors.IsNotFound(err) {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.Denied(err.Error())
+}
+
+// Loads the parameters schema of the given Addon, either inline or from the referenced ConfigMap.
+// Returns nil if the Addon does not declare a parameters schema.
+func (r *AddonWebhookHandler) getParametersSchema(
+	ctx context.Context, addon *addonsv1alpha1.Addon) (*apiextensionsv1.JSONSchemaProps, error) {
+	schemaSpec := addon.Spec.ParametersSchema
+	if schemaSpec == nil {
+		return nil, nil
+	}
+
+	switch {
+	case schemaSpec.OpenAPIV3Schema != nil && schemaSpec.ConfigMapRef != nil:
+		return nil, errSpecParametersSchemaMutuallyExclusive
+
+	case schemaSpec.OpenAPIV3Schema != nil:
+		return parseParametersSchema(schemaSpec.OpenAPIV3Schema.Raw)
+
+	case schemaSpec.ConfigMapRef != nil:
+		ref := schemaSpec.ConfigMapRef
+		key := ref.Key
+		if len(key) == 0 {
+			key = defaultParametersSchemaConfigMapKey
+		}
+
+		configMap := &corev1.ConfigMap{}
+		if err := r.uncachedReader().Get(ctx, client.ObjectKey{
+			Name:      ref.Name,
+			Namespace: ref.Namespace,
+		}, configMap); err != nil {
+			return nil, fmt.Errorf("getting parameters schema ConfigMap %s/%s: %w",
+				ref.Namespace, ref.Name, err)
+		}
+
+		rawSchema, ok := configMap.Data[key]
+		if !ok {
+			return nil, fmt.Errorf("parameters schema ConfigMap %s/%s has no key %q",
+				ref.Namespace, ref.Name, key)
+		}
+		return parseParametersSchema([]byte(rawSchema))
+
+	default:
+		return nil, errSpecParametersSchemaRequired
+	}
+}
@@ -0,0 +1,263 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+var parametersFieldPath = field.NewPath("spec", "parameters")
+
+// Parses an OpenAPI v3 schema given in JSON or YAML format.
+func parseParametersSchema(raw []byte) (*apiextensionsv1.JSONSchemaProps, error) {
+	schema := &apiextensionsv1.JSONSchemaProps{}
+	if err := yaml.Unmarshal(raw, schema); err != nil {
+		return nil, fmt.Errorf("parsing parameters schema: %w", err)
+	}
+	return schema, nil
+}
+
+// Validates Addon parameters against an OpenAPI v3 schema describing the parameters object.
+// Supports the structural subset of OpenAPI v3 that is also allowed in CustomResourceDefinitions:
+// type, properties, additionalProperties, required, items, enum,
+// minimum, maximum, multipleOf, minLength, maxLength, pattern,
+// minItems, maxItems, uniqueItems, minProperties, maxProperties and nullable.
+// Like pruning in CustomResourceDefinitions, fields that are not listed in properties
+// are rejected unless additionalProperties allows them.
+func validateParametersAgainstSchema(
+	parameters map[string]apiextensionsv1.JSON,
+	schema *apiextensionsv1.JSONSchemaProps,
+) field.ErrorList {
+	obj := map[string]interface{}{}
+	var errs field.ErrorList
+	for name, value := range parameters {
+		var v interface{}
+		if err := json.Unmarshal(value.Raw, &v); err != nil {
+			errs = append(errs, field.Invalid(
+				parametersFieldPath.Key(name), string(value.Raw), "not valid JSON"))
+			continue
+		}
+		obj[name] = v
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return validateValue(parametersFieldPath, obj, schema)
+}
+
+func validateValue(
+	fldPath *field.Path, value interface{}, schema *apiextensionsv1.JSONSchemaProps,
+) field.ErrorList {
+	if value == nil {
+		if schema.Nullable || len(schema.Type) == 0 {
+			return nil
+		}
+		return field.ErrorList{field.Invalid(fldPath, nil, "must not be null")}
+	}
+
+	if !matchesType(value, schema.Type) {
+		return field.ErrorList{field.Invalid(fldPath, value,
+			fmt.Sprintf("must be of type %s", schema.Type))}
+	}
+
+	var errs field.ErrorList
+	if len(schema.Enum) > 0 && !matchesEnum(value, schema.Enum) {
+		errs = append(errs, field.NotSupported(fldPath, value, enumValues(schema.Enum)))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		errs = append(errs, validateObject(fldPath, v, schema)...)
+	case []interface{}:
+		errs = append(errs, validateArray(fldPath, v, schema)...)
+	case string:
+		errs = append(errs, validateString(fldPath, v, schema)...)
+	case float64:
+		errs = append(errs, validateNumber(fldPath, v, schema)...)
+	}
+	return errs
+}
+
+func validateObject(
+	fldPath *field.Path, obj map[string]interface{}, schema *apiextensionsv1.JSONSchemaProps,
+) field.ErrorList {
+	var errs field.ErrorList
+	for _, required := range schema.Required {
+		if _, ok := obj[required]; !ok {
+			errs = append(errs, field.Required(fldPath.Child(required), ""))
+		}
+	}
+	if schema.MinProperties != nil && int64(len(obj)) < *schema.MinProperties {
+		errs = append(errs, field.Invalid(fldPath, len(obj),
+			fmt.Sprintf("must have at least %d properties", *schema.MinProperties)))
+	}
+	if schema.MaxProperties != nil && int64(len(obj)) > *schema.MaxProperties {
+		errs = append(errs, field.TooMany(fldPath, len(obj), int(*schema.MaxProperties)))
+	}
+
+	// iterate in a stable order to keep error messages deterministic
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if propSchema, ok := schema.Properties[key]; ok {
+			errs = append(errs, validateValue(fldPath.Child(key), obj[key], &propSchema)...)
+			continue
+		}
+
+		switch {
+		case schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil:
+			errs = append(errs, validateValue(
+				fldPath.Child(key), obj[key], schema.AdditionalProperties.Schema)...)
+		case schema.AdditionalProperties != nil && !schema.AdditionalProperties.Allows,
+			schema.AdditionalProperties == nil && len(schema.Properties) > 0:
+			errs = append(errs, field.Forbidden(fldPath.Child(key), "unknown field"))
+		}
+	}
+	return errs
+}
+
+func validateArray(
+	fldPath *field.Path, arr []interface{}, schema *apiextensionsv1.JSONSchemaProps,
+) field.ErrorList {
+	var errs field.ErrorList
+	if schema.MinItems != nil && int64(len(arr)) < *schema.MinItems {
+		errs = append(errs, field.Invalid(fldPath, len(arr),
+			fmt.Sprintf("must have at least %d items", *schema.MinItems)))
+	}
+	if schema.MaxItems != nil && int64(len(arr)) > *schema.MaxItems {
+		errs = append(errs, field.TooMany(fldPath, len(arr), int(*schema.MaxItems)))
+	}
+	if schema.UniqueItems {
+		for i := range arr {
+			for j := 0; j < i; j++ {
+				if equality.Semantic.DeepEqual(arr[i], arr[j]) {
+					errs = append(errs, field.Duplicate(fldPath.Index(i), arr[i]))
+					break
+				}
+			}
+		}
+	}
+	if schema.Items != nil && schema.Items.Schema != nil {
+		for i := range arr {
+			errs = append(errs, validateValue(fldPath.Index(i), arr[i], schema.Items.Schema)...)
+		}
+	}
+	return errs
+}
+
+func validateString(
+	fldPath *field.Path, str string, schema *apiextensionsv1.JSONSchemaProps,
+) field.ErrorList {
+	var errs field.ErrorList
+	length := int64(len([]rune(str)))
+	if schema.MinLength != nil && length < *schema.MinLength {
+		errs = append(errs, field.Invalid(fldPath, str,
+			fmt.Sprintf("must be at least %d characters long", *schema.MinLength)))
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		errs = append(errs, field.TooLong(fldPath, str, int(*schema.MaxLength)))
+	}
+	if len(schema.Pattern) > 0 {
+		re, err := regexp.Compile(schema.Pattern)
+		if err != nil {
+			errs = append(errs, field.InternalError(fldPath,
+				fmt.Errorf("invalid pattern %q in parameters schema: %w", schema.Pattern, err)))
+		} else if !re.MatchString(str) {
+			errs = append(errs, field.Invalid(fldPath, str,
+				fmt.Sprintf("must match pattern %q", schema.Pattern)))
+		}
+	}
+	return errs
+}
+
+func validateNumber(
+	fldPath *field.Path, num float64, schema *apiextensionsv1.JSONSchemaProps,
+) field.ErrorList {
+	var errs field.ErrorList
+	if schema.Minimum != nil {
+		if num < *schema.Minimum || (schema.ExclusiveMinimum && num == *schema.Minimum) {
+			errs = append(errs, field.Invalid(fldPath, num,
+				fmt.Sprintf("must be greater than%s %v", orEqual(!schema.ExclusiveMinimum), *schema.Minimum)))
+		}
+	}
+	if schema.Maximum != nil {
+		if num > *schema.Maximum || (schema.ExclusiveMaximum && num == *schema.Maximum) {
+			errs = append(errs, field.Invalid(fldPath, num,
+				fmt.Sprintf("must be less than%s %v", orEqual(!schema.ExclusiveMaximum), *schema.Maximum)))
+		}
+	}
+	if schema.MultipleOf != nil && *schema.MultipleOf != 0 {
+		if q := num / *schema.MultipleOf; q != math.Trunc(q) {
+			errs = append(errs, field.Invalid(fldPath, num,
+				fmt.Sprintf("must be a multiple of %v", *schema.MultipleOf)))
+		}
+	}
+	return errs
+}
+
+func orEqual(inclusive bool) string {
+	if inclusive {
+		return " or equal to"
+	}
+	return ""
+}
+
+func matchesType(value interface{}, schemaType string) bool {
+	switch schemaType {
+	case "":
+		return true
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		num, ok := value.(float64)
+		return ok && num == math.Trunc(num)
+	default:
+		return false
+	}
+}
+
+func matchesEnum(value interface{}, enum []apiextensionsv1.JSON) bool {
+	for _, e := range enum {
+		var enumValue interface{}
+		if err := json.Unmarshal(e.Raw, &enumValue); err != nil {
+			continue
+		}
+		if equality.Semantic.DeepEqual(value, enumValue) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumValues(enum []apiextensionsv1.JSON) []string {
+	values := make([]string, len(enum))
+	for i := range enum {
+		values[i] = string(enum[i].Raw)
+	}
+	return values
+}
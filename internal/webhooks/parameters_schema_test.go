@@ -0,0 +1,259 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
+	"github.com/openshift/addon-operator/internal/testutil"
+)
+
+const testParametersSchema = `
+type: object
+required:
+- contact-email
+properties:
+  contact-email:
+    type: string
+    pattern: "^.+@.+$"
+  replicas:
+    type: integer
+    minimum: 1
+    maximum: 5
+  size:
+    type: string
+    enum: ["small", "large"]
+  zones:
+    type: array
+    maxItems: 2
+    items:
+      type: string
+`
+
+func TestValidateParametersAgainstSchema(t *testing.T) {
+	schema, err := parseParametersSchema([]byte(testParametersSchema))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		parameters     map[string]apiextensionsv1.JSON
+		expectedFields []string
+	}{
+		{
+			name: "valid",
+			parameters: map[string]apiextensionsv1.JSON{
+				"contact-email": {Raw: []byte(`"sre@example.com"`)},
+				"replicas":      {Raw: []byte(`3`)},
+				"size":          {Raw: []byte(`"small"`)},
+				"zones":         {Raw: []byte(`["a", "b"]`)},
+			},
+		},
+		{
+			name:           "missing required",
+			parameters:     map[string]apiextensionsv1.JSON{},
+			expectedFields: []string{"spec.parameters.contact-email"},
+		},
+		{
+			name: "invalid values",
+			parameters: map[string]apiextensionsv1.JSON{
+				"contact-email": {Raw: []byte(`"nope"`)},
+				"replicas":      {Raw: []byte(`2.5`)},
+				"size":          {Raw: []byte(`"medium"`)},
+				"zones":         {Raw: []byte(`["a", 1, "c"]`)},
+			},
+			expectedFields: []string{
+				"spec.parameters.contact-email",
+				"spec.parameters.replicas",
+				"spec.parameters.size",
+				"spec.parameters.zones",
+				"spec.parameters.zones[1]",
+			},
+		},
+		{
+			name: "out of range",
+			parameters: map[string]apiextensionsv1.JSON{
+				"contact-email": {Raw: []byte(`"sre@example.com"`)},
+				"replicas":      {Raw: []byte(`10`)},
+			},
+			expectedFields: []string{"spec.parameters.replicas"},
+		},
+		{
+			name: "unknown field",
+			parameters: map[string]apiextensionsv1.JSON{
+				"contact-email": {Raw: []byte(`"sre@example.com"`)},
+				"replica":       {Raw: []byte(`1`)},
+			},
+			expectedFields: []string{"spec.parameters.replica"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validateParametersAgainstSchema(tc.parameters, schema)
+
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			assert.Equal(t, tc.expectedFields, fields)
+		})
+	}
+}
+
+func TestValidateAddon_ParametersSchema(t *testing.T) {
+	schema, err := parseParametersSchema([]byte(testParametersSchema))
+	require.NoError(t, err)
+
+	addon := &addonsv1alpha1.Addon{
+		Spec: addonsv1alpha1.AddonSpec{
+			Install: addonsv1alpha1.AddonInstallSpec{
+				Type:             addonsv1alpha1.OLMAllNamespaces,
+				OLMAllNamespaces: &addonsv1alpha1.AddonInstallOLMAllNamespaces{},
+			},
+			Parameters: map[string]apiextensionsv1.JSON{
+				"replicas": {Raw: []byte(`0`)},
+			},
+		},
+	}
+
+	err = validateAddon(addon, schema, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "spec.parameters.contact-email")
+		assert.Contains(t, err.Error(), "spec.parameters.replicas")
+	}
+
+	// without a schema only parameter names are validated
+	assert.NoError(t, validateAddon(addon, nil, nil))
+}
+
+func TestGetParametersSchema_ConfigMapRef(t *testing.T) {
+	c := testutil.NewClient()
+	apiReader := testutil.NewClient()
+	apiReader.On("Get",
+		mock.Anything,
+		client.ObjectKey{Name: "schemas", Namespace: "addon-operator"},
+		mock.IsType(&corev1.ConfigMap{}),
+	).Run(func(args mock.Arguments) {
+		cm := args.Get(2).(*corev1.ConfigMap)
+		cm.Data = map[string]string{
+			defaultParametersSchemaConfigMapKey: testParametersSchema,
+		}
+	}).Return(nil)
+
+	r := &AddonWebhookHandler{Client: c, APIReader: apiReader}
+	addon := &addonsv1alpha1.Addon{
+		Spec: addonsv1alpha1.AddonSpec{
+			ParametersSchema: &addonsv1alpha1.AddonParametersSchema{
+				ConfigMapRef: &addonsv1alpha1.AddonParametersSchemaConfigMapReference{
+					Name:      "schemas",
+					Namespace: "addon-operator",
+				},
+			},
+		},
+	}
+
+	schema, err := r.getParametersSchema(context.Background(), addon)
+	require.NoError(t, err)
+	if assert.NotNil(t, schema) {
+		assert.Equal(t, []string{"contact-email"}, schema.Required)
+	}
+	apiReader.AssertExpectations(t)
+	// ConfigMaps are not read from the cache.
+	c.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestValidateUpdate_ParametersSchema(t *testing.T) {
+	newAddon := func(email string) *addonsv1alpha1.Addon {
+		addon := testutil.NewAddonWithInstallSpec(addonsv1alpha1.AddonInstallSpec{
+			Type: addonsv1alpha1.OLMAllNamespaces,
+			OLMAllNamespaces: &addonsv1alpha1.AddonInstallOLMAllNamespaces{
+				AddonInstallOLMCommon: addonsv1alpha1.AddonInstallOLMCommon{
+					Namespace:          "reference-addon",
+					CatalogSourceImage: "quay.io/osd-addons/reference-addon-index@sha256:123",
+				},
+			},
+		}, "reference-addon")
+		addon.Spec.Parameters = map[string]apiextensionsv1.JSON{
+			"contact-email": {Raw: []byte(`"` + email + `"`)},
+		}
+		addon.Spec.ParametersSchema = &addonsv1alpha1.AddonParametersSchema{
+			ConfigMapRef: &addonsv1alpha1.AddonParametersSchemaConfigMapReference{
+				Name:      "schemas",
+				Namespace: "addon-operator",
+			},
+		}
+		return addon
+	}
+
+	t.Run("unchanged parameters are not validated again", func(t *testing.T) {
+		c := testutil.NewClient()
+		r := &AddonWebhookHandler{Client: c}
+
+		oldAddon := newAddon("sre@example.com")
+		addon := oldAddon.DeepCopy()
+		addon.Finalizers = []string{"addons.managed.openshift.io/cache"}
+
+		resp := r.validateUpdate(context.Background(), addon, oldAddon)
+		assert.True(t, resp.Allowed, resp.Result.Message)
+		c.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("deleted schema ConfigMap", func(t *testing.T) {
+		c := testutil.NewClient()
+		c.On("Get",
+			mock.Anything,
+			client.ObjectKey{Name: "schemas", Namespace: "addon-operator"},
+			mock.IsType(&corev1.ConfigMap{}),
+		).Return(k8sApiErrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "schemas"))
+		r := &AddonWebhookHandler{Client: c}
+
+		resp := r.validateUpdate(context.Background(),
+			newAddon("new@example.com"), newAddon("sre@example.com"))
+		assert.False(t, resp.Allowed)
+		assert.Equal(t, int32(http.StatusForbidden), resp.Result.Code)
+	})
+
+	t.Run("schema ConfigMap lookup failure", func(t *testing.T) {
+		c := testutil.NewClient()
+		c.On("Get",
+			mock.Anything,
+			client.ObjectKey{Name: "schemas", Namespace: "addon-operator"},
+			mock.IsType(&corev1.ConfigMap{}),
+		).Return(k8sApiErrors.NewServiceUnavailable("etcd unavailable"))
+		r := &AddonWebhookHandler{Client: c}
+
+		resp := r.validateUpdate(context.Background(),
+			newAddon("new@example.com"), newAddon("sre@example.com"))
+		assert.False(t, resp.Allowed)
+		assert.Equal(t, int32(http.StatusInternalServerError), resp.Result.Code)
+	})
+
+	t.Run("deleting", func(t *testing.T) {
+		c := testutil.NewClient()
+		r := &AddonWebhookHandler{Client: c}
+
+		oldAddon := newAddon("sre@example.com")
+		oldAddon.Finalizers = []string{"addons.managed.openshift.io/cache"}
+		now := metav1.Now()
+		oldAddon.DeletionTimestamp = &now
+		addon := oldAddon.DeepCopy()
+		addon.Finalizers = nil
+		// the install spec no longer passes validation
+		addon.Spec.Install.OLMAllNamespaces = nil
+
+		resp := r.validateUpdate(context.Background(), addon, oldAddon)
+		assert.True(t, resp.Allowed, resp.Result.Message)
+		c.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
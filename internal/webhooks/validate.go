@@ -1,9 +1,18 @@
 package webhooks
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
 
+	"github.com/blang/semver/v4"
+	"github.com/robfig/cron/v3"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
 )
@@ -12,16 +21,202 @@ var (
 	errSpecInstallTypeInvalid             = errors.New("invalid Addon .spec.install.type")
 	errSpecInstallOwnNamespaceRequired    = errors.New(".spec.install.olmOwnNamespace is required when .spec.install.type = OLMOwnNamespace")
 	errSpecInstallAllNamespacesRequired   = errors.New(".spec.install.olmAllNamespaces is required when .spec.install.type = OLMAllNamespaces")
+	errSpecInstallMultiNamespaceRequired  = errors.New(".spec.install.olmMultiNamespace is required when .spec.install.type = OLMMultiNamespace")
 	errSpecInstallConfigMutuallyExclusive = errors.New(".spec.install.olmAllNamespaces is mutually exclusive with .spec.install.olmOwnNamespace")
+	errSpecInstallManifestsRequired       = errors.New(".spec.install.manifests is required when .spec.install.type = Manifests")
+	errSpecInstallManifestsSourceRequired = errors.New(".spec.install.manifests requires exactly one of .image and .configMapRef")
+	errSpecInstallHelmRequired            = errors.New(".spec.install.helm is required when .spec.install.type = Helm")
+	errSpecInstallHelmChartRequired       = errors.New(".spec.install.helm.chart requires .repositoryURL, .name and .version")
+	errSpecInstallHelmValuesInvalid       = errors.New(".spec.install.helm.values must be an object")
+
+	errSpecInstallClusterExtensionRequired       = errors.New(".spec.install.olmClusterExtension is required when .spec.install.type = OLMClusterExtension")
+	errSpecInstallClusterExtensionFieldsRequired = errors.New(".spec.install.olmClusterExtension requires .namespace, .catalogSourceImage, .packageName and .serviceAccountName")
+
+	errSpecInstallTargetNamespacesRequired = errors.New(".spec.install.olmMultiNamespace.targetNamespaces is required when .spec.install.type = OLMMultiNamespace")
+	errSpecInstallTargetNamespaceUnknown   = errors.New(".spec.install.olmMultiNamespace.targetNamespaces must be listed in .spec.namespaces or already exist")
+
+	errSpecInstallUpgradePolicyVersionRequired = errors.New(".spec.install.*.upgradePolicy.version is required when .spec.install.*.upgradePolicy.type = ApproveUpTo")
+
+	errSpecInstallCatalogUpdateIntervalRequired = errors.New(".spec.install.*.catalogUpdatePolicy.interval must be greater than zero")
+
+	errSpecInstallAdditionalPackageFieldsRequired = errors.New(".spec.install.*.additionalPackages[*] requires .packageName, .channel and .catalogSourceImage")
+	errSpecInstallAdditionalPackageDuplicated     = errors.New(".spec.install.*.additionalPackages[*].packageName must be unique and differ from .spec.install.*.packageName")
+
+	errSpecDependenciesCycle = errors.New(".spec.dependencies must not contain cycles")
+
+	errSpecMaintenanceWindowDurationRequired = errors.New(".spec.maintenanceWindows[*].duration must be greater than zero")
+
+	errSpecParametersSchemaRequired          = errors.New(".spec.parametersSchema requires one of .openAPIV3Schema or .configMapRef")
+	errSpecParametersSchemaMutuallyExclusive = errors.New(".spec.parametersSchema.openAPIV3Schema is mutually exclusive with .spec.parametersSchema.configMapRef")
 )
 
-func validateAddon(addon *addonsv1alpha1.Addon) error {
-	return validateInstallSpec(addon.Spec.Install)
+const defaultParametersSchemaConfigMapKey = "schema"
+
+// Validates the given Addon.
+// Parameters are additionally validated against parametersSchema, if not nil.
+// existingNamespaces contains the Namespaces referenced by the Addon, that exist on the cluster.
+func validateAddon(
+	addon *addonsv1alpha1.Addon, parametersSchema *apiextensionsv1.JSONSchemaProps,
+	existingNamespaces map[string]bool) error {
+	availableNamespaces := map[string]bool{}
+	for name, exists := range existingNamespaces {
+		availableNamespaces[name] = exists
+	}
+	for _, namespace := range addon.Spec.Namespaces {
+		availableNamespaces[namespace.Name] = true
+	}
+
+	if err := validateInstallSpec(addon.Spec.Install, availableNamespaces); err != nil {
+		return err
+	}
+	if err := validateMaintenanceWindows(addon.Spec.MaintenanceWindows); err != nil {
+		return err
+	}
+	if err := validateParameters(addon.Spec.Parameters); err != nil {
+		return err
+	}
+	if err := validatePullSecrets(addon.Spec.PullSecrets); err != nil {
+		return err
+	}
+	if err := validateAdoption(addon.Spec.Adoption); err != nil {
+		return err
+	}
+	if parametersSchema != nil {
+		if errs := validateParametersAgainstSchema(
+			addon.Spec.Parameters, parametersSchema); len(errs) > 0 {
+			return errs.ToAggregate()
+		}
+	}
+	return nil
+}
+
+// Validates that adding the given Addon to the existing Addons
+// does not introduce a dependency cycle.
+func validateDependencies(
+	addon *addonsv1alpha1.Addon, existingAddons []addonsv1alpha1.Addon) error {
+	dependencies := map[string][]string{}
+	for _, existingAddon := range existingAddons {
+		for _, dependency := range existingAddon.Spec.Dependencies {
+			dependencies[existingAddon.Name] = append(
+				dependencies[existingAddon.Name], dependency.Name)
+		}
+	}
+	// the given Addon replaces its existing version
+	dependencies[addon.Name] = nil
+	for _, dependency := range addon.Spec.Dependencies {
+		dependencies[addon.Name] = append(dependencies[addon.Name], dependency.Name)
+	}
+
+	// Any new cycle has to pass through the given Addon.
+	if path := findDependencyPath(dependencies, addon.Name, addon.Name, nil, map[string]bool{}); path != nil {
+		return fmt.Errorf("%w: %s", errSpecDependenciesCycle, strings.Join(path, " -> "))
+	}
+	return nil
+}
+
+// Returns the path of Addon names leading from `from` to `to`
+// by following dependencies, or nil if there is none.
+func findDependencyPath(
+	dependencies map[string][]string, from, to string,
+	path []string, visited map[string]bool) []string {
+	path = append(path, from)
+	for _, dependency := range dependencies[from] {
+		if dependency == to {
+			return append(path, to)
+		}
+		if visited[dependency] {
+			continue
+		}
+		visited[dependency] = true
+		if found := findDependencyPath(dependencies, dependency, to, path, visited); found != nil {
+			return found
+		}
+	}
+	return nil
 }
 
-func validateInstallSpec(addonSpecInstall addonsv1alpha1.AddonInstallSpec) error {
-	if addonSpecInstall.OLMAllNamespaces != nil &&
-		addonSpecInstall.OLMOwnNamespace != nil {
+func validateMaintenanceWindows(windows []addonsv1alpha1.AddonMaintenanceWindow) error {
+	for i, window := range windows {
+		if _, err := cron.ParseStandard(window.Schedule); err != nil {
+			return fmt.Errorf(".spec.maintenanceWindows[%d].schedule is invalid: %w", i, err)
+		}
+		if window.Duration.Duration <= 0 {
+			return errSpecMaintenanceWindowDurationRequired
+		}
+	}
+	return nil
+}
+
+// Parameter names are used as keys in the parameters Secret and have to be valid Secret keys.
+func validateParameters(parameters map[string]apiextensionsv1.JSON) error {
+	names := make([]string, 0, len(parameters))
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if errs := validation.IsConfigMapKey(name); len(errs) > 0 {
+			return fmt.Errorf(".spec.parameters[%q] has an invalid name: %s",
+				name, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// Pull Secrets are copied into the install namespace under a name derived from theirs
+// and have to be listed only once.
+func validatePullSecrets(pullSecrets []addonsv1alpha1.AddonPullSecret) error {
+	seen := map[string]bool{}
+	for _, pullSecret := range pullSecrets {
+		if errs := validation.IsDNS1123Subdomain(pullSecret.Name); len(errs) > 0 {
+			return fmt.Errorf(".spec.pullSecrets[%q] has an invalid name: %s",
+				pullSecret.Name, strings.Join(errs, ", "))
+		}
+		if seen[pullSecret.Name] {
+			return fmt.Errorf(".spec.pullSecrets[%q] is duplicated", pullSecret.Name)
+		}
+		seen[pullSecret.Name] = true
+	}
+	return nil
+}
+
+// Each kind may only have one adoption policy override.
+func validateAdoption(adoption *addonsv1alpha1.AddonAdoption) error {
+	if adoption == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	for _, override := range adoption.Overrides {
+		if seen[override.Kind] {
+			return fmt.Errorf(".spec.adoption.overrides[%q] is duplicated", override.Kind)
+		}
+		seen[override.Kind] = true
+	}
+	return nil
+}
+
+// Validates the install spec of an Addon.
+// availableNamespaces contains the Namespaces that are managed by the Addon or already exist.
+func validateInstallSpec(
+	addonSpecInstall addonsv1alpha1.AddonInstallSpec, availableNamespaces map[string]bool) error {
+	if olmInstallConfigCount(addonSpecInstall) > 1 {
+		return errSpecInstallConfigMutuallyExclusive
+	}
+	if addonSpecInstall.OLMMultiNamespace != nil &&
+		addonSpecInstall.Type != addonsv1alpha1.OLMMultiNamespace {
+		return errSpecInstallConfigMutuallyExclusive
+	}
+	if addonSpecInstall.OLMClusterExtension != nil &&
+		addonSpecInstall.Type != addonsv1alpha1.OLMClusterExtension {
+		return errSpecInstallConfigMutuallyExclusive
+	}
+	if addonSpecInstall.Manifests != nil &&
+		addonSpecInstall.Type != addonsv1alpha1.Manifests {
+		return errSpecInstallConfigMutuallyExclusive
+	}
+	if addonSpecInstall.Helm != nil &&
+		addonSpecInstall.Type != addonsv1alpha1.Helm {
 		return errSpecInstallConfigMutuallyExclusive
 	}
 
@@ -32,7 +227,7 @@ func validateInstallSpec(addonSpecInstall addonsv1alpha1.AddonInstallSpec) error
 			return errSpecInstallOwnNamespaceRequired
 		}
 
-		return nil
+		return validateInstallOLMCommon(addonSpecInstall.OLMOwnNamespace.AddonInstallOLMCommon)
 
 	case addonsv1alpha1.OLMAllNamespaces:
 		if addonSpecInstall.OLMAllNamespaces == nil {
@@ -40,8 +235,58 @@ func validateInstallSpec(addonSpecInstall addonsv1alpha1.AddonInstallSpec) error
 			return errSpecInstallAllNamespacesRequired
 		}
 
+		return validateInstallOLMCommon(addonSpecInstall.OLMAllNamespaces.AddonInstallOLMCommon)
+
+	case addonsv1alpha1.OLMMultiNamespace:
+		if addonSpecInstall.OLMMultiNamespace == nil {
+			// missing configuration
+			return errSpecInstallMultiNamespaceRequired
+		}
+		if err := validateTargetNamespaces(
+			addonSpecInstall.OLMMultiNamespace.TargetNamespaces, availableNamespaces); err != nil {
+			return err
+		}
+
+		return validateInstallOLMCommon(addonSpecInstall.OLMMultiNamespace.AddonInstallOLMCommon)
+
+	case addonsv1alpha1.OLMClusterExtension:
+		clusterExtensionSpec := addonSpecInstall.OLMClusterExtension
+		if clusterExtensionSpec == nil {
+			// missing configuration
+			return errSpecInstallClusterExtensionRequired
+		}
+		if len(clusterExtensionSpec.Namespace) == 0 ||
+			len(clusterExtensionSpec.CatalogSourceImage) == 0 ||
+			len(clusterExtensionSpec.PackageName) == 0 ||
+			len(clusterExtensionSpec.ServiceAccountName) == 0 {
+			return errSpecInstallClusterExtensionFieldsRequired
+		}
+		return nil
+
+	case addonsv1alpha1.Manifests:
+		if addonSpecInstall.Manifests == nil {
+			// missing configuration
+			return errSpecInstallManifestsRequired
+		}
+		if olmInstallConfigCount(addonSpecInstall) > 0 {
+			return errSpecInstallConfigMutuallyExclusive
+		}
+		if (len(addonSpecInstall.Manifests.Image) == 0) ==
+			(addonSpecInstall.Manifests.ConfigMapRef == nil) {
+			return errSpecInstallManifestsSourceRequired
+		}
 		return nil
 
+	case addonsv1alpha1.Helm:
+		if addonSpecInstall.Helm == nil {
+			// missing configuration
+			return errSpecInstallHelmRequired
+		}
+		if olmInstallConfigCount(addonSpecInstall) > 0 {
+			return errSpecInstallConfigMutuallyExclusive
+		}
+		return validateInstallHelm(addonSpecInstall.Helm)
+
 	default:
 		// Unsupported Install Type
 		// This should never happen, unless the schema validation is wrong.
@@ -50,11 +295,128 @@ func validateInstallSpec(addonSpecInstall addonsv1alpha1.AddonInstallSpec) error
 	}
 }
 
+// Returns the number of OLM install type configurations present in the given install spec.
+func olmInstallConfigCount(addonSpecInstall addonsv1alpha1.AddonInstallSpec) (count int) {
+	if addonSpecInstall.OLMAllNamespaces != nil {
+		count++
+	}
+	if addonSpecInstall.OLMOwnNamespace != nil {
+		count++
+	}
+	if addonSpecInstall.OLMMultiNamespace != nil {
+		count++
+	}
+	if addonSpecInstall.OLMClusterExtension != nil {
+		count++
+	}
+	return count
+}
+
+// Target namespaces have to be unique and either managed by the Addon or already existing.
+func validateTargetNamespaces(targetNamespaces []string, availableNamespaces map[string]bool) error {
+	if len(targetNamespaces) == 0 {
+		return errSpecInstallTargetNamespacesRequired
+	}
+
+	seen := map[string]bool{}
+	for _, namespace := range targetNamespaces {
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return fmt.Errorf(".spec.install.olmMultiNamespace.targetNamespaces[%q] is invalid: %s",
+				namespace, strings.Join(errs, ", "))
+		}
+		if seen[namespace] {
+			return fmt.Errorf(".spec.install.olmMultiNamespace.targetNamespaces[%q] is duplicated", namespace)
+		}
+		seen[namespace] = true
+
+		if !availableNamespaces[namespace] {
+			return fmt.Errorf("%w: %q", errSpecInstallTargetNamespaceUnknown, namespace)
+		}
+	}
+	return nil
+}
+
+func validateInstallHelm(helmSpec *addonsv1alpha1.AddonInstallHelm) error {
+	chartRef := helmSpec.Chart
+	if len(chartRef.RepositoryURL) == 0 || len(chartRef.Name) == 0 || len(chartRef.Version) == 0 {
+		return errSpecInstallHelmChartRequired
+	}
+	repositoryURL, err := url.Parse(chartRef.RepositoryURL)
+	if err != nil || (repositoryURL.Scheme != "http" && repositoryURL.Scheme != "https") ||
+		len(repositoryURL.Host) == 0 {
+		return fmt.Errorf(".spec.install.helm.chart.repositoryURL must be an http(s) URL: %q", chartRef.RepositoryURL)
+	}
+	if _, err := semver.ParseTolerant(chartRef.Version); err != nil {
+		return fmt.Errorf(".spec.install.helm.chart.version is invalid: %w", err)
+	}
+
+	if helmSpec.Values != nil {
+		values := map[string]interface{}{}
+		if err := json.Unmarshal(helmSpec.Values.Raw, &values); err != nil {
+			return errSpecInstallHelmValuesInvalid
+		}
+	}
+	return nil
+}
+
+func validateInstallOLMCommon(common addonsv1alpha1.AddonInstallOLMCommon) error {
+	if err := validateAdditionalPackages(common); err != nil {
+		return err
+	}
+	if common.CatalogUpdatePolicy != nil &&
+		common.CatalogUpdatePolicy.Interval.Duration <= 0 {
+		return errSpecInstallCatalogUpdateIntervalRequired
+	}
+
+	if common.UpgradePolicy == nil ||
+		common.UpgradePolicy.Type != addonsv1alpha1.UpgradePolicyApproveUpTo {
+		return nil
+	}
+
+	if len(common.UpgradePolicy.Version) == 0 {
+		return errSpecInstallUpgradePolicyVersionRequired
+	}
+	if _, err := semver.ParseTolerant(common.UpgradePolicy.Version); err != nil {
+		return fmt.Errorf(".spec.install.*.upgradePolicy.version is invalid: %w", err)
+	}
+	return nil
+}
+
+// Additional packages share the Namespace with the main package
+// and have to be distinguishable by their package name.
+func validateAdditionalPackages(common addonsv1alpha1.AddonInstallOLMCommon) error {
+	seen := map[string]bool{common.PackageName: true}
+	for _, pkg := range common.AdditionalPackages {
+		if len(pkg.PackageName) == 0 ||
+			len(pkg.Channel) == 0 ||
+			len(pkg.CatalogSourceImage) == 0 {
+			return errSpecInstallAdditionalPackageFieldsRequired
+		}
+		if seen[pkg.PackageName] {
+			return errSpecInstallAdditionalPackageDuplicated
+		}
+		seen[pkg.PackageName] = true
+	}
+	return nil
+}
+
 var (
 	errInstallTypeImmutable = errors.New(".spec.install.type is immutable")
-	errInstallImmutable     = errors.New(".spec.install is immutable, except for .catalogSourceImage")
+	errInstallImmutable     = errors.New(".spec.install is immutable, except for .catalogSourceImage, .config, .upgradePolicy, .catalogUpdatePolicy, .foreignOperatorGroupPolicy, the .catalogSourceImage of .additionalPackages, the source of .manifests, the chart version, repository and values of .helm and the channel and version of .olmClusterExtension")
 )
 
+// Empties the fields of the OLM install configuration that may change.
+func resetMutableOLMCommonFields(common *addonsv1alpha1.AddonInstallOLMCommon) {
+	common.CatalogSourceImage = ""
+	common.Config = nil
+	common.UpgradePolicy = nil
+	common.CatalogUpdatePolicy = nil
+	common.ForeignOperatorGroupPolicy = ""
+	for i := range common.AdditionalPackages {
+		common.AdditionalPackages[i].CatalogSourceImage = ""
+	}
+}
+
 func validateAddonImmutability(addon, oldAddon *addonsv1alpha1.Addon) error {
 	if addon.Spec.Install.Type != oldAddon.Spec.Install.Type {
 		return errInstallTypeImmutable
@@ -63,18 +425,54 @@ func validateAddonImmutability(addon, oldAddon *addonsv1alpha1.Addon) error {
 	// empty fields that we don't want to compare
 	oldSpecInstall := oldAddon.Spec.Install.DeepCopy()
 	if oldSpecInstall.OLMAllNamespaces != nil {
-		oldSpecInstall.OLMAllNamespaces.CatalogSourceImage = ""
+		resetMutableOLMCommonFields(&oldSpecInstall.OLMAllNamespaces.AddonInstallOLMCommon)
 	}
 	if oldSpecInstall.OLMOwnNamespace != nil {
-		oldSpecInstall.OLMOwnNamespace.CatalogSourceImage = ""
+		resetMutableOLMCommonFields(&oldSpecInstall.OLMOwnNamespace.AddonInstallOLMCommon)
+	}
+	if oldSpecInstall.OLMMultiNamespace != nil {
+		resetMutableOLMCommonFields(&oldSpecInstall.OLMMultiNamespace.AddonInstallOLMCommon)
+	}
+	if oldSpecInstall.OLMClusterExtension != nil {
+		oldSpecInstall.OLMClusterExtension.CatalogSourceImage = ""
+		oldSpecInstall.OLMClusterExtension.Channel = ""
+		oldSpecInstall.OLMClusterExtension.Version = ""
+	}
+	if oldSpecInstall.Manifests != nil {
+		oldSpecInstall.Manifests.Image = ""
+		oldSpecInstall.Manifests.ConfigMapRef = nil
+	}
+	if oldSpecInstall.Helm != nil {
+		oldSpecInstall.Helm.Chart.RepositoryURL = ""
+		oldSpecInstall.Helm.Chart.Version = ""
+		oldSpecInstall.Helm.Values = nil
+		oldSpecInstall.Helm.ValuesFromParameters = false
 	}
 
 	specInstall := addon.Spec.Install.DeepCopy()
 	if specInstall.OLMAllNamespaces != nil {
-		specInstall.OLMAllNamespaces.CatalogSourceImage = ""
+		resetMutableOLMCommonFields(&specInstall.OLMAllNamespaces.AddonInstallOLMCommon)
 	}
 	if specInstall.OLMOwnNamespace != nil {
-		specInstall.OLMOwnNamespace.CatalogSourceImage = ""
+		resetMutableOLMCommonFields(&specInstall.OLMOwnNamespace.AddonInstallOLMCommon)
+	}
+	if specInstall.OLMMultiNamespace != nil {
+		resetMutableOLMCommonFields(&specInstall.OLMMultiNamespace.AddonInstallOLMCommon)
+	}
+	if specInstall.OLMClusterExtension != nil {
+		specInstall.OLMClusterExtension.CatalogSourceImage = ""
+		specInstall.OLMClusterExtension.Channel = ""
+		specInstall.OLMClusterExtension.Version = ""
+	}
+	if specInstall.Manifests != nil {
+		specInstall.Manifests.Image = ""
+		specInstall.Manifests.ConfigMapRef = nil
+	}
+	if specInstall.Helm != nil {
+		specInstall.Helm.Chart.RepositoryURL = ""
+		specInstall.Helm.Chart.Version = ""
+		specInstall.Helm.Values = nil
+		specInstall.Helm.ValuesFromParameters = false
 	}
 
 	// Do semantic DeepEqual instead of reflect.DeepEqual
@@ -1,9 +1,20 @@
 package webhooks
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	addonsv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
 	"github.com/openshift/addon-operator/internal/testutil"
@@ -41,6 +52,108 @@ func Test_validateInstallSpec(t *testing.T) {
 			},
 			expectedErr: errSpecInstallAllNamespacesRequired,
 		},
+		{
+			name: "spec.install.*.upgradePolicy.version required",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.OLMOwnNamespace,
+				OLMOwnNamespace: &addonsv1alpha1.AddonInstallOLMOwnNamespace{
+					AddonInstallOLMCommon: addonsv1alpha1.AddonInstallOLMCommon{
+						UpgradePolicy: &addonsv1alpha1.AddonUpgradePolicy{
+							Type: addonsv1alpha1.UpgradePolicyApproveUpTo,
+						},
+					},
+				},
+			},
+			expectedErr: errSpecInstallUpgradePolicyVersionRequired,
+		},
+		{
+			name: "spec.install.*.catalogUpdatePolicy.interval required",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.OLMOwnNamespace,
+				OLMOwnNamespace: &addonsv1alpha1.AddonInstallOLMOwnNamespace{
+					AddonInstallOLMCommon: addonsv1alpha1.AddonInstallOLMCommon{
+						CatalogUpdatePolicy: &addonsv1alpha1.AddonCatalogUpdatePolicy{},
+					},
+				},
+			},
+			expectedErr: errSpecInstallCatalogUpdateIntervalRequired,
+		},
+		{
+			name: "spec.install.*.additionalPackages fields required",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.OLMOwnNamespace,
+				OLMOwnNamespace: &addonsv1alpha1.AddonInstallOLMOwnNamespace{
+					AddonInstallOLMCommon: addonsv1alpha1.AddonInstallOLMCommon{
+						PackageName: "reference-addon",
+						AdditionalPackages: []addonsv1alpha1.AddonInstallOLMPackage{
+							{PackageName: "companion-operator", Channel: "alpha"},
+						},
+					},
+				},
+			},
+			expectedErr: errSpecInstallAdditionalPackageFieldsRequired,
+		},
+		{
+			name: "spec.install.*.additionalPackages must not repeat the main package",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.OLMOwnNamespace,
+				OLMOwnNamespace: &addonsv1alpha1.AddonInstallOLMOwnNamespace{
+					AddonInstallOLMCommon: addonsv1alpha1.AddonInstallOLMCommon{
+						PackageName: "reference-addon",
+						AdditionalPackages: []addonsv1alpha1.AddonInstallOLMPackage{
+							{
+								PackageName:        "reference-addon",
+								Channel:            "alpha",
+								CatalogSourceImage: "quay.io/osd-addons/reference-addon-index@sha256:123",
+							},
+						},
+					},
+				},
+			},
+			expectedErr: errSpecInstallAdditionalPackageDuplicated,
+		},
+		{
+			name: "spec.install.*.additionalPackages must be unique",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.OLMAllNamespaces,
+				OLMAllNamespaces: &addonsv1alpha1.AddonInstallOLMAllNamespaces{
+					AddonInstallOLMCommon: addonsv1alpha1.AddonInstallOLMCommon{
+						PackageName: "reference-addon",
+						AdditionalPackages: []addonsv1alpha1.AddonInstallOLMPackage{
+							{
+								PackageName:        "companion-operator",
+								Channel:            "alpha",
+								CatalogSourceImage: "quay.io/osd-addons/companion-index@sha256:123",
+							},
+							{
+								PackageName:        "companion-operator",
+								Channel:            "beta",
+								CatalogSourceImage: "quay.io/osd-addons/companion-index@sha256:456",
+							},
+						},
+					},
+				},
+			},
+			expectedErr: errSpecInstallAdditionalPackageDuplicated,
+		},
+		{
+			name: "spec.install.*.additionalPackages valid",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.OLMOwnNamespace,
+				OLMOwnNamespace: &addonsv1alpha1.AddonInstallOLMOwnNamespace{
+					AddonInstallOLMCommon: addonsv1alpha1.AddonInstallOLMCommon{
+						PackageName: "reference-addon",
+						AdditionalPackages: []addonsv1alpha1.AddonInstallOLMPackage{
+							{
+								PackageName:        "companion-operator",
+								Channel:            "alpha",
+								CatalogSourceImage: "quay.io/osd-addons/companion-index@sha256:123",
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "spec.install.allNamespaces and *.ownNamespace mutually exclusive",
 			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
@@ -50,16 +163,354 @@ func Test_validateInstallSpec(t *testing.T) {
 			},
 			expectedErr: errSpecInstallConfigMutuallyExclusive,
 		},
+		{
+			name: "spec.install.manifests required",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.Manifests,
+			},
+			expectedErr: errSpecInstallManifestsRequired,
+		},
+		{
+			name: "spec.install.manifests source required",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.Manifests,
+				Manifests: &addonsv1alpha1.AddonInstallManifests{
+					Namespace: "reference-addon",
+				},
+			},
+			expectedErr: errSpecInstallManifestsSourceRequired,
+		},
+		{
+			name: "spec.install.manifests image and configMapRef mutually exclusive",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.Manifests,
+				Manifests: &addonsv1alpha1.AddonInstallManifests{
+					Namespace: "reference-addon",
+					Image:     "quay.io/osd-addons/reference-addon-manifests@sha256:123",
+					ConfigMapRef: &addonsv1alpha1.AddonManifestsConfigMapReference{
+						Name: "manifests", Namespace: "reference-addon",
+					},
+				},
+			},
+			expectedErr: errSpecInstallManifestsSourceRequired,
+		},
+		{
+			name: "spec.install.manifests and *.ownNamespace mutually exclusive",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type:            addonsv1alpha1.OLMOwnNamespace,
+				OLMOwnNamespace: &addonsv1alpha1.AddonInstallOLMOwnNamespace{},
+				Manifests:       &addonsv1alpha1.AddonInstallManifests{},
+			},
+			expectedErr: errSpecInstallConfigMutuallyExclusive,
+		},
+		{
+			name: "spec.install.manifests valid",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.Manifests,
+				Manifests: &addonsv1alpha1.AddonInstallManifests{
+					Namespace: "reference-addon",
+					ConfigMapRef: &addonsv1alpha1.AddonManifestsConfigMapReference{
+						Name: "manifests", Namespace: "reference-addon",
+					},
+				},
+			},
+		},
+		{
+			name: "spec.install.olmMultiNamespace required",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.OLMMultiNamespace,
+			},
+			expectedErr: errSpecInstallMultiNamespaceRequired,
+		},
+		{
+			name: "spec.install.olmMultiNamespace and *.ownNamespace mutually exclusive",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type:              addonsv1alpha1.OLMMultiNamespace,
+				OLMOwnNamespace:   &addonsv1alpha1.AddonInstallOLMOwnNamespace{},
+				OLMMultiNamespace: &addonsv1alpha1.AddonInstallOLMMultiNamespace{},
+			},
+			expectedErr: errSpecInstallConfigMutuallyExclusive,
+		},
+		{
+			name: "spec.install.olmClusterExtension required",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.OLMClusterExtension,
+			},
+			expectedErr: errSpecInstallClusterExtensionRequired,
+		},
+		{
+			name: "spec.install.olmClusterExtension incomplete",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.OLMClusterExtension,
+				OLMClusterExtension: &addonsv1alpha1.AddonInstallOLMClusterExtension{
+					Namespace:   "reference-addon",
+					PackageName: "reference-addon",
+				},
+			},
+			expectedErr: errSpecInstallClusterExtensionFieldsRequired,
+		},
+		{
+			name: "spec.install.olmClusterExtension and type mutually exclusive",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type:                addonsv1alpha1.OLMOwnNamespace,
+				OLMOwnNamespace:     &addonsv1alpha1.AddonInstallOLMOwnNamespace{},
+				OLMClusterExtension: &addonsv1alpha1.AddonInstallOLMClusterExtension{},
+			},
+			expectedErr: errSpecInstallConfigMutuallyExclusive,
+		},
+		{
+			name: "spec.install.olmClusterExtension valid",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.OLMClusterExtension,
+				OLMClusterExtension: &addonsv1alpha1.AddonInstallOLMClusterExtension{
+					Namespace:          "reference-addon",
+					CatalogSourceImage: "quay.io/osd-addons/reference-addon-index@sha256:123",
+					Channel:            "stable",
+					PackageName:        "reference-addon",
+					ServiceAccountName: "reference-addon-installer",
+				},
+			},
+		},
+		{
+			name: "spec.install.helm required",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.Helm,
+			},
+			expectedErr: errSpecInstallHelmRequired,
+		},
+		{
+			name: "spec.install.helm.chart incomplete",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.Helm,
+				Helm: &addonsv1alpha1.AddonInstallHelm{
+					Namespace: "reference-addon",
+					Chart:     addonsv1alpha1.AddonHelmChart{Name: "reference-addon"},
+				},
+			},
+			expectedErr: errSpecInstallHelmChartRequired,
+		},
+		{
+			name: "spec.install.helm.values not an object",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.Helm,
+				Helm: &addonsv1alpha1.AddonInstallHelm{
+					Namespace: "reference-addon",
+					Chart: addonsv1alpha1.AddonHelmChart{
+						RepositoryURL: "https://charts.example.com",
+						Name:          "reference-addon",
+						Version:       "1.0.0",
+					},
+					Values: &apiextensionsv1.JSON{Raw: []byte(`["a"]`)},
+				},
+			},
+			expectedErr: errSpecInstallHelmValuesInvalid,
+		},
+		{
+			name: "spec.install.helm and type mutually exclusive",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.Manifests,
+				Manifests: &addonsv1alpha1.AddonInstallManifests{
+					Namespace: "reference-addon",
+					Image:     "quay.io/osd-addons/manifests:v1",
+				},
+				Helm: &addonsv1alpha1.AddonInstallHelm{},
+			},
+			expectedErr: errSpecInstallConfigMutuallyExclusive,
+		},
+		{
+			name: "spec.install.helm valid",
+			addonInstallSpec: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.Helm,
+				Helm: &addonsv1alpha1.AddonInstallHelm{
+					Namespace: "reference-addon",
+					Chart: addonsv1alpha1.AddonHelmChart{
+						RepositoryURL: "https://charts.example.com",
+						Name:          "reference-addon",
+						Version:       "1.0.0",
+					},
+					Values: &apiextensionsv1.JSON{Raw: []byte(`{"replicas": 2}`)},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := validateInstallSpec(tc.addonInstallSpec)
+			err := validateInstallSpec(tc.addonInstallSpec, nil)
 			assert.EqualValues(t, tc.expectedErr, err)
 		})
 	}
 }
 
+func TestValidateParameters(t *testing.T) {
+	testCases := []struct {
+		name       string
+		parameters map[string]apiextensionsv1.JSON
+		expectErr  bool
+	}{
+		{
+			name: "no parameters",
+		},
+		{
+			name: "valid names",
+			parameters: map[string]apiextensionsv1.JSON{
+				"contact-email": {Raw: []byte(`"sre@example.com"`)},
+				"replicas":      {Raw: []byte(`3`)},
+			},
+		},
+		{
+			name: "invalid name",
+			parameters: map[string]apiextensionsv1.JSON{
+				"contact email": {Raw: []byte(`"sre@example.com"`)},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateParameters(tc.parameters)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidatePullSecrets(t *testing.T) {
+	testCases := []struct {
+		name        string
+		pullSecrets []addonsv1alpha1.AddonPullSecret
+		expectErr   bool
+	}{
+		{
+			name: "no pull secrets",
+		},
+		{
+			name: "valid",
+			pullSecrets: []addonsv1alpha1.AddonPullSecret{
+				{Name: "quay-pull-secret"},
+				{Name: "registry.example.com"},
+			},
+		},
+		{
+			name: "invalid name",
+			pullSecrets: []addonsv1alpha1.AddonPullSecret{
+				{Name: "Quay_Pull_Secret"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "duplicated",
+			pullSecrets: []addonsv1alpha1.AddonPullSecret{
+				{Name: "quay-pull-secret"},
+				{Name: "quay-pull-secret"},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validatePullSecrets(tc.pullSecrets)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateAdoption(t *testing.T) {
+	testCases := []struct {
+		name      string
+		adoption  *addonsv1alpha1.AddonAdoption
+		expectErr bool
+	}{
+		{
+			name: "no adoption",
+		},
+		{
+			name: "valid",
+			adoption: &addonsv1alpha1.AddonAdoption{
+				Policy: addonsv1alpha1.AdoptionPolicyPrevent,
+				Overrides: []addonsv1alpha1.AddonAdoptionOverride{
+					{Kind: "Namespace", Policy: addonsv1alpha1.AdoptionPolicyAdoptIfLabelled},
+					{Kind: "Subscription", Policy: addonsv1alpha1.AdoptionPolicyAdoptAll},
+				},
+			},
+		},
+		{
+			name: "duplicated",
+			adoption: &addonsv1alpha1.AddonAdoption{
+				Overrides: []addonsv1alpha1.AddonAdoptionOverride{
+					{Kind: "Namespace", Policy: addonsv1alpha1.AdoptionPolicyAdoptIfLabelled},
+					{Kind: "Namespace", Policy: addonsv1alpha1.AdoptionPolicyAdoptAll},
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateAdoption(tc.adoption)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateMaintenanceWindows(t *testing.T) {
+	testCases := []struct {
+		name      string
+		windows   []addonsv1alpha1.AddonMaintenanceWindow
+		expectErr bool
+	}{
+		{
+			name: "no maintenance windows",
+		},
+		{
+			name: "valid",
+			windows: []addonsv1alpha1.AddonMaintenanceWindow{
+				{Schedule: "0 2 * * SAT", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+				{Schedule: "CRON_TZ=Europe/Berlin 0 22 * * 1-5", Duration: metav1.Duration{Duration: time.Hour}},
+			},
+		},
+		{
+			name: "invalid schedule",
+			windows: []addonsv1alpha1.AddonMaintenanceWindow{
+				{Schedule: "every saturday", Duration: metav1.Duration{Duration: time.Hour}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "missing duration",
+			windows: []addonsv1alpha1.AddonMaintenanceWindow{
+				{Schedule: "0 2 * * SAT"},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateMaintenanceWindows(tc.windows)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
 func TestValidateAddonInstallImmutability(t *testing.T) {
 	var (
 		addonName     = "test-addon"
@@ -114,6 +565,57 @@ func TestValidateAddonInstallImmutability(t *testing.T) {
 			}, addonName),
 			expectedErr: nil,
 		},
+		{
+			updatedAddon: testutil.NewAddonWithInstallSpec(addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.OLMAllNamespaces,
+				OLMAllNamespaces: &addonsv1alpha1.AddonInstallOLMAllNamespaces{
+					AddonInstallOLMCommon: addonsv1alpha1.AddonInstallOLMCommon{
+						Namespace:          "reference-addon",
+						PackageName:        addonName,
+						Channel:            "alpha",
+						CatalogSourceImage: catalogSource,
+						Config: &addonsv1alpha1.SubscriptionConfig{ // changed
+							NodeSelector: map[string]string{
+								"node-role.kubernetes.io/infra": "",
+							},
+						},
+					},
+				},
+			}, addonName),
+			expectedErr: nil,
+		},
+		{
+			updatedAddon: testutil.NewAddonWithInstallSpec(addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.OLMAllNamespaces,
+				OLMAllNamespaces: &addonsv1alpha1.AddonInstallOLMAllNamespaces{
+					AddonInstallOLMCommon: addonsv1alpha1.AddonInstallOLMCommon{
+						Namespace:          "reference-addon",
+						PackageName:        addonName,
+						Channel:            "alpha",
+						CatalogSourceImage: catalogSource,
+						UpgradePolicy: &addonsv1alpha1.AddonUpgradePolicy{ // changed
+							Type: addonsv1alpha1.UpgradePolicyManual,
+						},
+					},
+				},
+			}, addonName),
+			expectedErr: nil,
+		},
+		{
+			updatedAddon: testutil.NewAddonWithInstallSpec(addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.OLMAllNamespaces,
+				OLMAllNamespaces: &addonsv1alpha1.AddonInstallOLMAllNamespaces{
+					AddonInstallOLMCommon: addonsv1alpha1.AddonInstallOLMCommon{
+						Namespace:                  "reference-addon",
+						PackageName:                addonName,
+						Channel:                    "alpha",
+						CatalogSourceImage:         catalogSource,
+						ForeignOperatorGroupPolicy: addonsv1alpha1.ForeignOperatorGroupPolicyRemove, // changed
+					},
+				},
+			}, addonName),
+			expectedErr: nil,
+		},
 		{
 			updatedAddon: testutil.NewAddonWithInstallSpec(addonsv1alpha1.AddonInstallSpec{
 				Type: addonsv1alpha1.OLMOwnNamespace,
@@ -127,3 +629,302 @@ func TestValidateAddonInstallImmutability(t *testing.T) {
 		assert.EqualValues(t, tc.expectedErr, err)
 	}
 }
+
+func TestValidateAddonInstallImmutability_Manifests(t *testing.T) {
+	newManifestsAddon := func(namespace, image string) *addonsv1alpha1.Addon {
+		return testutil.NewAddonWithInstallSpec(addonsv1alpha1.AddonInstallSpec{
+			Type: addonsv1alpha1.Manifests,
+			Manifests: &addonsv1alpha1.AddonInstallManifests{
+				Namespace: namespace,
+				Image:     image,
+			},
+		}, "test-addon")
+	}
+	baseAddon := newManifestsAddon("reference-addon", "quay.io/osd-addons/manifests@sha256:123")
+
+	// the source of the manifests may change
+	assert.NoError(t, validateAddonImmutability(
+		newManifestsAddon("reference-addon", "quay.io/osd-addons/manifests@sha256:456"), baseAddon))
+	assert.EqualValues(t, errInstallImmutable, validateAddonImmutability(
+		newManifestsAddon("other-namespace", "quay.io/osd-addons/manifests@sha256:123"), baseAddon))
+}
+
+func TestValidateInstallSpec_HelmInvalid(t *testing.T) {
+	newSpec := func(repositoryURL, version string) addonsv1alpha1.AddonInstallSpec {
+		return addonsv1alpha1.AddonInstallSpec{
+			Type: addonsv1alpha1.Helm,
+			Helm: &addonsv1alpha1.AddonInstallHelm{
+				Namespace: "reference-addon",
+				Chart: addonsv1alpha1.AddonHelmChart{
+					RepositoryURL: repositoryURL,
+					Name:          "reference-addon",
+					Version:       version,
+				},
+			},
+		}
+	}
+
+	assert.Error(t, validateInstallSpec(newSpec("charts.example.com", "1.0.0"), nil))
+	assert.Error(t, validateInstallSpec(newSpec("https://charts.example.com", "latest"), nil))
+}
+
+func TestValidateAddon_TargetNamespaces(t *testing.T) {
+	newAddon := func(targetNamespaces ...string) *addonsv1alpha1.Addon {
+		addon := testutil.NewAddonWithInstallSpec(addonsv1alpha1.AddonInstallSpec{
+			Type: addonsv1alpha1.OLMMultiNamespace,
+			OLMMultiNamespace: &addonsv1alpha1.AddonInstallOLMMultiNamespace{
+				AddonInstallOLMCommon: addonsv1alpha1.AddonInstallOLMCommon{
+					Namespace:          "reference-addon",
+					CatalogSourceImage: "quay.io/osd-addons/reference-addon-index@sha256:123",
+				},
+				TargetNamespaces: targetNamespaces,
+			},
+		}, "test-addon")
+		addon.Spec.Namespaces = []addonsv1alpha1.AddonNamespace{{Name: "tenant-managed"}}
+		return addon
+	}
+	existingNamespaces := map[string]bool{"tenant-existing": true}
+
+	testCases := []struct {
+		name             string
+		targetNamespaces []string
+		expectedErr      error
+	}{
+		{
+			name:             "managed and existing",
+			targetNamespaces: []string{"tenant-managed", "tenant-existing"},
+		},
+		{
+			name:        "missing",
+			expectedErr: errSpecInstallTargetNamespacesRequired,
+		},
+		{
+			name:             "unknown",
+			targetNamespaces: []string{"tenant-managed", "tenant-unknown"},
+			expectedErr:      errSpecInstallTargetNamespaceUnknown,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateAddon(newAddon(tc.targetNamespaces...), nil, existingNamespaces)
+			if tc.expectedErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tc.expectedErr), "unexpected error: %v", err)
+		})
+	}
+
+	assert.Error(t, validateAddon(
+		newAddon("tenant-managed", "tenant-managed"), nil, existingNamespaces))
+	assert.Error(t, validateAddon(
+		newAddon("Invalid_Namespace"), nil, existingNamespaces))
+}
+
+func TestValidateAddonInstallImmutability_Helm(t *testing.T) {
+	newHelmAddon := func(namespace, name, version string) *addonsv1alpha1.Addon {
+		return testutil.NewAddonWithInstallSpec(addonsv1alpha1.AddonInstallSpec{
+			Type: addonsv1alpha1.Helm,
+			Helm: &addonsv1alpha1.AddonInstallHelm{
+				Namespace: namespace,
+				Chart: addonsv1alpha1.AddonHelmChart{
+					RepositoryURL: "https://charts.example.com",
+					Name:          name,
+					Version:       version,
+				},
+			},
+		}, "test-addon")
+	}
+	baseAddon := newHelmAddon("reference-addon", "reference-addon", "1.0.0")
+
+	// upgrades and value changes are allowed
+	upgradedAddon := newHelmAddon("reference-addon", "reference-addon", "1.1.0")
+	upgradedAddon.Spec.Install.Helm.Values = &apiextensionsv1.JSON{Raw: []byte(`{"replicas": 2}`)}
+	assert.NoError(t, validateAddonImmutability(upgradedAddon, baseAddon))
+
+	assert.EqualValues(t, errInstallImmutable, validateAddonImmutability(
+		newHelmAddon("other-namespace", "reference-addon", "1.0.0"), baseAddon))
+	assert.EqualValues(t, errInstallImmutable, validateAddonImmutability(
+		newHelmAddon("reference-addon", "other-chart", "1.0.0"), baseAddon))
+}
+
+func TestValidateAddonInstallImmutability_ClusterExtension(t *testing.T) {
+	newClusterExtensionAddon := func(packageName, channel, version string) *addonsv1alpha1.Addon {
+		return testutil.NewAddonWithInstallSpec(addonsv1alpha1.AddonInstallSpec{
+			Type: addonsv1alpha1.OLMClusterExtension,
+			OLMClusterExtension: &addonsv1alpha1.AddonInstallOLMClusterExtension{
+				Namespace:          "reference-addon",
+				CatalogSourceImage: "quay.io/osd-addons/reference-addon-index@sha256:123",
+				Channel:            channel,
+				PackageName:        packageName,
+				Version:            version,
+				ServiceAccountName: "reference-addon-installer",
+			},
+		}, "test-addon")
+	}
+	baseAddon := newClusterExtensionAddon("reference-addon", "stable", "1.0.0")
+
+	// channel and version may change
+	assert.NoError(t, validateAddonImmutability(
+		newClusterExtensionAddon("reference-addon", "candidate", ">=1.1.0"), baseAddon))
+	assert.EqualValues(t, errInstallImmutable, validateAddonImmutability(
+		newClusterExtensionAddon("other-package", "stable", "1.0.0"), baseAddon))
+}
+
+func TestValidateAddonInstallImmutability_AdditionalPackages(t *testing.T) {
+	newMultiPackageAddon := func(packageName, catalogSourceImage string) *addonsv1alpha1.Addon {
+		return testutil.NewAddonWithInstallSpec(addonsv1alpha1.AddonInstallSpec{
+			Type: addonsv1alpha1.OLMOwnNamespace,
+			OLMOwnNamespace: &addonsv1alpha1.AddonInstallOLMOwnNamespace{
+				AddonInstallOLMCommon: addonsv1alpha1.AddonInstallOLMCommon{
+					Namespace:          "reference-addon",
+					CatalogSourceImage: "quay.io/osd-addons/reference-addon-index@sha256:123",
+					Channel:            "alpha",
+					PackageName:        "reference-addon",
+					AdditionalPackages: []addonsv1alpha1.AddonInstallOLMPackage{
+						{
+							PackageName:        packageName,
+							Channel:            "alpha",
+							CatalogSourceImage: catalogSourceImage,
+						},
+					},
+				},
+			},
+		}, "test-addon")
+	}
+	baseAddon := newMultiPackageAddon(
+		"companion-operator", "quay.io/osd-addons/companion-index@sha256:123")
+
+	// catalog images of additional packages may change
+	assert.NoError(t, validateAddonImmutability(newMultiPackageAddon(
+		"companion-operator", "quay.io/osd-addons/companion-index@sha256:456"), baseAddon))
+	assert.EqualValues(t, errInstallImmutable, validateAddonImmutability(newMultiPackageAddon(
+		"other-operator", "quay.io/osd-addons/companion-index@sha256:123"), baseAddon))
+}
+
+func TestValidateDependencies(t *testing.T) {
+	newAddon := func(name string, dependencies ...string) addonsv1alpha1.Addon {
+		addon := addonsv1alpha1.Addon{}
+		addon.Name = name
+		for _, dependency := range dependencies {
+			addon.Spec.Dependencies = append(addon.Spec.Dependencies,
+				addonsv1alpha1.AddonDependency{Name: dependency})
+		}
+		return addon
+	}
+
+	existingAddons := []addonsv1alpha1.Addon{
+		newAddon("logging"),
+		newAddon("monitoring", "logging"),
+		newAddon("dashboards", "monitoring"),
+	}
+
+	testCases := []struct {
+		name      string
+		addon     addonsv1alpha1.Addon
+		expectErr bool
+	}{
+		{
+			name:  "no dependencies",
+			addon: newAddon("standalone"),
+		},
+		{
+			name:  "dependency chain",
+			addon: newAddon("app", "dashboards", "logging"),
+		},
+		{
+			name:  "missing dependency",
+			addon: newAddon("app", "does-not-exist"),
+		},
+		{
+			name:      "self",
+			addon:     newAddon("app", "app"),
+			expectErr: true,
+		},
+		{
+			name:      "cycle on update",
+			addon:     newAddon("logging", "dashboards"),
+			expectErr: true,
+		},
+		{
+			name:  "update without cycle",
+			addon: newAddon("dashboards", "logging"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateDependencies(&tc.addon, existingAddons)
+			if tc.expectErr {
+				assert.True(t, errors.Is(err, errSpecDependenciesCycle), err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGetExistingTargetNamespaces(t *testing.T) {
+	c := testutil.NewClient()
+	apiReader := testutil.NewClient()
+	apiReader.On("Get",
+		mock.Anything,
+		client.ObjectKey{Name: "tenant-existing"},
+		mock.IsType(&corev1.Namespace{}),
+	).Return(nil)
+	apiReader.On("Get",
+		mock.Anything,
+		client.ObjectKey{Name: "tenant-unknown"},
+		mock.IsType(&corev1.Namespace{}),
+	).Return(k8sApiErrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, "tenant-unknown"))
+
+	r := &AddonWebhookHandler{Client: c, APIReader: apiReader}
+	addon := &addonsv1alpha1.Addon{
+		Spec: addonsv1alpha1.AddonSpec{
+			Namespaces: []addonsv1alpha1.AddonNamespace{{Name: "tenant-managed"}},
+			Install: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.OLMMultiNamespace,
+				OLMMultiNamespace: &addonsv1alpha1.AddonInstallOLMMultiNamespace{
+					TargetNamespaces: []string{"tenant-managed", "tenant-existing", "tenant-unknown"},
+				},
+			},
+		},
+	}
+
+	existingNamespaces, err := r.getExistingTargetNamespaces(context.Background(), addon, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"tenant-existing": true}, existingNamespaces)
+	apiReader.AssertExpectations(t)
+	// Namespaces are not read from the cache.
+	c.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetExistingTargetNamespaces_Update(t *testing.T) {
+	c := testutil.NewClient()
+	c.On("Get",
+		mock.Anything,
+		client.ObjectKey{Name: "tenant-added"},
+		mock.IsType(&corev1.Namespace{}),
+	).Return(nil)
+
+	r := &AddonWebhookHandler{Client: c}
+	addon := &addonsv1alpha1.Addon{
+		Spec: addonsv1alpha1.AddonSpec{
+			Install: addonsv1alpha1.AddonInstallSpec{
+				Type: addonsv1alpha1.OLMMultiNamespace,
+				OLMMultiNamespace: &addonsv1alpha1.AddonInstallOLMMultiNamespace{
+					TargetNamespaces: []string{"tenant-deleted", "tenant-added"},
+				},
+			},
+		},
+	}
+	oldAddon := addon.DeepCopy()
+	oldAddon.Spec.Install.OLMMultiNamespace.TargetNamespaces = []string{"tenant-deleted"}
+
+	// "tenant-deleted" is not looked up again, as it was already a target namespace before.
+	existingNamespaces, err := r.getExistingTargetNamespaces(context.Background(), addon, oldAddon)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"tenant-deleted": true, "tenant-added": true}, existingNamespaces)
+	c.AssertExpectations(t)
+}